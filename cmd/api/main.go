@@ -1,17 +1,56 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/ardipermana59/go-template/config"
+	"github.com/ardipermana59/go-template/internal/analytics"
+	"github.com/ardipermana59/go-template/internal/attachment"
 	"github.com/ardipermana59/go-template/internal/auth"
+	"github.com/ardipermana59/go-template/internal/comment"
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/contentfilter"
+	"github.com/ardipermana59/go-template/internal/follow"
+	"github.com/ardipermana59/go-template/internal/health"
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/ardipermana59/go-template/internal/mail"
+	"github.com/ardipermana59/go-template/internal/media"
 	"github.com/ardipermana59/go-template/internal/middleware"
+	"github.com/ardipermana59/go-template/internal/moderation"
+	"github.com/ardipermana59/go-template/internal/notification"
+	"github.com/ardipermana59/go-template/internal/outbox"
 	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/privacy"
+	"github.com/ardipermana59/go-template/internal/reaction"
+	"github.com/ardipermana59/go-template/internal/realtime"
+	"github.com/ardipermana59/go-template/internal/syndication"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
+	"github.com/ardipermana59/go-template/internal/timeline"
+	"github.com/ardipermana59/go-template/internal/transfer"
 	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/ardipermana59/go-template/internal/webhook"
 	"github.com/ardipermana59/go-template/pkg/database"
+	"github.com/ardipermana59/go-template/pkg/mailer"
+	"github.com/ardipermana59/go-template/pkg/storage"
 	"github.com/gin-gonic/gin"
 )
 
+// Set at link time, e.g.
+// go build -ldflags "-X main.version=1.2.0 -X main.commit=$(git rev-parse HEAD)".
+var (
+	version   string
+	commit    string
+	buildTime string
+)
+
 func main() {
 	cfg, err := config.LoadConfig()
 	if err != nil {
@@ -23,21 +62,202 @@ func main() {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	if err := db.AutoMigrate(&user.User{}, &post.Post{}); err != nil {
+	if err := db.AutoMigrate(&media.Image{}, &media.ImageVariant{}, &user.User{}, &taxonomy.Tag{}, &taxonomy.Category{}, &post.Post{}, &post.PostSlug{}, &comment.Comment{}, &reaction.Reaction{}, &follow.Follow{}, &attachment.Attachment{}, &privacy.DataExport{}, &analytics.PostViewDay{}, &moderation.Report{}, &moderation.Action{}, &notification.Notification{}, &webhook.Subscription{}, &webhook.Delivery{}, &outbox.Message{}, &outbox.Processed{}, &job.Record{}, &job.Schedule{}); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
 	jwtService := auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpireHours)
 
+	fileStorage, err := newStorage(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialise storage:", err)
+	}
+
+	if err := media.CheckFormats(cfg.ImageVariantFormats); err != nil {
+		log.Fatal("Invalid image configuration:", err)
+	}
+	imageWorker := media.NewWorker(cfg.ImageWorkers, 100)
+	mediaService := media.NewService(media.NewRepository(db), fileStorage, imageWorker,
+		cfg.UploadMaxSizeMB, cfg.ImageVariantSizes, cfg.ImageVariantFormats)
+	imageWorker.Start(mediaService)
+
+	outboxDispatcher := outbox.NewDispatcher(outbox.NewRepository(db), outbox.Settings{
+		Interval:    time.Duration(cfg.OutboxPollSeconds) * time.Second,
+		MaxAttempts: cfg.OutboxMaxAttempts,
+		RetryBase:   time.Duration(cfg.OutboxRetryBaseSeconds) * time.Second,
+		Retention:   time.Duration(cfg.OutboxRetentionHours) * time.Hour,
+	})
+
+	jobRepo := job.NewRepository(db)
+	jobQueue := job.NewQueue(jobRepo, job.Settings{
+		Workers:      cfg.JobWorkers,
+		PollInterval: time.Duration(cfg.JobPollSeconds) * time.Second,
+		MaxAttempts:  cfg.JobMaxAttempts,
+		RetryBase:    time.Duration(cfg.JobRetryBaseSeconds) * time.Second,
+		Retention:    time.Duration(cfg.JobRetentionHours) * time.Hour,
+	})
+
 	userRepo := user.NewRepository(db)
-	userService := user.NewService(userRepo, jwtService)
+	userService := user.NewService(userRepo, jwtService, mediaService,
+		user.WithOutbox(outboxDispatcher),
+	)
 	userHandler := user.NewHandler(userService)
 
+	taxonomyRepo := taxonomy.NewRepository(db)
+	taxonomyService := taxonomy.NewService(taxonomyRepo)
+	taxonomyHandler := taxonomy.NewHandler(taxonomyService)
+
+	eventHub := realtime.NewHub(cfg.RealtimeReplaySize)
+	realtimeHandler := realtime.NewHandler(eventHub, time.Duration(cfg.RealtimeHeartbeatSeconds)*time.Second)
+
+	notificationRepo := notification.NewRepository(db)
+	notificationService := notification.NewService(notificationRepo, userRepo,
+		notification.WithPublisher(eventHub),
+	)
+	notificationHandler := notification.NewHandler(notificationService)
+
 	postRepo := post.NewRepository(db)
-	postService := post.NewService(postRepo)
+	moderationRepo := moderation.NewRepository(db)
+
+	contentFilter, err := newContentFilter(cfg, postRepo)
+	if err != nil {
+		log.Fatal("Invalid content filter configuration:", err)
+	}
+
+	commentRepo := comment.NewRepository(db)
+	commentService := comment.NewService(commentRepo, postRepo,
+		cfg.CommentMaxDepth, time.Duration(cfg.CommentEditWindowMinutes)*time.Minute)
+	commentHandler := comment.NewHandler(commentService)
+
+	reactionRepo := reaction.NewRepository(db)
+	reactionService := reaction.NewService(reactionRepo, postRepo, notificationService, cfg.ReactionTypes)
+	reactionHandler := reaction.NewHandler(reactionService)
+
+	attachmentRepo := attachment.NewRepository(db)
+	attachmentService := attachment.NewService(attachmentRepo, postRepo, fileStorage,
+		cfg.UploadMaxSizeMB, cfg.UploadUserQuotaMB, cfg.UploadAllowedTypes)
+	attachmentHandler := attachment.NewHandler(attachmentService)
+
+	analyticsService := analytics.NewService(analytics.NewRepository(db), postRepo,
+		time.Duration(cfg.ViewDedupeWindowMinutes)*time.Minute)
+	viewFlusher := analytics.NewFlusher(time.Duration(cfg.ViewFlushIntervalSeconds) * time.Second)
+	viewFlusher.Start(analyticsService)
+	analyticsHandler := analytics.NewHandler(analyticsService)
+
+	postService := post.NewService(postRepo, taxonomyService, mediaService,
+		post.WithEnricher(commentService),
+		post.WithEnricher(reactionService),
+		post.WithEnricher(analyticsService),
+		post.WithDeleteHook(commentService.DeletePostComments),
+		post.WithDeleteHook(reactionService.DeletePostReactions),
+		post.WithDeleteHook(attachmentService.DeletePostAttachments),
+		post.WithDeleteHook(analyticsService.DeletePostViews),
+		post.WithViewRecorder(analyticsService),
+		post.WithContentFilter(contentFilter),
+		post.WithReviewHook(moderation.ReviewHook(moderationRepo)),
+		post.WithSaveHook(notificationService.NotifyMentions),
+		post.WithEventPublisher(eventHub),
+		post.WithOutbox(outboxDispatcher),
+		post.WithBulkLimit(cfg.BulkMaxOperations),
+		post.WithRequireVersion(cfg.RequireIfMatch),
+	)
 	postHandler := post.NewHandler(postService)
 
+	webhookDispatcher := webhook.NewDispatcher(time.Duration(cfg.WebhookPollSeconds) * time.Second)
+	webhookService := webhook.NewService(webhook.NewRepository(db), webhookDispatcher, webhook.Settings{
+		MaxAttempts:  cfg.WebhookMaxAttempts,
+		RetryBase:    time.Duration(cfg.WebhookRetryBaseSeconds) * time.Second,
+		DisableAfter: cfg.WebhookDisableAfter,
+		Timeout:      time.Duration(cfg.WebhookTimeoutSeconds) * time.Second,
+	})
+	webhookDispatcher.Start(webhookService)
+	webhookHandler := webhook.NewHandler(webhookService)
+
+	if err := mail.CheckFrom(cfg.MailFrom); err != nil {
+		log.Fatal("Invalid mail configuration:", err)
+	}
+	mailTemplates, err := mail.Templates(cfg.MailDefaultLocale)
+	if err != nil {
+		log.Fatal("Failed to parse mail templates:", err)
+	}
+	mailSender, err := newMailer(cfg)
+	if err != nil {
+		log.Fatal("Failed to initialise mailer:", err)
+	}
+	mail.RegisterJobs(jobQueue, mailSender)
+	mailService := mail.NewService(mailTemplates, jobQueue, cfg.MailFrom)
+
+	// Subscribers are registered before the dispatcher starts.
+	webhook.Subscribe(outboxDispatcher, webhookService, postService, userService)
+	mail.Subscribe(outboxDispatcher, mailService, userService, mail.Site{Name: cfg.AppName, URL: cfg.AppURL}, cfg.MailDefaultLocale)
+	outboxDispatcher.Start()
+
+	moderationService := moderation.NewService(moderationRepo, postRepo, postService)
+	moderationHandler := moderation.NewHandler(moderationService)
+
+	followRepo := follow.NewRepository(db)
+	followService := follow.NewService(followRepo, userRepo, notificationService)
+	followHandler := follow.NewHandler(followService)
+
+	timelineService := timeline.NewService(timeline.NewFanOutOnReadStore(db), postService)
+	timelineHandler := timeline.NewHandler(timelineService)
+
+	syndicationService := syndication.NewService(postService, userService, cfg.AppName, cfg.AppURL, cfg.FeedLimit)
+	syndicationHandler := syndication.NewHandler(syndicationService, cfg.AppURL)
+
+	transferHandler := transfer.NewHandler(transfer.NewService(postService, taxonomyService))
+
+	if err := privacy.CheckPolicy(cfg.ErasurePolicy); err != nil {
+		log.Fatal("Invalid privacy configuration:", err)
+	}
+	privacyService := privacy.NewService(privacy.NewRepository(db), fileStorage, jobQueue, userService, postService,
+		cfg.ErasurePolicy, time.Duration(cfg.AccountDeletionGraceDays)*24*time.Hour, time.Duration(cfg.DataExportExpireHours)*time.Hour,
+		privacy.WithSection("profile", userService),
+		privacy.WithSection("posts", postService),
+		privacy.WithSection("comments", commentService),
+		privacy.WithSection("reactions", reactionService),
+		privacy.WithSection("follows", followService),
+		privacy.WithSection("attachments", attachmentService),
+		privacy.WithSection("moderation", moderationService),
+		privacy.WithSection("notifications", notificationService),
+	)
+	if err := privacy.RegisterJobs(jobQueue, privacyService); err != nil {
+		log.Fatal("Failed to register privacy jobs:", err)
+	}
+	privacyHandler := privacy.NewHandler(privacyService, cfg.AppURL)
+
+	jobQueue.Start()
+	jobHandler := job.NewHandler(job.NewService(jobRepo, jobQueue))
+
+	healthService := health.NewService(time.Duration(cfg.HealthCheckTimeoutSeconds)*time.Second,
+		health.WithCheck("database", func(ctx context.Context) error {
+			return database.Ping(ctx, db)
+		}, 0),
+		health.WithBuildInfo(health.ReadBuildInfo(version, commit, buildTime)),
+	)
+	healthHandler := health.NewHandler(healthService)
+
 	r := gin.Default()
+	r.MaxMultipartMemory = 8 << 20
+	// Without trusted proxies the client IP cannot be spoofed with
+	// X-Forwarded-For.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal("Invalid trusted proxies:", err)
+	}
+
+	r.GET("/healthz", healthHandler.Live)
+	r.GET("/readyz", healthHandler.Ready)
+	r.GET("/version", healthHandler.Version)
+
+	if cfg.StorageDriver == "local" {
+		r.Group(cfg.StoragePublicURL, middleware.ImmutableCache()).Static("/", cfg.StorageLocalPath)
+	}
+
+	r.GET("/feeds/posts.rss", syndicationHandler.PostsRSS)
+	r.GET("/feeds/posts.atom", syndicationHandler.PostsAtom)
+	r.GET("/users/:user_id/feed.atom", syndicationHandler.UserAtom)
+
+	ifMatch := etag.IfMatch(cfg.RequireIfMatch)
 
 	api := r.Group("/api/v1")
 	{
@@ -45,41 +265,253 @@ func main() {
 		{
 			authGroup.POST("/register", userHandler.Register)
 			authGroup.POST("/login", userHandler.Login)
+			authGroup.POST("/restore", userHandler.RestoreAccount)
 		}
 
 		protectedGroup := api.Group("")
 		protectedGroup.Use(middleware.AuthMiddleware(jwtService))
+		protectedGroup.Use(middleware.ActiveAccountMiddleware(userService))
 		{
 			protectedGroup.GET("/profile", userHandler.GetProfile)
-			protectedGroup.PUT("/profile", userHandler.UpdateProfile)
+			protectedGroup.PUT("/profile", ifMatch, userHandler.UpdateProfile)
+			protectedGroup.PATCH("/profile", ifMatch, userHandler.PatchProfile)
 			protectedGroup.PUT("/change-password", userHandler.ChangePassword)
+			protectedGroup.PUT("/profile/avatar", userHandler.UpdateAvatar)
+			protectedGroup.DELETE("/profile/avatar", userHandler.DeleteAvatar)
+			protectedGroup.DELETE("/me", privacyHandler.DeleteAccount)
+			protectedGroup.POST("/me/export", privacyHandler.RequestExport)
+			protectedGroup.GET("/me/exports/:id", privacyHandler.GetExport)
+			protectedGroup.GET("/me/exports/:id/download", privacyHandler.DownloadExport)
+			protectedGroup.GET("/me/warnings", moderationHandler.GetMyWarnings)
+
+			protectedGroup.GET("/notifications", notificationHandler.GetNotifications)
+			protectedGroup.PUT("/notifications/read", notificationHandler.MarkAllRead)
+			protectedGroup.PUT("/notifications/:id/read", notificationHandler.MarkRead)
 
 			protectedGroup.GET("/posts/my", postHandler.GetMyPosts)
 			protectedGroup.POST("/posts", postHandler.CreatePost)
-			protectedGroup.PUT("/posts/:id", postHandler.UpdatePost)
+			protectedGroup.PUT("/posts/:id", ifMatch, postHandler.UpdatePost)
+			protectedGroup.PATCH("/posts/:id", ifMatch, postHandler.PatchPost)
+			protectedGroup.POST("/posts/bulk", postHandler.Bulk)
+			protectedGroup.GET("/posts/export", transferHandler.ExportMyPosts)
+			protectedGroup.POST("/posts/import", transferHandler.ImportPosts)
 			protectedGroup.DELETE("/posts/:id", postHandler.DeletePost)
+			protectedGroup.PUT("/posts/:id/cover", postHandler.UpdateCover)
+			protectedGroup.DELETE("/posts/:id/cover", postHandler.DeleteCover)
+			protectedGroup.GET("/posts/:id/stats", analyticsHandler.GetPostStats)
+
+			protectedGroup.POST("/posts/:id/comments", commentHandler.CreateComment)
+			protectedGroup.POST("/posts/:id/reports", moderationHandler.ReportPost)
+			protectedGroup.PUT("/comments/:id", commentHandler.UpdateComment)
+			protectedGroup.DELETE("/comments/:id", commentHandler.DeleteComment)
+
+			protectedGroup.POST("/users/:user_id/follow", followHandler.Follow)
+			protectedGroup.DELETE("/users/:user_id/follow", followHandler.Unfollow)
+			protectedGroup.GET("/feed", timelineHandler.GetFeed)
+
+			protectedGroup.PUT("/posts/:id/reactions/:type", reactionHandler.React)
+			protectedGroup.DELETE("/posts/:id/reactions/:type", reactionHandler.Unreact)
+
+			protectedGroup.POST("/posts/:id/attachments", attachmentHandler.Upload)
+			protectedGroup.DELETE("/attachments/:id", attachmentHandler.DeleteAttachment)
 		}
 
 		publicGroup := api.Group("")
+		publicGroup.Use(middleware.OptionalAuthMiddleware(jwtService))
 		{
 			publicGroup.GET("/posts", postHandler.GetAllPosts)
 			publicGroup.GET("/posts/:id", postHandler.GetPostByID)
+			publicGroup.GET("/posts/by-slug/:slug", postHandler.GetPostBySlug)
 			publicGroup.GET("/users/:user_id/posts", postHandler.GetPostsByUserID)
+			publicGroup.GET("/users/:user_id/followers", followHandler.GetFollowers)
+			publicGroup.GET("/users/:user_id/following", followHandler.GetFollowing)
+			publicGroup.GET("/posts/:id/comments", commentHandler.GetPostComments)
+			publicGroup.GET("/comments/:id/replies", commentHandler.GetReplies)
+			publicGroup.GET("/tags", taxonomyHandler.GetTags)
+			publicGroup.GET("/categories", taxonomyHandler.GetCategories)
+			publicGroup.GET("/posts/:id/attachments", attachmentHandler.GetPostAttachments)
+		}
+
+		streamGroup := api.Group("/stream")
+		streamGroup.Use(middleware.QueryTokenMiddleware())
+		streamGroup.Use(middleware.AuthMiddleware(jwtService))
+		streamGroup.Use(middleware.ActiveAccountMiddleware(userService))
+		{
+			streamGroup.GET("", realtimeHandler.Stream)
+			streamGroup.GET("/ws", realtimeHandler.WebSocket)
+		}
+
+		moderationGroup := api.Group("/moderation")
+		moderationGroup.Use(middleware.AuthMiddleware(jwtService))
+		moderationGroup.Use(middleware.ActiveAccountMiddleware(userService))
+		moderationGroup.Use(middleware.RoleMiddleware("admin", "moderator"))
+		{
+			moderationGroup.GET("/queue", moderationHandler.GetQueue)
+			moderationGroup.POST("/posts/:id/actions", moderationHandler.TakeAction)
 		}
 
 		adminGroup := api.Group("/admin")
 		adminGroup.Use(middleware.AuthMiddleware(jwtService))
+		adminGroup.Use(middleware.ActiveAccountMiddleware(userService))
 		adminGroup.Use(middleware.RoleMiddleware("admin"))
 		{
 			adminGroup.GET("/users", userHandler.GetAllUsers)
 			adminGroup.GET("/users/:id", userHandler.GetUserByID)
-			adminGroup.PUT("/users/:id", userHandler.UpdateUser)
+			adminGroup.PUT("/users/:id", ifMatch, userHandler.UpdateUser)
+			adminGroup.PATCH("/users/:id", ifMatch, userHandler.PatchUser)
 			adminGroup.DELETE("/users/:id", userHandler.DeleteUser)
+			adminGroup.GET("/posts/export", transferHandler.ExportAllPosts)
+
+			adminGroup.PUT("/tags/:id", taxonomyHandler.RenameTag)
+			adminGroup.POST("/tags/merge", taxonomyHandler.MergeTags)
+			adminGroup.POST("/categories", taxonomyHandler.CreateCategory)
+			adminGroup.PUT("/categories/:id", taxonomyHandler.UpdateCategory)
+			adminGroup.DELETE("/categories/:id", taxonomyHandler.DeleteCategory)
+
+			adminGroup.POST("/webhooks", webhookHandler.CreateSubscription)
+			adminGroup.GET("/webhooks", webhookHandler.GetSubscriptions)
+			adminGroup.GET("/webhooks/:id", webhookHandler.GetSubscription)
+			adminGroup.PUT("/webhooks/:id", webhookHandler.UpdateSubscription)
+			adminGroup.DELETE("/webhooks/:id", webhookHandler.DeleteSubscription)
+			adminGroup.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+			adminGroup.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+
+			adminGroup.GET("/jobs", jobHandler.GetJobs)
+			adminGroup.GET("/jobs/:id", jobHandler.GetJob)
+			adminGroup.POST("/jobs/:id/retry", jobHandler.RetryJob)
 		}
 	}
 
-	log.Printf("🚀 Server running on port %s", cfg.ServerPort)
-	if err := r.Run(":" + cfg.ServerPort); err != nil {
-		log.Fatal("Failed to start server:", err)
+	srv := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           r,
+		ReadTimeout:       time.Duration(cfg.ServerReadTimeoutSeconds) * time.Second,
+		ReadHeaderTimeout: time.Duration(cfg.ServerReadHeaderTimeoutSeconds) * time.Second,
+		WriteTimeout:      time.Duration(cfg.ServerWriteTimeoutSeconds) * time.Second,
+		IdleTimeout:       time.Duration(cfg.ServerIdleTimeoutSeconds) * time.Second,
+		MaxHeaderBytes:    cfg.ServerMaxHeaderBytes,
+	}
+	// Event streams never finish on their own and would hold Shutdown until
+	// its deadline.
+	srv.RegisterOnShutdown(eventHub.Close)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Printf("🚀 Server running on port %s", cfg.ServerPort)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	signals, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	failed := false
+	select {
+	case <-signals.Done():
+		log.Println("Shutting down...")
+	case err := <-serverErr:
+		log.Println("Server failed:", err)
+		failed = true
+	}
+	// A second signal stops the process right away.
+	stopSignals()
+
+	healthService.ShutDown()
+	if !failed && cfg.ShutdownDelaySeconds > 0 {
+		time.Sleep(time.Duration(cfg.ShutdownDelaySeconds) * time.Second)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.ShutdownTimeoutSeconds)*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Println("Failed to drain HTTP connections:", err)
+	}
+
+	// Workers stop after the requests that feed them, and before the
+	// workers they feed: the outbox queues webhook deliveries and jobs.
+	stopWithin(ctx, "view flusher", viewFlusher.Stop)
+	stopWithin(ctx, "image worker", imageWorker.Stop)
+	stopWithin(ctx, "outbox dispatcher", outboxDispatcher.Stop)
+	stopWithin(ctx, "webhook dispatcher", webhookDispatcher.Stop)
+	stopWithin(ctx, "job queue", jobQueue.Stop)
+
+	if err := database.Close(db); err != nil {
+		log.Println("Failed to close database:", err)
+	}
+	log.Println("Server stopped")
+	if failed {
+		cancel()
+		os.Exit(1)
+	}
+}
+
+// stopWithin calls stop and waits for it until ctx ends. Work still running
+// after the deadline is abandoned; persisted work is picked up again after
+// the restart.
+func stopWithin(ctx context.Context, name string, stop func()) {
+	done := make(chan struct{})
+	go func() {
+		stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		log.Printf("The %s did not stop before the shutdown deadline", name)
+	}
+}
+
+func newContentFilter(cfg *config.Config, postRepo post.Repository) (contentfilter.Filter, error) {
+	duplicates, err := contentfilter.ParseVerdict(cfg.ContentFilterDuplicates)
+	if err != nil {
+		return nil, fmt.Errorf("CONTENT_FILTER_DUPLICATES: %w", err)
+	}
+
+	pipeline := contentfilter.Pipeline{
+		contentfilter.WordList(cfg.ContentFilterBlockedWords, contentfilter.Reject),
+		contentfilter.WordList(cfg.ContentFilterFlaggedWords, contentfilter.Flag),
+		contentfilter.LinkLimit(cfg.ContentFilterMaxLinks, contentfilter.Flag),
+	}
+	if duplicates != contentfilter.Allow {
+		pipeline = append(pipeline, contentfilter.Duplicate(post.HashLookup(postRepo), duplicates))
+	}
+	return pipeline, nil
+}
+
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	switch cfg.StorageDriver {
+	case "s3":
+		return storage.NewS3Storage(storage.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PathStyle: cfg.S3PathStyle,
+			PublicURL: cfg.S3PublicURL,
+
+			CacheControl: "public, max-age=31536000, immutable",
+		}, nil)
+	case "local":
+		return storage.NewLocalStorage(cfg.StorageLocalPath, cfg.StoragePublicURL)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
+
+func newMailer(cfg *config.Config) (mailer.Mailer, error) {
+	switch cfg.MailDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+			From:     cfg.MailFrom,
+		})
+	case "file":
+		return mailer.NewFileMailer(cfg.MailFilePath, cfg.MailFrom)
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.MailDriver)
 	}
 }
@@ -0,0 +1,89 @@
+// Command import loads posts from an NDJSON or CSV file into the account of
+// one user, using the same validation and external ID rules as
+// POST /api/v1/posts/import.
+//
+//	go run ./cmd/import -user 1 [-format csv] posts.csv
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ardipermana59/go-template/config"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
+	"github.com/ardipermana59/go-template/internal/transfer"
+	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/ardipermana59/go-template/pkg/database"
+)
+
+func main() {
+	userID := flag.Uint("user", 0, "ID of the user that will own the imported posts")
+	format := flag.String("format", "", "file format (ndjson or csv), detected from the extension by default")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s -user ID [-format ndjson|csv] FILE\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *userID == 0 || flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	path := flag.Arg(0)
+	if *format == "" {
+		*format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		if *format == "jsonl" {
+			*format = transfer.FormatNDJSON
+		}
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	db, err := database.NewDatabase(cfg.GetDSN())
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	if _, err := user.NewRepository(db).FindByID(uint(*userID)); err != nil {
+		log.Fatalf("Failed to find user %d: %v", *userID, err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Failed to open file:", err)
+	}
+	defer file.Close()
+
+	// Imports only create posts, so the post service needs neither media
+	// handling nor enrichers.
+	taxonomyService := taxonomy.NewService(taxonomy.NewRepository(db))
+	postService := post.NewService(post.NewRepository(db), taxonomyService, nil)
+	service := transfer.NewService(postService, taxonomyService)
+
+	result, appErr := service.Import(file, *format, uint(*userID))
+	if result != nil {
+		for _, row := range result.Errors {
+			for _, e := range row.Errors {
+				fmt.Fprintf(os.Stderr, "line %d %s: %s: %s\n", row.Line, row.ExternalID, e.Field, e.Message)
+			}
+		}
+		fmt.Printf("created: %d, skipped: %d, failed: %d\n", result.Created, result.Skipped, result.Failed)
+	}
+	if appErr != nil {
+		for _, e := range appErr {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", e.Field, e.Message)
+		}
+		os.Exit(1)
+	}
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
+	AppName string
+	AppURL  string
+
 	DBHost         string
 	DBPort         string
 	DBUser         string
@@ -17,14 +21,167 @@ type Config struct {
 	ServerPort     string
 	JWTSecret      string
 	JWTExpireHours int
+
+	ServerReadTimeoutSeconds       int
+	ServerReadHeaderTimeoutSeconds int
+	ServerWriteTimeoutSeconds      int
+	ServerIdleTimeoutSeconds       int
+	ServerMaxHeaderBytes           int
+	// TrustedProxies lists the proxy addresses or CIDRs whose
+	// X-Forwarded-For header is believed. Empty trusts none, so the client
+	// IP is the address of the connection.
+	TrustedProxies []string
+	// ShutdownTimeoutSeconds bounds draining requests and stopping the
+	// background workers after SIGINT or SIGTERM.
+	ShutdownTimeoutSeconds int
+	// ShutdownDelaySeconds is how long /readyz reports not ready before
+	// the server stops accepting connections, giving load balancers time
+	// to take the instance out of rotation.
+	ShutdownDelaySeconds int
+	// HealthCheckTimeoutSeconds bounds each dependency check of /readyz.
+	HealthCheckTimeoutSeconds int
+
+	// RequireIfMatch rejects updates without an If-Match header.
+	RequireIfMatch bool
+
+	CommentMaxDepth          int
+	CommentEditWindowMinutes int
+
+	ReactionTypes []string
+
+	FeedLimit int
+
+	ViewDedupeWindowMinutes  int
+	ViewFlushIntervalSeconds int
+
+	BulkMaxOperations int
+
+	// RealtimeReplaySize is the number of recent events kept for clients
+	// resuming a stream.
+	RealtimeReplaySize       int
+	RealtimeHeartbeatSeconds int
+
+	OutboxPollSeconds      int
+	OutboxMaxAttempts      int
+	OutboxRetryBaseSeconds int
+	OutboxRetentionHours   int
+
+	// JobWorkers is the number of jobs run at once by this instance.
+	JobWorkers          int
+	JobPollSeconds      int
+	JobMaxAttempts      int
+	JobRetryBaseSeconds int
+	JobRetentionHours   int
+
+	WebhookMaxAttempts      int
+	WebhookTimeoutSeconds   int
+	WebhookRetryBaseSeconds int
+	WebhookPollSeconds      int
+	// WebhookDisableAfter disables a subscription after this many failed
+	// attempts in a row; 0 never disables.
+	WebhookDisableAfter int
+
+	ContentFilterBlockedWords []string
+	ContentFilterFlaggedWords []string
+	ContentFilterMaxLinks     int
+	// ContentFilterDuplicates is the verdict for repeated content: "allow",
+	// "flag" or "reject".
+	ContentFilterDuplicates string
+
+	// ErasurePolicy is "anonymize" or "delete"; see internal/privacy.
+	ErasurePolicy            string
+	AccountDeletionGraceDays int
+	DataExportExpireHours    int
+
+	StorageDriver    string
+	StorageLocalPath string
+	StoragePublicURL string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3PathStyle      bool
+	S3PublicURL      string
+
+	UploadMaxSizeMB    int
+	UploadUserQuotaMB  int
+	UploadAllowedTypes []string
+
+	ImageVariantSizes   []int
+	ImageVariantFormats []string
+	ImageWorkers        int
+
+	// MailDriver is "smtp" or "file"; the file driver writes .eml files
+	// to MailFilePath instead of sending them.
+	MailDriver        string
+	MailFrom          string
+	MailDefaultLocale string
+	MailFilePath      string
+	SMTPHost          string
+	SMTPPort          int
+	SMTPUsername      string
+	SMTPPassword      string
+	SMTPTLS           string
 }
 
 func LoadConfig() (*Config, error) {
 	godotenv.Load()
 
 	expireHours, _ := strconv.Atoi(getEnv("JWT_EXPIRE_HOURS", "24"))
+	serverReadTimeout, _ := strconv.Atoi(getEnv("SERVER_READ_TIMEOUT_SECONDS", "30"))
+	serverReadHeaderTimeout, _ := strconv.Atoi(getEnv("SERVER_READ_HEADER_TIMEOUT_SECONDS", "10"))
+	serverWriteTimeout, _ := strconv.Atoi(getEnv("SERVER_WRITE_TIMEOUT_SECONDS", "60"))
+	serverIdleTimeout, _ := strconv.Atoi(getEnv("SERVER_IDLE_TIMEOUT_SECONDS", "120"))
+	serverMaxHeaderBytes, _ := strconv.Atoi(getEnv("SERVER_MAX_HEADER_BYTES", "1048576"))
+	shutdownTimeout, _ := strconv.Atoi(getEnv("SHUTDOWN_TIMEOUT_SECONDS", "30"))
+	shutdownDelay, _ := strconv.Atoi(getEnv("SHUTDOWN_DELAY_SECONDS", "5"))
+	healthCheckTimeout, _ := strconv.Atoi(getEnv("HEALTH_CHECK_TIMEOUT_SECONDS", "2"))
+	commentMaxDepth, _ := strconv.Atoi(getEnv("COMMENT_MAX_DEPTH", "3"))
+	commentEditWindow, _ := strconv.Atoi(getEnv("COMMENT_EDIT_WINDOW_MINUTES", "15"))
+	uploadMaxSize, _ := strconv.Atoi(getEnv("UPLOAD_MAX_SIZE_MB", "10"))
+	uploadUserQuota, _ := strconv.Atoi(getEnv("UPLOAD_USER_QUOTA_MB", "100"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "1025"))
+	s3PathStyle, _ := strconv.ParseBool(getEnv("S3_PATH_STYLE", "true"))
+	imageWorkers, _ := strconv.Atoi(getEnv("IMAGE_WORKERS", "2"))
+	feedLimit, _ := strconv.Atoi(getEnv("FEED_LIMIT", "20"))
+	viewDedupeWindow, _ := strconv.Atoi(getEnv("VIEW_DEDUPE_WINDOW_MINUTES", "30"))
+	viewFlushInterval, _ := strconv.Atoi(getEnv("VIEW_FLUSH_INTERVAL_SECONDS", "10"))
+	bulkMaxOperations, _ := strconv.Atoi(getEnv("BULK_MAX_OPERATIONS", "100"))
+	realtimeReplaySize, _ := strconv.Atoi(getEnv("REALTIME_REPLAY_SIZE", "1000"))
+	realtimeHeartbeat, _ := strconv.Atoi(getEnv("REALTIME_HEARTBEAT_SECONDS", "25"))
+	outboxPoll, _ := strconv.Atoi(getEnv("OUTBOX_POLL_SECONDS", "1"))
+	outboxMaxAttempts, _ := strconv.Atoi(getEnv("OUTBOX_MAX_ATTEMPTS", "10"))
+	outboxRetryBase, _ := strconv.Atoi(getEnv("OUTBOX_RETRY_BASE_SECONDS", "5"))
+	outboxRetention, _ := strconv.Atoi(getEnv("OUTBOX_RETENTION_HOURS", "168"))
+	jobWorkers, _ := strconv.Atoi(getEnv("JOB_WORKERS", "4"))
+	jobPoll, _ := strconv.Atoi(getEnv("JOB_POLL_SECONDS", "5"))
+	jobMaxAttempts, _ := strconv.Atoi(getEnv("JOB_MAX_ATTEMPTS", "5"))
+	jobRetryBase, _ := strconv.Atoi(getEnv("JOB_RETRY_BASE_SECONDS", "10"))
+	jobRetention, _ := strconv.Atoi(getEnv("JOB_RETENTION_HOURS", "168"))
+	webhookMaxAttempts, _ := strconv.Atoi(getEnv("WEBHOOK_MAX_ATTEMPTS", "8"))
+	webhookTimeout, _ := strconv.Atoi(getEnv("WEBHOOK_TIMEOUT_SECONDS", "10"))
+	webhookRetryBase, _ := strconv.Atoi(getEnv("WEBHOOK_RETRY_BASE_SECONDS", "30"))
+	webhookPoll, _ := strconv.Atoi(getEnv("WEBHOOK_POLL_SECONDS", "15"))
+	webhookDisableAfter, _ := strconv.Atoi(getEnv("WEBHOOK_DISABLE_AFTER_FAILURES", "20"))
+	contentFilterMaxLinks, _ := strconv.Atoi(getEnv("CONTENT_FILTER_MAX_LINKS", "5"))
+	deletionGraceDays, _ := strconv.Atoi(getEnv("ACCOUNT_DELETION_GRACE_DAYS", "30"))
+	dataExportExpire, _ := strconv.Atoi(getEnv("DATA_EXPORT_EXPIRE_HOURS", "48"))
+	requireIfMatch, _ := strconv.ParseBool(getEnv("REQUIRE_IF_MATCH", "true"))
+
+	var imageSizes []int
+	for _, value := range getEnvList("IMAGE_VARIANT_SIZES", "128,512,1024") {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid IMAGE_VARIANT_SIZES value %q", value)
+		}
+		imageSizes = append(imageSizes, size)
+	}
 
 	config := &Config{
+		AppName: getEnv("APP_NAME", "Go Template"),
+		AppURL:  strings.TrimSuffix(getEnv("APP_URL", "http://localhost:8080"), "/"),
+
 		DBHost:         getEnv("DB_HOST", "localhost"),
 		DBPort:         getEnv("DB_PORT", "3306"),
 		DBUser:         getEnv("DB_USER", "root"),
@@ -33,6 +190,88 @@ func LoadConfig() (*Config, error) {
 		ServerPort:     getEnv("SERVER_PORT", "8080"),
 		JWTSecret:      getEnv("JWT_SECRET", "your-secret-key"),
 		JWTExpireHours: expireHours,
+
+		ServerReadTimeoutSeconds:       serverReadTimeout,
+		ServerReadHeaderTimeoutSeconds: serverReadHeaderTimeout,
+		ServerWriteTimeoutSeconds:      serverWriteTimeout,
+		ServerIdleTimeoutSeconds:       serverIdleTimeout,
+		ServerMaxHeaderBytes:           serverMaxHeaderBytes,
+		TrustedProxies:                 getEnvList("TRUSTED_PROXIES", ""),
+		ShutdownTimeoutSeconds:         shutdownTimeout,
+		ShutdownDelaySeconds:           shutdownDelay,
+		HealthCheckTimeoutSeconds:      healthCheckTimeout,
+
+		RequireIfMatch: requireIfMatch,
+
+		CommentMaxDepth:          commentMaxDepth,
+		CommentEditWindowMinutes: commentEditWindow,
+
+		ReactionTypes: getEnvList("REACTION_TYPES", "like,love,laugh,wow,sad,angry"),
+
+		FeedLimit: feedLimit,
+
+		ViewDedupeWindowMinutes:  viewDedupeWindow,
+		ViewFlushIntervalSeconds: viewFlushInterval,
+
+		BulkMaxOperations: bulkMaxOperations,
+
+		RealtimeReplaySize:       realtimeReplaySize,
+		RealtimeHeartbeatSeconds: realtimeHeartbeat,
+
+		OutboxPollSeconds:      outboxPoll,
+		OutboxMaxAttempts:      outboxMaxAttempts,
+		OutboxRetryBaseSeconds: outboxRetryBase,
+		OutboxRetentionHours:   outboxRetention,
+
+		JobWorkers:          jobWorkers,
+		JobPollSeconds:      jobPoll,
+		JobMaxAttempts:      jobMaxAttempts,
+		JobRetryBaseSeconds: jobRetryBase,
+		JobRetentionHours:   jobRetention,
+
+		WebhookMaxAttempts:      webhookMaxAttempts,
+		WebhookTimeoutSeconds:   webhookTimeout,
+		WebhookRetryBaseSeconds: webhookRetryBase,
+		WebhookPollSeconds:      webhookPoll,
+		WebhookDisableAfter:     webhookDisableAfter,
+
+		ContentFilterBlockedWords: getEnvList("CONTENT_FILTER_BLOCKED_WORDS", ""),
+		ContentFilterFlaggedWords: getEnvList("CONTENT_FILTER_FLAGGED_WORDS", ""),
+		ContentFilterMaxLinks:     contentFilterMaxLinks,
+		ContentFilterDuplicates:   getEnv("CONTENT_FILTER_DUPLICATES", "flag"),
+
+		ErasurePolicy:            getEnv("ERASURE_POLICY", "anonymize"),
+		AccountDeletionGraceDays: deletionGraceDays,
+		DataExportExpireHours:    dataExportExpire,
+
+		StorageDriver:    getEnv("STORAGE_DRIVER", "local"),
+		StorageLocalPath: getEnv("STORAGE_LOCAL_PATH", "./uploads"),
+		StoragePublicURL: getEnv("STORAGE_PUBLIC_URL", "/media"),
+		S3Endpoint:       getEnv("S3_ENDPOINT", ""),
+		S3Region:         getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:         getEnv("S3_BUCKET", ""),
+		S3AccessKey:      getEnv("S3_ACCESS_KEY", ""),
+		S3SecretKey:      getEnv("S3_SECRET_KEY", ""),
+		S3PathStyle:      s3PathStyle,
+		S3PublicURL:      getEnv("S3_PUBLIC_URL", ""),
+
+		UploadMaxSizeMB:    uploadMaxSize,
+		UploadUserQuotaMB:  uploadUserQuota,
+		UploadAllowedTypes: getEnvList("UPLOAD_ALLOWED_TYPES", "image/jpeg,image/png,image/gif,image/webp,application/pdf"),
+
+		ImageVariantSizes:   imageSizes,
+		ImageVariantFormats: getEnvList("IMAGE_VARIANT_FORMATS", "webp,jpeg"),
+		ImageWorkers:        imageWorkers,
+
+		MailDriver:        getEnv("MAIL_DRIVER", "file"),
+		MailFrom:          getEnv("MAIL_FROM", "Go Template <no-reply@localhost>"),
+		MailDefaultLocale: getEnv("MAIL_DEFAULT_LOCALE", "en"),
+		MailFilePath:      getEnv("MAIL_FILE_PATH", "./mail"),
+		SMTPHost:          getEnv("SMTP_HOST", "localhost"),
+		SMTPPort:          smtpPort,
+		SMTPUsername:      getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:      getEnv("SMTP_PASSWORD", ""),
+		SMTPTLS:           getEnv("SMTP_TLS", "none"),
 	}
 
 	return config, nil
@@ -54,3 +293,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvList(key, defaultValue string) []string {
+	var values []string
+	for _, value := range strings.Split(getEnv(key, defaultValue), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
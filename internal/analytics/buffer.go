@@ -0,0 +1,129 @@
+package analytics
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+type viewKey struct {
+	postID uint
+	day    time.Time
+}
+
+// buffer de-duplicates views and counts them in memory until they are
+// flushed. Both the de-duplication window and the counts are local to the
+// process.
+type buffer struct {
+	window time.Duration
+
+	mu      sync.Mutex
+	seen    map[string]time.Time
+	pending map[viewKey]int64
+}
+
+func newBuffer(window time.Duration) *buffer {
+	return &buffer{
+		window:  window,
+		seen:    make(map[string]time.Time),
+		pending: make(map[viewKey]int64),
+	}
+}
+
+// add counts a view unless viewer already viewed the post within the
+// window. It reports whether the view was counted.
+func (b *buffer) add(postID uint, viewer string) bool {
+	now := time.Now()
+	seenKey := viewer + "|" + strconv.FormatUint(uint64(postID), 10)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if last, ok := b.seen[seenKey]; ok && now.Sub(last) < b.window {
+		return false
+	}
+	b.seen[seenKey] = now
+	b.pending[viewKey{postID: postID, day: day(now)}]++
+	return true
+}
+
+// take returns the pending counts and starts a new batch. Entries of the
+// de-duplication map that are outside the window are dropped as well.
+func (b *buffer) take() []PostViewDay {
+	now := time.Now()
+
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = make(map[viewKey]int64)
+	for key, last := range b.seen {
+		if now.Sub(last) >= b.window {
+			delete(b.seen, key)
+		}
+	}
+	b.mu.Unlock()
+
+	rows := make([]PostViewDay, 0, len(pending))
+	for key, views := range pending {
+		rows = append(rows, PostViewDay{PostID: key.postID, Day: key.day, Views: views})
+	}
+	return rows
+}
+
+// restore puts rows back after a failed flush so they are retried.
+func (b *buffer) restore(rows []PostViewDay) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, row := range rows {
+		b.pending[viewKey{postID: row.PostID, day: row.Day}] += row.Views
+	}
+}
+
+// counts returns the pending views of each of postIDs.
+func (b *buffer) counts(postIDs []uint) map[uint]int64 {
+	wanted := make(map[uint]bool, len(postIDs))
+	for _, id := range postIDs {
+		wanted[id] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	counts := make(map[uint]int64)
+	for key, views := range b.pending {
+		if wanted[key.postID] {
+			counts[key.postID] += views
+		}
+	}
+	return counts
+}
+
+// daily returns the pending views of a post by day.
+func (b *buffer) daily(postID uint) map[time.Time]int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	views := make(map[time.Time]int64)
+	for key, count := range b.pending {
+		if key.postID == postID {
+			views[key.day] += count
+		}
+	}
+	return views
+}
+
+// drop forgets the pending views of a deleted post.
+func (b *buffer) drop(postID uint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for key := range b.pending {
+		if key.postID == postID {
+			delete(b.pending, key)
+		}
+	}
+}
+
+// day returns the UTC calendar day of t. The result is midnight in the local
+// time zone because the database connection converts times to local time,
+// which would move UTC midnight to the previous day west of Greenwich.
+func day(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,55 @@
+package analytics
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Flusher writes buffered view counts every interval.
+type Flusher struct {
+	interval time.Duration
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewFlusher(interval time.Duration) *Flusher {
+	return &Flusher{
+		interval: interval,
+		stop:     make(chan struct{}),
+	}
+}
+
+func (f *Flusher) Start(service Service) {
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		ticker := time.NewTicker(f.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				flush(service)
+			case <-f.stop:
+				flush(service)
+				return
+			}
+		}
+	}()
+}
+
+// Stop flushes the remaining counts and waits for the flusher to exit.
+func (f *Flusher) Stop() {
+	f.once.Do(func() {
+		close(f.stop)
+	})
+	f.wg.Wait()
+}
+
+func flush(service Service) {
+	if err := service.Flush(); err != nil {
+		log.Printf("analytics: failed to flush view counts: %v", err)
+	}
+}
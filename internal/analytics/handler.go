@@ -0,0 +1,48 @@
+package analytics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetPostStats(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var query StatsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	stats, appErr := h.service.GetStats(uint(id), c.GetUint("user_id"), query.Days)
+	if appErr != nil {
+		if appErr.Has("post") {
+			response.Error(c, http.StatusNotFound, "Post not found", appErr)
+			return
+		}
+		if appErr.Has("ownership") {
+			response.Error(c, http.StatusForbidden, "Failed to retrieve post statistics", appErr)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve post statistics", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Post statistics retrieved successfully", stats)
+}
@@ -0,0 +1,40 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+)
+
+const (
+	DefaultStatsDays = 30
+	MaxStatsDays     = 365
+
+	dateFormat = "2006-01-02"
+)
+
+// PostViewDay holds the number of counted views of a post on one UTC day.
+type PostViewDay struct {
+	PostID uint      `gorm:"primaryKey;autoIncrement:false"`
+	Post   post.Post `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Day    time.Time `gorm:"type:date;primaryKey"`
+	Views  int64     `gorm:"not null;default:0"`
+}
+
+// StatsQuery selects how many days, ending today, GetStats returns.
+type StatsQuery struct {
+	Days int `form:"days" binding:"omitempty,min=1,max=365"`
+}
+
+type StatsResponse struct {
+	PostID    uint         `json:"post_id"`
+	ViewCount int64        `json:"view_count"`
+	From      string       `json:"from"`
+	To        string       `json:"to"`
+	Daily     []DailyViews `json:"daily"`
+}
+
+type DailyViews struct {
+	Date  string `json:"date"`
+	Views int64  `json:"views"`
+}
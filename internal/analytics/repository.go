@@ -0,0 +1,106 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// AddViews adds the counts to the stored totals in one statement. Rows of
+	// posts that were deleted in the meantime are dropped.
+	AddViews(rows []PostViewDay) error
+	SumByPostIDs(postIDs []uint) (map[uint]int64, error)
+	FindDaily(postID uint, from, to time.Time) ([]PostViewDay, error)
+	DeleteByPostID(postID uint) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) AddViews(rows []PostViewDay) error {
+	if len(rows) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.PostID)
+	}
+	var existing []uint
+	if err := r.db.Model(&post.Post{}).Where("id IN ?", ids).Pluck("id", &existing).Error; err != nil {
+		return err
+	}
+	exists := make(map[uint]bool, len(existing))
+	for _, id := range existing {
+		exists[id] = true
+	}
+
+	kept := rows[:0]
+	for _, row := range rows {
+		if exists[row.PostID] {
+			kept = append(kept, row)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	return r.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"views": gorm.Expr("views + VALUES(views)"),
+			}),
+		}).
+		Create(&kept).Error
+}
+
+func (r *repository) SumByPostIDs(postIDs []uint) (map[uint]int64, error) {
+	sums := make(map[uint]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return sums, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Views  int64
+	}
+	err := r.db.Model(&PostViewDay{}).
+		Select("post_id, SUM(views) AS views").
+		Where("post_id IN ?", postIDs).
+		Group("post_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		sums[row.PostID] = row.Views
+	}
+	return sums, nil
+}
+
+func (r *repository) FindDaily(postID uint, from, to time.Time) ([]PostViewDay, error) {
+	var rows []PostViewDay
+	err := r.db.
+		Where("post_id = ? AND day BETWEEN ? AND ?", postID, from.Format(dateFormat), to.Format(dateFormat)).
+		Order("day").
+		Find(&rows).Error
+	return rows, err
+}
+
+func (r *repository) DeleteByPostID(postID uint) error {
+	return r.db.Where("post_id = ?", postID).Delete(&PostViewDay{}).Error
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
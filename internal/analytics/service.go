@@ -0,0 +1,133 @@
+package analytics
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/post"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	// RecordView is registered as a post.ViewRecorder. It only touches
+	// memory; counts reach the database with the next Flush.
+	RecordView(postID uint, viewer string)
+	// Flush writes the buffered counts in one batch.
+	Flush() error
+	// EnrichPosts fills in PostResponse.ViewCount, including views that
+	// were not flushed yet.
+	EnrichPosts(posts []*post.PostResponse, viewerID uint) error
+	// DeletePostViews is registered as a post.DeleteHook.
+	DeletePostViews(tx *gorm.DB, p *post.Post) error
+	// GetStats returns daily view counts of the last days days. Only the
+	// author of the post may read them.
+	GetStats(postID, userID uint, days int) (*StatsResponse, apperror.AppErrors)
+}
+
+type service struct {
+	repo     Repository
+	postRepo post.Repository
+	buffer   *buffer
+}
+
+func NewService(repo Repository, postRepo post.Repository, dedupeWindow time.Duration) Service {
+	return &service{
+		repo:     repo,
+		postRepo: postRepo,
+		buffer:   newBuffer(dedupeWindow),
+	}
+}
+
+func (s *service) RecordView(postID uint, viewer string) {
+	s.buffer.add(postID, viewer)
+}
+
+func (s *service) Flush() error {
+	rows := s.buffer.take()
+	if err := s.repo.AddViews(rows); err != nil {
+		s.buffer.restore(rows)
+		return err
+	}
+	return nil
+}
+
+func (s *service) EnrichPosts(posts []*post.PostResponse, viewerID uint) error {
+	ids := make([]uint, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+
+	sums, err := s.repo.SumByPostIDs(ids)
+	if err != nil {
+		return err
+	}
+	pending := s.buffer.counts(ids)
+
+	for _, p := range posts {
+		p.ViewCount = sums[p.ID] + pending[p.ID]
+	}
+	return nil
+}
+
+func (s *service) DeletePostViews(tx *gorm.DB, p *post.Post) error {
+	if err := s.repo.WithTx(tx).DeleteByPostID(p.ID); err != nil {
+		return err
+	}
+	post.AfterCommit(tx, func() {
+		s.buffer.drop(p.ID)
+	})
+	return nil
+}
+
+func (s *service) GetStats(postID, userID uint, days int) (*StatsResponse, apperror.AppErrors) {
+	p, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if p.UserID != userID {
+		return nil, apperror.OwnershipRequired()
+	}
+
+	if days < 1 {
+		days = DefaultStatsDays
+	}
+	to := day(time.Now())
+	from := to.AddDate(0, 0, 1-days)
+
+	rows, err := s.repo.FindDaily(postID, from, to)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	sums, err := s.repo.SumByPostIDs([]uint{postID})
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	// Days are matched by their date because the database returns them in
+	// its own time zone.
+	views := make(map[string]int64, days)
+	for _, row := range rows {
+		views[row.Day.Format(dateFormat)] += row.Views
+	}
+	var pendingTotal int64
+	for d, count := range s.buffer.daily(postID) {
+		views[d.Format(dateFormat)] += count
+		pendingTotal += count
+	}
+
+	stats := &StatsResponse{
+		PostID:    postID,
+		ViewCount: sums[postID] + pendingTotal,
+		From:      from.Format(dateFormat),
+		To:        to.Format(dateFormat),
+		Daily:     make([]DailyViews, 0, days),
+	}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format(dateFormat)
+		stats.Daily = append(stats.Daily, DailyViews{Date: date, Views: views[date]})
+	}
+	return stats, nil
+}
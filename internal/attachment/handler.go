@@ -0,0 +1,84 @@
+package attachment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Upload(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to upload attachment", apperror.FileRequired())
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to upload attachment", apperror.FileRequired())
+		return
+	}
+	defer file.Close()
+
+	attachment, appErr := h.service.Upload(c.Request.Context(), uint(postID), userID, Upload{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Body:     file,
+	})
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to upload attachment", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Attachment uploaded successfully", attachment)
+}
+
+func (h *Handler) GetPostAttachments(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	attachments, appErr := h.service.GetPostAttachments(uint(postID), c.GetUint("user_id"))
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Attachments retrieved successfully", attachments)
+}
+
+func (h *Handler) DeleteAttachment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	if appErr := h.service.DeleteAttachment(c.Request.Context(), uint(id), userID); appErr != nil {
+		response.Error(c, http.StatusForbidden, "Failed to delete attachment", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Attachment deleted successfully", nil)
+}
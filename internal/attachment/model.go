@@ -0,0 +1,43 @@
+package attachment
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+type Attachment struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PostID      uint      `json:"post_id" gorm:"not null;index"`
+	Post        post.Post `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	UserID      uint      `json:"user_id" gorm:"not null;index"`
+	User        user.User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	StorageKey  string    `json:"-" gorm:"size:255;uniqueIndex;not null"`
+	FileName    string    `json:"file_name" gorm:"size:255;not null"`
+	ContentType string    `json:"content_type" gorm:"size:100;not null"`
+	Size        int64     `json:"size" gorm:"not null"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type AttachmentResponse struct {
+	ID          uint      `json:"id"`
+	PostID      uint      `json:"post_id"`
+	FileName    string    `json:"file_name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	URL         string    `json:"url"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (a *Attachment) ToResponse(url string) *AttachmentResponse {
+	return &AttachmentResponse{
+		ID:          a.ID,
+		PostID:      a.PostID,
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		URL:         url,
+		CreatedAt:   a.CreatedAt,
+	}
+}
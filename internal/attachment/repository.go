@@ -0,0 +1,71 @@
+package attachment
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(attachment *Attachment) error
+	FindByID(id uint) (*Attachment, error)
+	FindByPostID(postID uint) ([]Attachment, error)
+	FindByUserID(userID uint) ([]Attachment, error)
+	TotalSizeByUserID(userID uint) (int64, error)
+	Delete(id uint) error
+	DeleteByPostID(postID uint) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(attachment *Attachment) error {
+	return r.db.Omit(clause.Associations).Create(attachment).Error
+}
+
+func (r *repository) FindByID(id uint) (*Attachment, error) {
+	var attachment Attachment
+	err := r.db.First(&attachment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+func (r *repository) FindByPostID(postID uint) ([]Attachment, error) {
+	var attachments []Attachment
+	err := r.db.Where("post_id = ?", postID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *repository) FindByUserID(userID uint) ([]Attachment, error) {
+	var attachments []Attachment
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&attachments).Error
+	return attachments, err
+}
+
+func (r *repository) TotalSizeByUserID(userID uint) (int64, error) {
+	var total int64
+	err := r.db.Model(&Attachment{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(SUM(size), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *repository) Delete(id uint) error {
+	return r.db.Delete(&Attachment{}, id).Error
+}
+
+func (r *repository) DeleteByPostID(postID uint) error {
+	return r.db.Where("post_id = ?", postID).Delete(&Attachment{}).Error
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
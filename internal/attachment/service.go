@@ -0,0 +1,267 @@
+package attachment
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/pkg/storage"
+	"gorm.io/gorm"
+)
+
+const megabyte = 1 << 20
+
+// Upload describes a file received from a client. Size is the length the
+// client declared; the stored size is what was actually read.
+type Upload struct {
+	FileName string
+	Size     int64
+	Body     io.Reader
+}
+
+type Service interface {
+	Upload(ctx context.Context, postID, userID uint, upload Upload) (*AttachmentResponse, apperror.AppErrors)
+	GetPostAttachments(postID, viewerID uint) ([]AttachmentResponse, apperror.AppErrors)
+	DeleteAttachment(ctx context.Context, id, userID uint) apperror.AppErrors
+
+	// DeletePostAttachments is registered as a post.DeleteHook.
+	DeletePostAttachments(tx *gorm.DB, p *post.Post) error
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+type service struct {
+	repo         Repository
+	postRepo     post.Repository
+	storage      storage.Storage
+	maxSizeMB    int
+	quotaMB      int
+	allowedTypes []string
+}
+
+func NewService(repo Repository, postRepo post.Repository, store storage.Storage, maxSizeMB, quotaMB int, allowedTypes []string) Service {
+	return &service{
+		repo:         repo,
+		postRepo:     postRepo,
+		storage:      store,
+		maxSizeMB:    maxSizeMB,
+		quotaMB:      quotaMB,
+		allowedTypes: allowedTypes,
+	}
+}
+
+func (s *service) Upload(ctx context.Context, postID, userID uint, upload Upload) (*AttachmentResponse, apperror.AppErrors) {
+	p, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if p.UserID != userID {
+		return nil, apperror.OwnershipRequired()
+	}
+
+	maxSize := int64(s.maxSizeMB) * megabyte
+	if upload.Size > maxSize {
+		return nil, apperror.FileTooLarge(s.maxSizeMB)
+	}
+
+	used, err := s.repo.TotalSizeByUserID(userID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	if s.quotaMB > 0 && used+upload.Size > int64(s.quotaMB)*megabyte {
+		return nil, apperror.UploadQuotaExceeded(s.quotaMB)
+	}
+
+	// The declared Content-Type is not trusted; the type is sniffed from the
+	// first bytes of the file instead.
+	body := bufio.NewReaderSize(upload.Body, 512)
+	head, _ := body.Peek(512)
+	contentType := sniffContentType(head)
+	if !s.allowed(contentType) {
+		return nil, apperror.UnsupportedFileType(contentType)
+	}
+
+	key, err := newKey(postID, contentType, upload.FileName)
+	if err != nil {
+		return nil, apperror.StorageError(err)
+	}
+
+	counter := &countingReader{r: io.LimitReader(body, maxSize+1)}
+	if err := s.storage.Put(ctx, key, counter, upload.Size, contentType); err != nil {
+		return nil, apperror.StorageError(err)
+	}
+	if counter.n > maxSize {
+		s.storage.Delete(ctx, key)
+		return nil, apperror.FileTooLarge(s.maxSizeMB)
+	}
+
+	attachment := &Attachment{
+		PostID:      postID,
+		UserID:      userID,
+		StorageKey:  key,
+		FileName:    cleanFileName(upload.FileName),
+		ContentType: contentType,
+		Size:        counter.n,
+	}
+	if err := s.repo.Create(attachment); err != nil {
+		s.storage.Delete(ctx, key)
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return attachment.ToResponse(s.storage.URL(key)), nil
+}
+
+func (s *service) GetPostAttachments(postID, viewerID uint) ([]AttachmentResponse, apperror.AppErrors) {
+	p, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if !p.VisibleTo(viewerID) {
+		return nil, apperror.PostNotFound()
+	}
+
+	attachments, err := s.repo.FindByPostID(postID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for _, a := range attachments {
+		responses = append(responses, *a.ToResponse(s.storage.URL(a.StorageKey)))
+	}
+	return responses, nil
+}
+
+func (s *service) DeleteAttachment(ctx context.Context, id, userID uint) apperror.AppErrors {
+	attachment, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.AttachmentNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
+	if attachment.UserID != userID {
+		return apperror.OwnershipRequired()
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return apperror.DatabaseError(err)
+	}
+	if err := s.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		return apperror.StorageError(err)
+	}
+	return nil
+}
+
+// DeletePostAttachments removes the rows inside the post's delete
+// transaction and the stored files once it has been committed, so a rolled
+// back delete keeps its files and a failed storage call only leaves an
+// orphaned object behind.
+func (s *service) DeletePostAttachments(tx *gorm.DB, p *post.Post) error {
+	repo := s.repo.WithTx(tx)
+	attachments, err := repo.FindByPostID(p.ID)
+	if err != nil {
+		return err
+	}
+	if err := repo.DeleteByPostID(p.ID); err != nil {
+		return err
+	}
+
+	post.AfterCommit(tx, func() {
+		for _, a := range attachments {
+			if err := s.storage.Delete(context.Background(), a.StorageKey); err != nil {
+				log.Printf("attachment: failed to delete %s: %v", a.StorageKey, err)
+			}
+		}
+	})
+	return nil
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	attachments, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]AttachmentResponse, 0, len(attachments))
+	for _, a := range attachments {
+		responses = append(responses, *a.ToResponse(s.storage.URL(a.StorageKey)))
+	}
+	return responses, nil
+}
+
+func (s *service) allowed(contentType string) bool {
+	for _, t := range s.allowedTypes {
+		if t == contentType {
+			return true
+		}
+	}
+	return false
+}
+
+func sniffContentType(head []byte) string {
+	contentType := http.DetectContentType(head)
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		return mediaType
+	}
+	return contentType
+}
+
+func newKey(postID uint, contentType, fileName string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	ext := strings.ToLower(filepath.Ext(fileName))
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 && !contains(exts, ext) {
+		ext = exts[0]
+	}
+	return "posts/" + strconv.FormatUint(uint64(postID), 10) + "/" + hex.EncodeToString(buf) + ext, nil
+}
+
+func cleanFileName(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return "file"
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
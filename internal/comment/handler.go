@@ -0,0 +1,126 @@
+package comment
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) CreateComment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var dto CreateCommentDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	comment, appErr := h.service.CreateComment(uint(postID), userID, dto)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create comment", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Comment created successfully", comment)
+}
+
+func (h *Handler) GetPostComments(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var params pagination.Params
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	comments, meta, appErr := h.service.GetPostComments(uint(postID), c.GetUint("user_id"), params)
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Comments retrieved successfully", comments, meta)
+}
+
+func (h *Handler) GetReplies(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var params pagination.Params
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	replies, meta, appErr := h.service.GetReplies(uint(id), c.GetUint("user_id"), params)
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Comment not found", appErr)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Replies retrieved successfully", replies, meta)
+}
+
+func (h *Handler) UpdateComment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var dto UpdateCommentDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	comment, appErr := h.service.UpdateComment(uint(id), userID, dto)
+	if appErr != nil {
+		response.Error(c, http.StatusForbidden, "Failed to update comment", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Comment updated successfully", comment)
+}
+
+func (h *Handler) DeleteComment(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	appErr := h.service.DeleteComment(uint(id), userID)
+	if appErr != nil {
+		response.Error(c, http.StatusForbidden, "Failed to delete comment", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Comment deleted successfully", nil)
+}
@@ -0,0 +1,61 @@
+package comment
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+type Comment struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	PostID    uint       `json:"post_id" gorm:"not null;index"`
+	Post      post.Post  `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	UserID    uint       `json:"user_id" gorm:"not null"`
+	User      user.User  `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ParentID  *uint      `json:"parent_id" gorm:"index"`
+	Parent    *Comment   `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:CASCADE"`
+	Depth     int        `json:"depth" gorm:"not null;default:0"`
+	Content   string     `json:"content" gorm:"type:text;not null"`
+	EditedAt  *time.Time `json:"edited_at"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+type CreateCommentDTO struct {
+	Content  string `json:"content" binding:"required,min=1,max=5000"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+type UpdateCommentDTO struct {
+	Content string `json:"content" binding:"required,min=1,max=5000"`
+}
+
+type CommentResponse struct {
+	ID         uint               `json:"id"`
+	PostID     uint               `json:"post_id"`
+	ParentID   *uint              `json:"parent_id"`
+	Depth      int                `json:"depth"`
+	Content    string             `json:"content"`
+	UserID     uint               `json:"user_id"`
+	User       *user.UserResponse `json:"user"`
+	ReplyCount int64              `json:"reply_count"`
+	EditedAt   *time.Time         `json:"edited_at"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+func (c *Comment) ToResponse() *CommentResponse {
+	return &CommentResponse{
+		ID:        c.ID,
+		PostID:    c.PostID,
+		ParentID:  c.ParentID,
+		Depth:     c.Depth,
+		Content:   c.Content,
+		UserID:    c.UserID,
+		User:      c.User.ToResponse(),
+		EditedAt:  c.EditedAt,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
@@ -0,0 +1,123 @@
+package comment
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(comment *Comment) error
+	FindByID(id uint) (*Comment, error)
+	FindTopLevelByPostID(postID uint, offset, limit int) ([]Comment, int64, error)
+	FindReplies(parentID uint, offset, limit int) ([]Comment, int64, error)
+	FindByUserID(userID uint) ([]Comment, error)
+	Update(comment *Comment) error
+	Delete(id uint) error
+	DeleteByPostID(postID uint) error
+	CountByPostIDs(postIDs []uint) (map[uint]int64, error)
+	CountRepliesByParentIDs(parentIDs []uint) (map[uint]int64, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+type idCount struct {
+	ID    uint
+	Count int64
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(comment *Comment) error {
+	return r.db.Omit(clause.Associations).Create(comment).Error
+}
+
+func (r *repository) FindByID(id uint) (*Comment, error) {
+	var comment Comment
+	err := r.db.Preload("User.Avatar.Variants").First(&comment, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *repository) FindTopLevelByPostID(postID uint, offset, limit int) ([]Comment, int64, error) {
+	return r.findPage(r.db.Where("post_id = ? AND parent_id IS NULL", postID), offset, limit)
+}
+
+func (r *repository) FindReplies(parentID uint, offset, limit int) ([]Comment, int64, error) {
+	return r.findPage(r.db.Where("parent_id = ?", parentID), offset, limit)
+}
+
+func (r *repository) findPage(query *gorm.DB, offset, limit int) ([]Comment, int64, error) {
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Model(&Comment{}).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var comments []Comment
+	err := query.Preload("User.Avatar.Variants").
+		Order("created_at ASC, id ASC").
+		Offset(offset).
+		Limit(limit).
+		Find(&comments).Error
+	return comments, total, err
+}
+
+func (r *repository) Update(comment *Comment) error {
+	return r.db.Omit(clause.Associations).Save(comment).Error
+}
+
+func (r *repository) Delete(id uint) error {
+	return r.db.Delete(&Comment{}, id).Error
+}
+
+func (r *repository) DeleteByPostID(postID uint) error {
+	return r.db.Where("post_id = ?", postID).Delete(&Comment{}).Error
+}
+
+func (r *repository) CountByPostIDs(postIDs []uint) (map[uint]int64, error) {
+	return r.countBy("post_id", postIDs)
+}
+
+func (r *repository) CountRepliesByParentIDs(parentIDs []uint) (map[uint]int64, error) {
+	return r.countBy("parent_id", parentIDs)
+}
+
+func (r *repository) countBy(column string, ids []uint) (map[uint]int64, error) {
+	counts := make(map[uint]int64, len(ids))
+	if len(ids) == 0 {
+		return counts, nil
+	}
+
+	var rows []idCount
+	err := r.db.Model(&Comment{}).
+		Select(column+" AS id, COUNT(*) AS count").
+		Where(column+" IN ?", ids).
+		Group(column).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) FindByUserID(userID uint) ([]Comment, error) {
+	var comments []Comment
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&comments).Error
+	return comments, err
+}
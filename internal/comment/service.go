@@ -0,0 +1,262 @@
+package comment
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/post"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	CreateComment(postID, userID uint, dto CreateCommentDTO) (*CommentResponse, apperror.AppErrors)
+	GetPostComments(postID, viewerID uint, params pagination.Params) ([]CommentResponse, *pagination.Meta, apperror.AppErrors)
+	GetReplies(id, viewerID uint, params pagination.Params) ([]CommentResponse, *pagination.Meta, apperror.AppErrors)
+	UpdateComment(id, userID uint, dto UpdateCommentDTO) (*CommentResponse, apperror.AppErrors)
+	DeleteComment(id, userID uint) apperror.AppErrors
+
+	// EnrichPosts fills in PostResponse.CommentCount.
+	EnrichPosts(posts []*post.PostResponse, viewerID uint) error
+	// DeletePostComments is registered as a post.DeleteHook.
+	DeletePostComments(tx *gorm.DB, p *post.Post) error
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+type service struct {
+	repo       Repository
+	postRepo   post.Repository
+	maxDepth   int
+	editWindow time.Duration
+}
+
+func NewService(repo Repository, postRepo post.Repository, maxDepth int, editWindow time.Duration) Service {
+	return &service{
+		repo:       repo,
+		postRepo:   postRepo,
+		maxDepth:   maxDepth,
+		editWindow: editWindow,
+	}
+}
+
+func (s *service) CreateComment(postID, userID uint, dto CreateCommentDTO) (*CommentResponse, apperror.AppErrors) {
+	if _, appErr := s.findPost(postID, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	comment := &Comment{
+		PostID:  postID,
+		UserID:  userID,
+		Content: dto.Content,
+	}
+
+	if dto.ParentID != nil {
+		parent, appErr := s.findComment(*dto.ParentID)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if parent.PostID != postID {
+			return nil, apperror.InvalidParentComment()
+		}
+		if parent.Depth+1 > s.maxDepth {
+			return nil, apperror.CommentDepthExceeded(s.maxDepth)
+		}
+		comment.ParentID = &parent.ID
+		comment.Depth = parent.Depth + 1
+	}
+
+	if err := s.repo.Create(comment); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	created, err := s.repo.FindByID(comment.ID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return created.ToResponse(), nil
+}
+
+func (s *service) GetPostComments(postID, viewerID uint, params pagination.Params) ([]CommentResponse, *pagination.Meta, apperror.AppErrors) {
+	if _, appErr := s.findPost(postID, viewerID); appErr != nil {
+		return nil, nil, appErr
+	}
+
+	params = params.Normalize()
+	comments, total, err := s.repo.FindTopLevelByPostID(postID, params.Offset(), params.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	responses, appErr := s.toResponses(comments)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	return responses, pagination.NewMeta(params, total), nil
+}
+
+func (s *service) GetReplies(id, viewerID uint, params pagination.Params) ([]CommentResponse, *pagination.Meta, apperror.AppErrors) {
+	comment, appErr := s.findComment(id)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	if _, appErr := s.findPost(comment.PostID, viewerID); appErr != nil {
+		return nil, nil, appErr
+	}
+
+	params = params.Normalize()
+	comments, total, err := s.repo.FindReplies(id, params.Offset(), params.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	responses, appErr := s.toResponses(comments)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	return responses, pagination.NewMeta(params, total), nil
+}
+
+func (s *service) UpdateComment(id, userID uint, dto UpdateCommentDTO) (*CommentResponse, apperror.AppErrors) {
+	comment, appErr := s.findComment(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if comment.UserID != userID {
+		return nil, apperror.OwnershipRequired()
+	}
+	if _, appErr := s.findPost(comment.PostID, userID); appErr != nil {
+		return nil, appErr
+	}
+
+	if time.Since(comment.CreatedAt) > s.editWindow {
+		return nil, apperror.CommentEditWindowExpired()
+	}
+
+	now := time.Now()
+	comment.Content = dto.Content
+	comment.EditedAt = &now
+
+	if err := s.repo.Update(comment); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	responses, appErr := s.toResponses([]Comment{*comment})
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return &responses[0], nil
+}
+
+// DeleteComment lets either the comment author or the owner of the post
+// remove a comment. Replies are removed with it.
+func (s *service) DeleteComment(id, userID uint) apperror.AppErrors {
+	comment, appErr := s.findComment(id)
+	if appErr != nil {
+		return appErr
+	}
+
+	if comment.UserID != userID {
+		p, appErr := s.findPost(comment.PostID, userID)
+		if appErr != nil {
+			return appErr
+		}
+		if p.UserID != userID {
+			return apperror.OwnershipRequired()
+		}
+	}
+
+	if err := s.repo.Delete(comment.ID); err != nil {
+		return apperror.DatabaseError(err)
+	}
+
+	return nil
+}
+
+func (s *service) EnrichPosts(posts []*post.PostResponse, viewerID uint) error {
+	ids := make([]uint, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+
+	counts, err := s.repo.CountByPostIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range posts {
+		p.CommentCount = counts[p.ID]
+	}
+	return nil
+}
+
+func (s *service) DeletePostComments(tx *gorm.DB, p *post.Post) error {
+	return s.repo.WithTx(tx).DeleteByPostID(p.ID)
+}
+
+// findPost loads a post viewerID may see. Hidden posts of other users are
+// reported as not found.
+func (s *service) findPost(id, viewerID uint) (*post.Post, apperror.AppErrors) {
+	p, err := s.postRepo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if !p.VisibleTo(viewerID) {
+		return nil, apperror.PostNotFound()
+	}
+	return p, nil
+}
+
+func (s *service) findComment(id uint) (*Comment, apperror.AppErrors) {
+	comment, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.CommentNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	return comment, nil
+}
+
+func (s *service) toResponses(comments []Comment) ([]CommentResponse, apperror.AppErrors) {
+	ids := make([]uint, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+
+	replyCounts, err := s.repo.CountRepliesByParentIDs(ids)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response := comment.ToResponse()
+		response.ReplyCount = replyCounts[comment.ID]
+		responses = append(responses, *response)
+	}
+
+	return responses, nil
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	comments, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]CommentResponse, 0, len(comments))
+	for _, comment := range comments {
+		response := comment.ToResponse()
+		response.User = nil
+		responses = append(responses, *response)
+	}
+	return responses, nil
+}
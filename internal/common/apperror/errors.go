@@ -2,6 +2,10 @@ package apperror
 
 import (
 	"fmt"
+	"strings"
+	"time"
+
+	customValidator "github.com/ardipermana59/go-template/pkg/validator"
 )
 
 type AppError struct {
@@ -26,6 +30,10 @@ func EmailAlreadyExists() AppErrors {
 	return NewErrors(NewError("email", "The email has already been taken"))
 }
 
+func UsernameAlreadyExists() AppErrors {
+	return NewErrors(NewError("username", "The username has already been taken"))
+}
+
 func InvalidCredentials() AppErrors {
 	return NewErrors(NewError("credentials", "The provided credentials are invalid"))
 }
@@ -57,3 +65,218 @@ func InvalidID() AppErrors {
 func DatabaseError(err error) AppErrors {
 	return NewErrors(NewError("database", fmt.Sprintf("A database error occurred: %v", err)))
 }
+
+func TagNotFound() AppErrors {
+	return NewErrors(NewError("tag", "The tag could not be found"))
+}
+
+func TagAlreadyExists() AppErrors {
+	return NewErrors(NewError("name", "A tag with this name already exists, merge the tags instead"))
+}
+
+func CategoryNotFound() AppErrors {
+	return NewErrors(NewError("category", "The category could not be found"))
+}
+
+func CategoryAlreadyExists() AppErrors {
+	return NewErrors(NewError("name", "A category with this name already exists"))
+}
+
+func InvalidCategoryParent() AppErrors {
+	return NewErrors(NewError("parent_id", "A category cannot be placed under itself or one of its descendants"))
+}
+
+func InvalidName() AppErrors {
+	return NewErrors(NewError("name", "The name must contain at least one letter or digit"))
+}
+
+func CommentNotFound() AppErrors {
+	return NewErrors(NewError("comment", "The comment could not be found"))
+}
+
+func InvalidParentComment() AppErrors {
+	return NewErrors(NewError("parent_id", "The parent comment does not belong to this post"))
+}
+
+func CommentDepthExceeded(maxDepth int) AppErrors {
+	return NewErrors(NewError("parent_id", fmt.Sprintf("Replies cannot be nested more than %d levels deep", maxDepth)))
+}
+
+func CommentEditWindowExpired() AppErrors {
+	return NewErrors(NewError("comment", "The comment can no longer be edited"))
+}
+
+func InvalidReactionType(allowed []string) AppErrors {
+	return NewErrors(NewError("type", fmt.Sprintf("The reaction type must be one of: %s", strings.Join(allowed, ", "))))
+}
+
+func CannotFollowSelf() AppErrors {
+	return NewErrors(NewError("user_id", "You cannot follow yourself"))
+}
+
+func InvalidCursor() AppErrors {
+	return NewErrors(NewError("cursor", "The provided cursor is invalid"))
+}
+
+func ContentRenderFailed() AppErrors {
+	return NewErrors(NewError("content", "The content could not be rendered"))
+}
+
+func AttachmentNotFound() AppErrors {
+	return NewErrors(NewError("attachment", "The attachment could not be found"))
+}
+
+func FileRequired() AppErrors {
+	return NewErrors(NewError("file", "The file field is required"))
+}
+
+func FileTooLarge(maxMB int) AppErrors {
+	return NewErrors(NewError("file", fmt.Sprintf("The file must not be larger than %d MB", maxMB)))
+}
+
+func UnsupportedFileType(contentType string) AppErrors {
+	return NewErrors(NewError("file", fmt.Sprintf("Files of type %s are not allowed", contentType)))
+}
+
+func UploadQuotaExceeded(quotaMB int) AppErrors {
+	return NewErrors(NewError("file", fmt.Sprintf("The upload would exceed your storage quota of %d MB", quotaMB)))
+}
+
+func StorageError(err error) AppErrors {
+	return NewErrors(NewError("storage", fmt.Sprintf("A storage error occurred: %v", err)))
+}
+
+func InvalidImage() AppErrors {
+	return NewErrors(NewError("file", "The file is not a valid image or its dimensions are too large"))
+}
+
+func VersionConflict() AppErrors {
+	return NewErrors(NewError("version", "The resource has been modified since it was retrieved; fetch it again and retry"))
+}
+
+func PreconditionRequired() AppErrors {
+	return NewErrors(NewError("if_match", "The If-Match header is required for this request"))
+}
+
+// Has reports whether any of the errors concerns the given field.
+func (e AppErrors) Has(field string) bool {
+	for _, err := range e {
+		if err.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+func InvalidPatch(reason string) AppErrors {
+	return NewErrors(NewError("patch", fmt.Sprintf("The patch is invalid: %s", reason)))
+}
+
+func UnsupportedPatchType(supported ...string) AppErrors {
+	return NewErrors(NewError("content_type", fmt.Sprintf("The Content-Type must be one of: %s", strings.Join(supported, ", "))))
+}
+
+func BulkLimitExceeded(max int) AppErrors {
+	return NewErrors(NewError("operations", fmt.Sprintf("A batch may contain at most %d operations", max)))
+}
+
+func BulkRolledBack() AppErrors {
+	return NewErrors(NewError("operations", "An operation failed, so none of the operations were applied"))
+}
+
+func InvalidBulkData() AppErrors {
+	return NewErrors(NewError("data", "The data must be a JSON object matching the operation"))
+}
+
+// Validation converts validator errors to AppErrors for places that report
+// them inside a larger response, such as per-row import results.
+func Validation(err error) AppErrors {
+	var errors AppErrors
+	for _, e := range customValidator.FormatValidationErrors(err) {
+		errors = append(errors, NewError(e.Field, e.Message))
+	}
+	return errors
+}
+
+func UnsupportedFormat(supported ...string) AppErrors {
+	return NewErrors(NewError("format", fmt.Sprintf("The format must be one of: %s", strings.Join(supported, ", "))))
+}
+
+func InvalidImportFile(err error) AppErrors {
+	return NewErrors(NewError("file", fmt.Sprintf("The file could not be read: %v", err)))
+}
+
+func InvalidImportRecord(err error) AppErrors {
+	return NewErrors(NewError("record", fmt.Sprintf("The record could not be decoded: %v", err)))
+}
+
+func PasswordIncorrect() AppErrors {
+	return NewErrors(NewError("password", "The password is incorrect"))
+}
+
+func AccountPendingDeletion(deleteAt time.Time) AppErrors {
+	return NewErrors(NewError("account", fmt.Sprintf("The account is disabled and will be deleted on %s", deleteAt.UTC().Format(time.RFC3339))))
+}
+
+func AccountNotPendingDeletion() AppErrors {
+	return NewErrors(NewError("account", "The account is not scheduled for deletion"))
+}
+
+func AccountDisabled() AppErrors {
+	return NewErrors(NewError("account", "The account is disabled"))
+}
+
+func ExportNotFound() AppErrors {
+	return NewErrors(NewError("export", "The data export could not be found"))
+}
+
+func ExportNotReady() AppErrors {
+	return NewErrors(NewError("export", "The data export is not ready for download"))
+}
+
+func CannotReportOwnPost() AppErrors {
+	return NewErrors(NewError("post_id", "You cannot report your own post"))
+}
+
+func PostAlreadyReported() AppErrors {
+	return NewErrors(NewError("report", "You have already reported this post"))
+}
+
+func NotificationNotFound() AppErrors {
+	return NewErrors(NewError("notification", "The notification could not be found"))
+}
+
+func InvalidStreamTopic(topic string) AppErrors {
+	return NewErrors(NewError("topics", fmt.Sprintf("Unknown topic %q, use posts, notifications or user:<id>", topic)))
+}
+
+func InvalidLastEventID() AppErrors {
+	return NewErrors(NewError("last_event_id", "The last event ID must be a positive number"))
+}
+
+func WebhookNotFound() AppErrors {
+	return NewErrors(NewError("webhook", "The webhook subscription could not be found"))
+}
+
+func WebhookDisabled() AppErrors {
+	return NewErrors(NewError("active", "The webhook subscription is disabled, enable it before redelivering"))
+}
+
+func DeliveryNotFound() AppErrors {
+	return NewErrors(NewError("delivery", "The webhook delivery could not be found"))
+}
+
+func JobNotFound() AppErrors {
+	return NewErrors(NewError("job", "The job could not be found"))
+}
+
+func JobNotRetryable(status string) AppErrors {
+	return NewErrors(NewError("status", fmt.Sprintf("A %s job cannot be retried, only dead and pending jobs", status)))
+}
+
+func NotReady(status string) AppErrors {
+	if status == "shutting_down" {
+		return NewErrors(NewError("status", "The service is shutting down"))
+	}
+	return NewErrors(NewError("status", "A dependency of the service is unavailable"))
+}
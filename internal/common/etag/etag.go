@@ -0,0 +1,69 @@
+package etag
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+// ErrVersionMismatch is returned by repositories when a conditional update
+// matched no row because the stored version has moved on.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+const expectedVersionKey = "expected_version"
+
+// Format returns the strong entity tag for a resource version.
+func Format(version uint) string {
+	return `"` + strconv.FormatUint(uint64(version), 10) + `"`
+}
+
+// Set adds the ETag header for a resource version to the response.
+func Set(c *gin.Context, version uint) {
+	c.Header("ETag", Format(version))
+}
+
+// IfMatch parses the If-Match header of update requests and stores the
+// version it names for ExpectedVersion. When required is set, requests
+// without the header are rejected with 428 Precondition Required. Weak or
+// malformed tags can never match and are rejected with 412.
+func IfMatch(required bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := strings.TrimSpace(c.GetHeader("If-Match"))
+		if header == "" {
+			if required {
+				response.Error(c, http.StatusPreconditionRequired, "Precondition required", apperror.PreconditionRequired())
+				c.Abort()
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if header == "*" {
+			c.Next()
+			return
+		}
+
+		value := strings.TrimSuffix(strings.TrimPrefix(header, `"`), `"`)
+		version, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || version == 0 || len(value)+2 != len(header) {
+			response.Error(c, http.StatusPreconditionFailed, "Precondition failed", apperror.VersionConflict())
+			c.Abort()
+			return
+		}
+
+		c.Set(expectedVersionKey, uint(version))
+		c.Next()
+	}
+}
+
+// ExpectedVersion returns the version named by If-Match, or zero when the
+// update is unconditional.
+func ExpectedVersion(c *gin.Context) uint {
+	return c.GetUint(expectedVersionKey)
+}
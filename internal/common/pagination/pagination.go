@@ -0,0 +1,55 @@
+package pagination
+
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+// Params are the paging query parameters accepted by list endpoints.
+type Params struct {
+	Page    int `form:"page" binding:"omitempty,min=1"`
+	PerPage int `form:"per_page" binding:"omitempty,min=1,max=100"`
+}
+
+type Meta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"per_page"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// Normalize fills in defaults for missing or out of range values.
+func (p Params) Normalize() Params {
+	if p.Page < 1 {
+		p.Page = 1
+	}
+	if p.PerPage < 1 {
+		p.PerPage = DefaultPerPage
+	}
+	if p.PerPage > MaxPerPage {
+		p.PerPage = MaxPerPage
+	}
+	return p
+}
+
+func (p Params) Offset() int {
+	return (p.Page - 1) * p.PerPage
+}
+
+func (p Params) Limit() int {
+	return p.PerPage
+}
+
+func NewMeta(p Params, total int64) *Meta {
+	totalPages := int(total) / p.PerPage
+	if int(total)%p.PerPage != 0 {
+		totalPages++
+	}
+
+	return &Meta{
+		Page:       p.Page,
+		PerPage:    p.PerPage,
+		Total:      total,
+		TotalPages: totalPages,
+	}
+}
@@ -0,0 +1,76 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	jsonpatch "github.com/evanphx/json-patch/v5"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+const (
+	// MergePatch is the media type of RFC 7396 JSON Merge Patch documents.
+	MergePatch = "application/merge-patch+json"
+	// JSONPatch is the media type of RFC 6902 JSON Patch documents.
+	JSONPatch = "application/json-patch+json"
+)
+
+// Bind applies the patch in the request body to document and decodes the
+// result into target, which is then validated with the struct's binding
+// tags. document is the current state of the resource in the same shape as
+// target. On failure the error response has been written and Bind returns
+// false.
+func Bind(c *gin.Context, document, target interface{}) bool {
+	body, err := c.GetRawData()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid patch", apperror.InvalidPatch(err.Error()))
+		return false
+	}
+
+	original, err := json.Marshal(document)
+	if err != nil {
+		response.InternalError(c, err)
+		return false
+	}
+
+	var patched []byte
+	switch c.ContentType() {
+	case MergePatch:
+		patched, err = jsonpatch.MergePatch(original, body)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid patch", apperror.InvalidPatch(err.Error()))
+			return false
+		}
+	case JSONPatch:
+		operations, err := jsonpatch.DecodePatch(body)
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid patch", apperror.InvalidPatch(err.Error()))
+			return false
+		}
+		patched, err = operations.Apply(original)
+		if err != nil {
+			response.Error(c, http.StatusUnprocessableEntity, "Patch could not be applied", apperror.InvalidPatch(err.Error()))
+			return false
+		}
+	default:
+		response.Error(c, http.StatusUnsupportedMediaType, "Unsupported patch format", apperror.UnsupportedPatchType(MergePatch, JSONPatch))
+		return false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		response.Error(c, http.StatusUnprocessableEntity, "Patch could not be applied", apperror.InvalidPatch(err.Error()))
+		return false
+	}
+
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		response.ValidationError(c, err)
+		return false
+	}
+	return true
+}
@@ -10,6 +10,7 @@ type Response struct {
 	Success bool        `json:"success"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
+	Meta    interface{} `json:"meta,omitempty"`
 	Error   interface{} `json:"error,omitempty"`
 }
 
@@ -21,6 +22,15 @@ func Success(c *gin.Context, code int, message string, data interface{}) {
 	})
 }
 
+func Paginated(c *gin.Context, code int, message string, data interface{}, meta interface{}) {
+	c.JSON(code, Response{
+		Success: true,
+		Message: message,
+		Data:    data,
+		Meta:    meta,
+	})
+}
+
 func Error(c *gin.Context, code int, message string, errors apperror.AppErrors) {
 	c.JSON(code, Response{
 		Success: false,
@@ -29,6 +39,17 @@ func Error(c *gin.Context, code int, message string, errors apperror.AppErrors)
 	})
 }
 
+// ErrorWithData is Error for failures that still describe what happened in
+// data, such as the per-operation results of a rolled back batch.
+func ErrorWithData(c *gin.Context, code int, message string, data interface{}, errors apperror.AppErrors) {
+	c.JSON(code, Response{
+		Success: false,
+		Message: message,
+		Data:    data,
+		Error:   errors,
+	})
+}
+
 func ValidationError(c *gin.Context, err error) {
 	errors := customValidator.FormatValidationErrors(err)
 
@@ -0,0 +1,88 @@
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+)
+
+// Verdict is the decision of a filter. Higher verdicts win when several
+// filters disagree.
+type Verdict int
+
+const (
+	Allow Verdict = iota
+	// Flag accepts the content but holds it back for a moderator.
+	Flag
+	// Reject refuses the content.
+	Reject
+)
+
+func (v Verdict) String() string {
+	switch v {
+	case Flag:
+		return "flag"
+	case Reject:
+		return "reject"
+	default:
+		return "allow"
+	}
+}
+
+// ParseVerdict is the inverse of Verdict.String.
+func ParseVerdict(value string) (Verdict, error) {
+	switch value {
+	case "allow":
+		return Allow, nil
+	case "flag":
+		return Flag, nil
+	case "reject":
+		return Reject, nil
+	}
+	return Allow, fmt.Errorf("unknown verdict %q", value)
+}
+
+// Content is the text a filter checks. PostID is zero for new posts.
+// Context is passed on to lookups such as HashLookup and may be nil.
+type Content struct {
+	Context context.Context
+	PostID  uint
+	UserID  uint
+	Title   string
+	Body    string
+}
+
+// Result is the verdict of a filter and the reasons behind it. Reasons use
+// the field they were found in, "title" or "content".
+type Result struct {
+	Verdict Verdict
+	Reasons apperror.AppErrors
+}
+
+type Filter interface {
+	Check(content Content) (Result, error)
+}
+
+// Pipeline runs every filter and combines their results: the highest
+// verdict wins and the reasons of all filters that did not allow the
+// content are kept.
+type Pipeline []Filter
+
+func (p Pipeline) Check(content Content) (Result, error) {
+	var combined Result
+	for _, filter := range p {
+		result, err := filter.Check(content)
+		if err != nil {
+			return Result{}, err
+		}
+		if result.Verdict == Allow {
+			continue
+		}
+		if result.Verdict > combined.Verdict {
+			combined.Verdict = result.Verdict
+		}
+		combined.Reasons = append(combined.Reasons, result.Reasons...)
+	}
+	return combined, nil
+}
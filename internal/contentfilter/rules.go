@@ -0,0 +1,115 @@
+package contentfilter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+)
+
+type wordList struct {
+	words   map[string]bool
+	verdict Verdict
+}
+
+// WordList matches whole words case-insensitively in the title and the
+// body. An empty list allows everything.
+func WordList(words []string, verdict Verdict) Filter {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			set[word] = true
+		}
+	}
+	return &wordList{words: set, verdict: verdict}
+}
+
+func (f *wordList) Check(content Content) (Result, error) {
+	var result Result
+	for _, field := range []struct{ name, text string }{{"title", content.Title}, {"content", content.Body}} {
+		for _, word := range splitWords(field.text) {
+			if f.words[word] {
+				result.Verdict = f.verdict
+				result.Reasons = append(result.Reasons,
+					apperror.NewError(field.name, fmt.Sprintf("The %s contains the blocked word %q", field.name, word)))
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://\S+|\bwww\.\S+`)
+
+type linkLimit struct {
+	max     int
+	verdict Verdict
+}
+
+// LinkLimit counts links in the body. Zero disables the filter.
+func LinkLimit(max int, verdict Verdict) Filter {
+	return &linkLimit{max: max, verdict: verdict}
+}
+
+func (f *linkLimit) Check(content Content) (Result, error) {
+	if f.max <= 0 {
+		return Result{}, nil
+	}
+	count := len(linkPattern.FindAllStringIndex(content.Body, -1))
+	if count <= f.max {
+		return Result{}, nil
+	}
+	return Result{
+		Verdict: f.verdict,
+		Reasons: apperror.NewErrors(apperror.NewError("content",
+			fmt.Sprintf("The content contains %d links, at most %d are allowed", count, f.max))),
+	}, nil
+}
+
+// HashLookup reports whether a post other than excludePostID has content
+// with the given Hash.
+type HashLookup func(ctx context.Context, hash string, excludePostID uint) (bool, error)
+
+type duplicate struct {
+	lookup  HashLookup
+	verdict Verdict
+}
+
+// Duplicate detects bodies that were already posted, ignoring case and
+// whitespace.
+func Duplicate(lookup HashLookup, verdict Verdict) Filter {
+	return &duplicate{lookup: lookup, verdict: verdict}
+}
+
+func (f *duplicate) Check(content Content) (Result, error) {
+	ctx := content.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	exists, err := f.lookup(ctx, Hash(content.Body), content.PostID)
+	if err != nil || !exists {
+		return Result{}, err
+	}
+	return Result{
+		Verdict: f.verdict,
+		Reasons: apperror.NewErrors(apperror.NewError("content", "The content duplicates an existing post")),
+	}, nil
+}
+
+// Hash fingerprints a body for duplicate detection.
+func Hash(body string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(body)), " ")
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,97 @@
+package follow
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) Follow(c *gin.Context) {
+	followerID := c.GetUint("user_id")
+	followeeID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", apperror.InvalidID())
+		return
+	}
+
+	status, appErr := h.service.Follow(followerID, uint(followeeID))
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to follow user", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "User followed successfully", status)
+}
+
+func (h *Handler) Unfollow(c *gin.Context) {
+	followerID := c.GetUint("user_id")
+	followeeID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", apperror.InvalidID())
+		return
+	}
+
+	status, appErr := h.service.Unfollow(followerID, uint(followeeID))
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to unfollow user", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "User unfollowed successfully", status)
+}
+
+func (h *Handler) GetFollowers(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", apperror.InvalidID())
+		return
+	}
+
+	var params pagination.Params
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	users, meta, appErr := h.service.GetFollowers(uint(userID), params)
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "User not found", appErr)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Followers retrieved successfully", users, meta)
+}
+
+func (h *Handler) GetFollowing(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid user ID", apperror.InvalidID())
+		return
+	}
+
+	var params pagination.Params
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	users, meta, appErr := h.service.GetFollowing(uint(userID), params)
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "User not found", appErr)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Following retrieved successfully", users, meta)
+}
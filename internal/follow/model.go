@@ -0,0 +1,21 @@
+package follow
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+type Follow struct {
+	FollowerID uint      `json:"follower_id" gorm:"primaryKey;autoIncrement:false"`
+	Follower   user.User `json:"-" gorm:"foreignKey:FollowerID;constraint:OnDelete:CASCADE"`
+	FolloweeID uint      `json:"followee_id" gorm:"primaryKey;autoIncrement:false;index"`
+	Followee   user.User `json:"-" gorm:"foreignKey:FolloweeID;constraint:OnDelete:CASCADE"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type StatusResponse struct {
+	UserID    uint  `json:"user_id"`
+	Following bool  `json:"following"`
+	Followers int64 `json:"followers"`
+}
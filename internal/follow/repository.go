@@ -0,0 +1,86 @@
+package follow
+
+import (
+	"github.com/ardipermana59/go-template/internal/user"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Create returns false when the follow already existed.
+	Create(follow *Follow) (bool, error)
+	Delete(followerID, followeeID uint) error
+	CountFollowers(userID uint) (int64, error)
+	FindFollowers(userID uint, offset, limit int) ([]user.User, int64, error)
+	FindFollowing(userID uint, offset, limit int) ([]user.User, int64, error)
+	// FindByUserID returns the follows in which the user is either side.
+	FindByUserID(userID uint) ([]Follow, error)
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(follow *Follow) (bool, error) {
+	result := r.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(follow)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) Delete(followerID, followeeID uint) error {
+	return r.db.
+		Where("follower_id = ? AND followee_id = ?", followerID, followeeID).
+		Delete(&Follow{}).Error
+}
+
+func (r *repository) CountFollowers(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&Follow{}).Where("followee_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *repository) FindFollowers(userID uint, offset, limit int) ([]user.User, int64, error) {
+	return r.findUsers("follows.follower_id", "follows.followee_id", userID, offset, limit)
+}
+
+func (r *repository) FindFollowing(userID uint, offset, limit int) ([]user.User, int64, error) {
+	return r.findUsers("follows.followee_id", "follows.follower_id", userID, offset, limit)
+}
+
+// findUsers lists the users on one side of the follows table for the given
+// user on the other side, most recent relationship first.
+func (r *repository) findUsers(joinColumn, filterColumn string, userID uint, offset, limit int) ([]user.User, int64, error) {
+	query := r.db.Model(&user.User{}).
+		Joins("JOIN follows ON "+joinColumn+" = users.id").
+		Where(filterColumn+" = ?", userID).
+		Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []user.User
+	err := query.Preload("Avatar.Variants").
+		Order("follows.created_at DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&users).Error
+	return users, total, err
+}
+
+func (r *repository) FindByUserID(userID uint) ([]Follow, error) {
+	var follows []Follow
+	err := r.db.Where("follower_id = ? OR followee_id = ?", userID, userID).
+		Order("created_at").
+		Find(&follows).Error
+	return follows, err
+}
@@ -0,0 +1,129 @@
+package follow
+
+import (
+	"log"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/notification"
+	"github.com/ardipermana59/go-template/internal/user"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	Follow(followerID, followeeID uint) (*StatusResponse, apperror.AppErrors)
+	Unfollow(followerID, followeeID uint) (*StatusResponse, apperror.AppErrors)
+	GetFollowers(userID uint, params pagination.Params) ([]user.UserResponse, *pagination.Meta, apperror.AppErrors)
+	GetFollowing(userID uint, params pagination.Params) ([]user.UserResponse, *pagination.Meta, apperror.AppErrors)
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+type service struct {
+	repo          Repository
+	userRepo      user.Repository
+	notifications notification.Service
+}
+
+func NewService(repo Repository, userRepo user.Repository, notificationService notification.Service) Service {
+	return &service{
+		repo:          repo,
+		userRepo:      userRepo,
+		notifications: notificationService,
+	}
+}
+
+func (s *service) Follow(followerID, followeeID uint) (*StatusResponse, apperror.AppErrors) {
+	if followerID == followeeID {
+		return nil, apperror.CannotFollowSelf()
+	}
+	if appErr := s.ensureUser(followeeID); appErr != nil {
+		return nil, appErr
+	}
+
+	follow := &Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	}
+	created, err := s.repo.Create(follow)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	// The follow is already stored, so a failed notification is only logged.
+	if created {
+		if err := s.notifications.Notify(&notification.Notification{
+			UserID:  followeeID,
+			Type:    notification.TypeFollow,
+			ActorID: followerID,
+		}); err != nil {
+			log.Printf("follow: failed to notify user %d: %v", followeeID, err)
+		}
+	}
+
+	return s.status(followeeID, true)
+}
+
+func (s *service) Unfollow(followerID, followeeID uint) (*StatusResponse, apperror.AppErrors) {
+	if appErr := s.ensureUser(followeeID); appErr != nil {
+		return nil, appErr
+	}
+
+	if err := s.repo.Delete(followerID, followeeID); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return s.status(followeeID, false)
+}
+
+func (s *service) GetFollowers(userID uint, params pagination.Params) ([]user.UserResponse, *pagination.Meta, apperror.AppErrors) {
+	return s.list(userID, params, s.repo.FindFollowers)
+}
+
+func (s *service) GetFollowing(userID uint, params pagination.Params) ([]user.UserResponse, *pagination.Meta, apperror.AppErrors) {
+	return s.list(userID, params, s.repo.FindFollowing)
+}
+
+func (s *service) list(userID uint, params pagination.Params, find func(uint, int, int) ([]user.User, int64, error)) ([]user.UserResponse, *pagination.Meta, apperror.AppErrors) {
+	if appErr := s.ensureUser(userID); appErr != nil {
+		return nil, nil, appErr
+	}
+
+	params = params.Normalize()
+	users, total, err := find(userID, params.Offset(), params.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]user.UserResponse, 0, len(users))
+	for _, u := range users {
+		responses = append(responses, *u.ToResponse())
+	}
+
+	return responses, pagination.NewMeta(params, total), nil
+}
+
+func (s *service) ensureUser(id uint) apperror.AppErrors {
+	if _, err := s.userRepo.FindByID(id); err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.UserNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
+	return nil
+}
+
+func (s *service) status(followeeID uint, following bool) (*StatusResponse, apperror.AppErrors) {
+	followers, err := s.repo.CountFollowers(followeeID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return &StatusResponse{
+		UserID:    followeeID,
+		Following: following,
+		Followers: followers,
+	}, nil
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	return s.repo.FindByUserID(userID)
+}
@@ -0,0 +1,46 @@
+package health
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// Live answers as long as the process serves requests. It checks no
+// dependencies, so an unavailable database does not get the process
+// restarted.
+func (h *Handler) Live(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Alive", gin.H{"status": StatusOK})
+}
+
+// Ready reports whether the instance should receive traffic, with the
+// status of every dependency check. The errors of failing checks are only
+// logged.
+func (h *Handler) Ready(c *gin.Context) {
+	report, ready := h.service.Ready(c.Request.Context())
+	for name, component := range report.Components {
+		if component.Status != StatusOK {
+			log.Printf("health: %s check failed: %s", name, component.Error)
+		}
+	}
+	if !ready {
+		response.ErrorWithData(c, http.StatusServiceUnavailable, "Service is not ready", report, apperror.NotReady(report.Status))
+		return
+	}
+	response.Success(c, http.StatusOK, "Ready", report)
+}
+
+func (h *Handler) Version(c *gin.Context) {
+	response.Success(c, http.StatusOK, "Build information retrieved successfully", h.service.BuildInfo())
+}
@@ -0,0 +1,202 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusOK      = "ok"
+	StatusFailing = "failing"
+
+	StatusReady        = "ready"
+	StatusNotReady     = "not_ready"
+	StatusShuttingDown = "shutting_down"
+)
+
+// Check reports whether a dependency is usable. It should return soon after
+// ctx ends.
+type Check func(ctx context.Context) error
+
+type check struct {
+	name    string
+	run     Check
+	timeout time.Duration
+}
+
+// ComponentReport is the result of one check. Error is kept out of the
+// response, since /readyz is public.
+type ComponentReport struct {
+	Status     string `json:"status"`
+	Error      string `json:"-"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report is the result of a readiness probe.
+type Report struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentReport `json:"components"`
+}
+
+// BuildInfo describes the running binary.
+type BuildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
+type Service interface {
+	// Ready runs every check at the same time, each bounded by its timeout.
+	// The service is ready when all of them pass and it is not shutting
+	// down.
+	Ready(ctx context.Context) (*Report, bool)
+	// ShutDown makes the service report not ready from now on, so load
+	// balancers stop sending new requests before the server stops.
+	ShutDown()
+	BuildInfo() BuildInfo
+}
+
+type service struct {
+	checks       []check
+	timeout      time.Duration
+	build        BuildInfo
+	shuttingDown atomic.Bool
+}
+
+type Option func(*service)
+
+// WithCheck adds a dependency check. A zero timeout uses the default one.
+func WithCheck(name string, run Check, timeout time.Duration) Option {
+	return func(s *service) {
+		s.checks = append(s.checks, check{name: name, run: run, timeout: timeout})
+	}
+}
+
+// WithBuildInfo sets the version information of the binary. The Go version
+// is filled in when it is empty.
+func WithBuildInfo(build BuildInfo) Option {
+	return func(s *service) {
+		if build.GoVersion == "" {
+			build.GoVersion = runtime.Version()
+		}
+		s.build = build
+	}
+}
+
+// NewService builds the service. timeout bounds checks registered without
+// their own.
+func NewService(timeout time.Duration, opts ...Option) Service {
+	s := &service{
+		timeout: timeout,
+		build:   BuildInfo{Version: "dev", GoVersion: runtime.Version()},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Ready(ctx context.Context) (*Report, bool) {
+	report := &Report{
+		Status:     StatusReady,
+		Components: make(map[string]ComponentReport, len(s.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	ready := true
+	for _, c := range s.checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			component := s.run(ctx, c)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[c.name] = component
+			if component.Status != StatusOK {
+				ready = false
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	switch {
+	case s.shuttingDown.Load():
+		report.Status = StatusShuttingDown
+		ready = false
+	case !ready:
+		report.Status = StatusNotReady
+	}
+	return report, ready
+}
+
+func (s *service) run(ctx context.Context, c check) ComponentReport {
+	timeout := c.timeout
+	if timeout <= 0 {
+		timeout = s.timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- c.run(ctx)
+	}()
+
+	// A check that ignores its context does not hold up the probe.
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("timed out after %s", timeout)
+	}
+
+	component := ComponentReport{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		component.Status = StatusFailing
+		component.Error = err.Error()
+	}
+	return component
+}
+
+func (s *service) ShutDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *service) BuildInfo() BuildInfo {
+	return s.build
+}
+
+// ReadBuildInfo combines the values set at link time with the version
+// control information Go records in the binary, which fills in the commit
+// and build time when they were not set.
+func ReadBuildInfo(version, commit, buildTime string) BuildInfo {
+	build := BuildInfo{Version: version, Commit: commit, BuildTime: buildTime, GoVersion: runtime.Version()}
+	if build.Version == "" {
+		build.Version = "dev"
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && build.Commit == "":
+				build.Commit = setting.Value
+			case setting.Key == "vcs.time" && build.BuildTime == "":
+				build.BuildTime = setting.Value
+			}
+		}
+	}
+	return build
+}
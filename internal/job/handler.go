@@ -0,0 +1,77 @@
+package job
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetJobs(c *gin.Context) {
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	jobs, meta, appErr := h.service.GetJobs(query)
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Jobs retrieved successfully", jobs, meta)
+}
+
+func (h *Handler) GetJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	job, appErr := h.service.GetJob(uint(id))
+	if appErr != nil {
+		if appErr.Has("job") {
+			response.Error(c, http.StatusNotFound, "Job not found", appErr)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to retrieve job", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Job retrieved successfully", job)
+}
+
+func (h *Handler) RetryJob(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	job, appErr := h.service.RetryJob(uint(id))
+	if appErr != nil {
+		switch {
+		case appErr.Has("job"):
+			response.Error(c, http.StatusNotFound, "Job not found", appErr)
+		case appErr.Has("status"):
+			response.Error(c, http.StatusConflict, "Job cannot be retried", appErr)
+		default:
+			response.Error(c, http.StatusInternalServerError, "Failed to retry job", appErr)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Job queued for retry", job)
+}
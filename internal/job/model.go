@@ -0,0 +1,92 @@
+package job
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	// StatusDead marks jobs that failed their last attempt. They are kept
+	// until an admin retries them.
+	StatusDead = "dead"
+)
+
+// Record is a queued job. Pending jobs run once RunAt has passed; running
+// jobs whose lock expired, because their worker stopped, run again.
+type Record struct {
+	ID          uint       `gorm:"primaryKey"`
+	Type        string     `gorm:"size:100;not null;index"`
+	Payload     string     `gorm:"type:text;not null"`
+	Status      string     `gorm:"size:20;not null;index:idx_jobs_due"`
+	RunAt       time.Time  `gorm:"not null;index:idx_jobs_due"`
+	Attempts    int        `gorm:"not null;default:0"`
+	MaxAttempts int        `gorm:"not null"`
+	LockedUntil *time.Time `gorm:"index"`
+	// UniqueKey is cleared when the job finishes, so another job with the
+	// same key can be queued again.
+	UniqueKey  *string `gorm:"size:191;uniqueIndex"`
+	LastError  string  `gorm:"size:500"`
+	StartedAt  *time.Time
+	FinishedAt *time.Time
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+func (Record) TableName() string {
+	return "jobs"
+}
+
+// Schedule stores when a recurring job runs next. It is shared by all
+// instances so a recurring job is queued once per run.
+type Schedule struct {
+	Name      string `gorm:"primaryKey;size:100"`
+	Spec      string `gorm:"size:100;not null"`
+	NextRunAt time.Time
+	LastRunAt *time.Time
+	UpdatedAt time.Time
+}
+
+func (Schedule) TableName() string {
+	return "job_schedules"
+}
+
+type ListQuery struct {
+	pagination.Params
+	Status string `form:"status" binding:"omitempty,oneof=pending running succeeded dead"`
+	Type   string `form:"type" binding:"max=100"`
+}
+
+type JobResponse struct {
+	ID          uint            `json:"id"`
+	Type        string          `json:"type"`
+	Payload     json.RawMessage `json:"payload"`
+	Status      string          `json:"status"`
+	RunAt       time.Time       `json:"run_at"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	LastError   string          `json:"last_error"`
+	StartedAt   *time.Time      `json:"started_at"`
+	FinishedAt  *time.Time      `json:"finished_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+func (r *Record) ToResponse() *JobResponse {
+	return &JobResponse{
+		ID:          r.ID,
+		Type:        r.Type,
+		Payload:     json.RawMessage(r.Payload),
+		Status:      r.Status,
+		RunAt:       r.RunAt,
+		Attempts:    r.Attempts,
+		MaxAttempts: r.MaxAttempts,
+		LastError:   r.LastError,
+		StartedAt:   r.StartedAt,
+		FinishedAt:  r.FinishedAt,
+		CreatedAt:   r.CreatedAt,
+	}
+}
@@ -0,0 +1,485 @@
+package job
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultTimeout = 5 * time.Minute
+	// lockGrace is added to the timeout of a job for its lock, so a job is
+	// only run again when its worker is gone.
+	lockGrace = time.Minute
+	// maxRetryDelay caps the backoff between attempts.
+	maxRetryDelay = 6 * time.Hour
+)
+
+// ErrDuplicate is returned by Enqueue when a job with the same unique key is
+// still pending or running.
+var ErrDuplicate = errors.New("job: a job with the same unique key is queued")
+
+// Job is the payload of a background job. Jobs are structs with a value
+// receiver JobType, stored as JSON.
+type Job interface {
+	JobType() string
+}
+
+// Enqueuer queues jobs. It is implemented by Queue.
+type Enqueuer interface {
+	Enqueue(job Job, opts ...EnqueueOption) (*Record, error)
+	EnqueueTx(tx *gorm.DB, job Job, opts ...EnqueueOption) (*Record, error)
+}
+
+// Settings configure the worker pool.
+type Settings struct {
+	// Workers is the number of jobs run at the same time by this instance.
+	Workers int
+	// PollInterval is how often idle workers look for due jobs, for example
+	// jobs queued by other instances or retries that became due.
+	PollInterval time.Duration
+	// MaxAttempts is the default number of attempts before a job is dead.
+	MaxAttempts int
+	// RetryBase is the delay after the first failed attempt. It doubles
+	// with every further failure.
+	RetryBase time.Duration
+	// Retention is how long succeeded jobs are kept. Zero keeps them.
+	Retention time.Duration
+}
+
+type handler struct {
+	run         func(ctx context.Context, payload []byte) error
+	concurrency int
+	timeout     time.Duration
+	maxAttempts int
+}
+
+type schedule struct {
+	name string
+	spec string
+	next Spec
+	job  Job
+}
+
+// Queue stores jobs in the database and runs them with a pool of workers.
+// Handlers and schedules are registered before Start.
+type Queue struct {
+	repo      Repository
+	settings  Settings
+	handlers  map[string]*handler
+	schedules []schedule
+
+	mu      sync.Mutex
+	running map[string]int
+
+	wake   chan struct{}
+	stop   chan struct{}
+	once   sync.Once
+	wg     sync.WaitGroup
+	cancel context.CancelFunc
+}
+
+func NewQueue(repo Repository, settings Settings) *Queue {
+	return &Queue{
+		repo:     repo,
+		settings: settings,
+		handlers: make(map[string]*handler),
+		running:  make(map[string]int),
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// HandlerOption configures how jobs of one type run.
+type HandlerOption func(*handler)
+
+// Concurrency limits how many jobs of the type run at the same time on one
+// instance. By default only the number of workers limits them.
+func Concurrency(n int) HandlerOption {
+	return func(h *handler) {
+		h.concurrency = n
+	}
+}
+
+// Timeout cancels the context of a job that runs longer. The default is
+// five minutes.
+func Timeout(d time.Duration) HandlerOption {
+	return func(h *handler) {
+		h.timeout = d
+	}
+}
+
+// Attempts overrides Settings.MaxAttempts for the type.
+func Attempts(n int) HandlerOption {
+	return func(h *handler) {
+		h.maxAttempts = n
+	}
+}
+
+// Handle registers run for jobs of type J. A returned error or a panic fails
+// the attempt; the job is retried with backoff until it runs out of attempts
+// and is marked dead. Jobs can run more than once when a worker stops during
+// an attempt, so run should be safe to repeat.
+func Handle[J Job](q *Queue, run func(ctx context.Context, job J) error, opts ...HandlerOption) {
+	var zero J
+	h := &handler{
+		run: func(ctx context.Context, payload []byte) error {
+			var job J
+			if err := json.Unmarshal(payload, &job); err != nil {
+				return err
+			}
+			return run(ctx, job)
+		},
+		timeout:     defaultTimeout,
+		maxAttempts: q.settings.MaxAttempts,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	q.handlers[zero.JobType()] = h
+}
+
+// Every queues job on the schedule given by spec, see ParseSpec. The name
+// identifies the schedule across instances and restarts; each run is queued
+// by one instance only, and not while the previous run is still queued.
+func (q *Queue) Every(name, spec string, job Job) error {
+	next, err := ParseSpec(spec)
+	if err != nil {
+		return fmt.Errorf("job: schedule %s: %w", name, err)
+	}
+	q.schedules = append(q.schedules, schedule{name: name, spec: spec, next: next, job: job})
+	return nil
+}
+
+// EnqueueOption configures a queued job.
+type EnqueueOption func(*Record)
+
+// RunAt delays the job until t.
+func RunAt(t time.Time) EnqueueOption {
+	return func(r *Record) {
+		r.RunAt = t
+	}
+}
+
+// Delay delays the job by d.
+func Delay(d time.Duration) EnqueueOption {
+	return func(r *Record) {
+		r.RunAt = time.Now().Add(d)
+	}
+}
+
+// Unique rejects the job with ErrDuplicate while another job with the same
+// key is pending or running.
+func Unique(key string) EnqueueOption {
+	return func(r *Record) {
+		r.UniqueKey = &key
+	}
+}
+
+// MaxAttempts overrides the number of attempts of this job.
+func MaxAttempts(n int) EnqueueOption {
+	return func(r *Record) {
+		r.MaxAttempts = n
+	}
+}
+
+func (q *Queue) Enqueue(job Job, opts ...EnqueueOption) (*Record, error) {
+	record, err := q.enqueue(q.repo, job, opts)
+	if err == nil {
+		q.Trigger()
+	}
+	return record, err
+}
+
+// EnqueueTx queues the job inside tx, so it only runs if tx is committed.
+// Workers pick it up at the next poll.
+func (q *Queue) EnqueueTx(tx *gorm.DB, job Job, opts ...EnqueueOption) (*Record, error) {
+	return q.enqueue(q.repo.WithTx(tx), job, opts)
+}
+
+func (q *Queue) enqueue(repo Repository, job Job, opts []EnqueueOption) (*Record, error) {
+	payload, err := json.Marshal(job)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{
+		Type:        job.JobType(),
+		Payload:     string(payload),
+		Status:      StatusPending,
+		RunAt:       time.Now(),
+		MaxAttempts: q.settings.MaxAttempts,
+	}
+	if h, ok := q.handlers[record.Type]; ok {
+		record.MaxAttempts = h.maxAttempts
+	}
+	for _, opt := range opts {
+		opt(record)
+	}
+
+	created, err := repo.Create(record)
+	if err != nil {
+		return nil, err
+	}
+	if !created {
+		return nil, ErrDuplicate
+	}
+	return record, nil
+}
+
+// Trigger wakes an idle worker. It never blocks.
+func (q *Queue) Trigger() {
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the workers and the scheduler.
+func (q *Queue) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+
+	for i := 0; i < q.settings.Workers; i++ {
+		q.wg.Add(1)
+		go func() {
+			defer q.wg.Done()
+			ticker := time.NewTicker(q.settings.PollInterval)
+			defer ticker.Stop()
+
+			for {
+				for q.RunNext(ctx) {
+					select {
+					case <-q.stop:
+						return
+					default:
+					}
+				}
+				select {
+				case <-ticker.C:
+				case <-q.wake:
+				case <-q.stop:
+					return
+				}
+			}
+		}()
+	}
+
+	q.wg.Add(1)
+	go func() {
+		defer q.wg.Done()
+		ticker := time.NewTicker(q.settings.PollInterval)
+		defer ticker.Stop()
+
+		lastPurge := time.Time{}
+		for {
+			q.Schedule(time.Now())
+			if q.settings.Retention > 0 && time.Since(lastPurge) >= time.Hour {
+				lastPurge = time.Now()
+				if _, err := q.repo.DeleteSucceededBefore(lastPurge.Add(-q.settings.Retention)); err != nil {
+					log.Printf("job: failed to delete succeeded jobs: %v", err)
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-q.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops claiming jobs, cancels the context of the running ones and
+// waits for them to return. Jobs cut short are queued again without using
+// up an attempt.
+func (q *Queue) Stop() {
+	q.once.Do(func() {
+		close(q.stop)
+		if q.cancel != nil {
+			q.cancel()
+		}
+	})
+	q.wg.Wait()
+}
+
+// RunNext claims one due job and runs it. It reports whether a job was run.
+func (q *Queue) RunNext(ctx context.Context) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	record := q.claim()
+	if record == nil {
+		return false
+	}
+	defer q.release(record.Type)
+
+	// Another idle worker can look for the next job in the meantime.
+	q.Trigger()
+	err := q.run(ctx, q.handlers[record.Type], record)
+	if err != nil && ctx.Err() != nil {
+		q.requeue(record)
+		return true
+	}
+	q.finish(record, err)
+	return true
+}
+
+// claim takes the next due job of a type that is below its concurrency
+// limit and counts it as running. Claims of the workers of one instance are
+// serialized so a limit cannot be exceeded between the check and the count.
+func (q *Queue) claim() *Record {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	// The lock of a job outlasts the longest timeout of the types that can
+	// be claimed.
+	types := make([]string, 0, len(q.handlers))
+	lock := time.Duration(0)
+	for jobType, h := range q.handlers {
+		if h.concurrency > 0 && q.running[jobType] >= h.concurrency {
+			continue
+		}
+		types = append(types, jobType)
+		if h.timeout > lock {
+			lock = h.timeout
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	record, err := q.repo.Claim(types, now, now.Add(lock+lockGrace))
+	if err != nil {
+		log.Printf("job: failed to claim a job: %v", err)
+		return nil
+	}
+	if record != nil {
+		q.running[record.Type]++
+	}
+	return record
+}
+
+func (q *Queue) release(jobType string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running[jobType]--
+}
+
+func (q *Queue) run(ctx context.Context, h *handler, record *Record) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, h.timeout)
+	defer cancel()
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+			log.Printf("job: %s %d panicked: %v\n%s", record.Type, record.ID, r, debug.Stack())
+		}
+	}()
+	return h.run(ctx, []byte(record.Payload))
+}
+
+func (q *Queue) finish(record *Record, runErr error) {
+	now := time.Now()
+	record.LockedUntil = nil
+	record.LastError = ""
+	switch {
+	case runErr == nil:
+		record.Status = StatusSucceeded
+		record.FinishedAt = &now
+		record.UniqueKey = nil
+	case record.Attempts >= record.MaxAttempts:
+		record.Status = StatusDead
+		record.FinishedAt = &now
+		record.UniqueKey = nil
+		record.LastError = truncate(runErr.Error(), 500)
+		log.Printf("job: %s %d is dead after %d attempts: %v", record.Type, record.ID, record.Attempts, runErr)
+	default:
+		record.Status = StatusPending
+		record.RunAt = now.Add(backoff(q.settings.RetryBase, record.Attempts))
+		record.LastError = truncate(runErr.Error(), 500)
+	}
+	q.save(record)
+}
+
+// requeue makes a job that was cancelled by Stop due again without counting
+// the attempt.
+func (q *Queue) requeue(record *Record) {
+	record.Status = StatusPending
+	record.Attempts--
+	record.RunAt = time.Now()
+	record.LockedUntil = nil
+	q.save(record)
+}
+
+func (q *Queue) save(record *Record) {
+	if err := q.repo.SaveResult(record); err != nil {
+		log.Printf("job: failed to record the result of %s %d: %v", record.Type, record.ID, err)
+	}
+}
+
+// Schedule queues the recurring jobs that are due at now. It is called by
+// the scheduler every poll interval.
+func (q *Queue) Schedule(now time.Time) {
+	for _, s := range q.schedules {
+		if err := q.schedule(s, now); err != nil {
+			log.Printf("job: failed to schedule %s: %v", s.name, err)
+		}
+	}
+}
+
+func (q *Queue) schedule(s schedule, now time.Time) error {
+	stored, err := q.repo.FindSchedule(s.name, s.spec, s.next.Next(now))
+	if err != nil {
+		return err
+	}
+
+	// A changed spec takes effect from now on.
+	if stored.Spec != s.spec {
+		_, err := q.repo.AdvanceSchedule(stored, s.spec, s.next.Next(now), nil)
+		return err
+	}
+	if stored.NextRunAt.After(now) {
+		return nil
+	}
+
+	// Runs missed while no instance was up are not caught up; the next run
+	// is computed from now.
+	return q.repo.Transaction(func(tx *gorm.DB) error {
+		repo := q.repo.WithTx(tx)
+		advanced, err := repo.AdvanceSchedule(stored, s.spec, s.next.Next(now), &now)
+		if err != nil || !advanced {
+			return err
+		}
+		_, err = q.enqueue(repo, s.job, []EnqueueOption{Unique("schedule:" + s.name)})
+		if err == ErrDuplicate {
+			log.Printf("job: skipped %s, its previous run is still queued", s.name)
+			return nil
+		}
+		return err
+	})
+}
+
+func backoff(base time.Duration, failedAttempts int) time.Duration {
+	delay := base
+	for i := 1; i < failedAttempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max]
+}
@@ -0,0 +1,176 @@
+package job
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Create queues a job. It returns false without an error when a job
+	// with the same unique key is still pending or running.
+	Create(record *Record) (bool, error)
+	// Claim locks the next due job of one of the types until lockedUntil
+	// and counts the attempt. It returns nil when no job is due. Workers of
+	// other instances skip the rows locked by the claim instead of waiting.
+	Claim(types []string, now, lockedUntil time.Time) (*Record, error)
+	// SaveResult writes the outcome of an attempt and releases the lock.
+	SaveResult(record *Record) error
+	FindByID(id uint) (*Record, error)
+	FindAll(query ListQuery, offset, limit int) ([]Record, int64, error)
+	// DeleteSucceededBefore removes jobs that succeeded before the given
+	// time.
+	DeleteSucceededBefore(before time.Time) (int64, error)
+
+	// FindSchedule returns the schedule with the name, creating it with
+	// spec and next when it doesn't exist yet.
+	FindSchedule(name, spec string, next time.Time) (*Schedule, error)
+	// AdvanceSchedule moves a schedule from its current next run to next and
+	// records ranAt unless it is nil. It returns false when another instance
+	// advanced it first.
+	AdvanceSchedule(schedule *Schedule, spec string, next time.Time, ranAt *time.Time) (bool, error)
+
+	WithTx(tx *gorm.DB) Repository
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(record *Record) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) Claim(types []string, now, lockedUntil time.Time) (*Record, error) {
+	if len(types) == 0 {
+		return nil, nil
+	}
+
+	var claimed *Record
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var records []Record
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until <= ?)",
+				StatusPending, now, StatusRunning, now).
+			Where("type IN ?", types).
+			Order("run_at, id").
+			Limit(1).
+			Find(&records).Error
+		if err != nil || len(records) == 0 {
+			return err
+		}
+
+		record := &records[0]
+		record.Status = StatusRunning
+		record.Attempts++
+		record.LockedUntil = &lockedUntil
+		record.StartedAt = &now
+		err = tx.Model(&Record{}).Where("id = ?", record.ID).UpdateColumns(map[string]interface{}{
+			"status":       record.Status,
+			"attempts":     record.Attempts,
+			"locked_until": record.LockedUntil,
+			"started_at":   record.StartedAt,
+			"updated_at":   now,
+		}).Error
+		if err != nil {
+			return err
+		}
+		claimed = record
+		return nil
+	})
+	return claimed, err
+}
+
+func (r *repository) SaveResult(record *Record) error {
+	record.UpdatedAt = time.Now()
+	return r.db.Model(&Record{}).Where("id = ?", record.ID).UpdateColumns(map[string]interface{}{
+		"status":       record.Status,
+		"run_at":       record.RunAt,
+		"attempts":     record.Attempts,
+		"locked_until": record.LockedUntil,
+		"unique_key":   record.UniqueKey,
+		"last_error":   record.LastError,
+		"finished_at":  record.FinishedAt,
+		"updated_at":   record.UpdatedAt,
+	}).Error
+}
+
+func (r *repository) FindByID(id uint) (*Record, error) {
+	var record Record
+	if err := r.db.First(&record, id).Error; err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+func (r *repository) FindAll(query ListQuery, offset, limit int) ([]Record, int64, error) {
+	db := r.db.Model(&Record{})
+	if query.Status != "" {
+		db = db.Where("status = ?", query.Status)
+	}
+	if query.Type != "" {
+		db = db.Where("type = ?", query.Type)
+	}
+	db = db.Session(&gorm.Session{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var records []Record
+	err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&records).Error
+	return records, total, err
+}
+
+func (r *repository) DeleteSucceededBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND finished_at < ?", StatusSucceeded, before).Delete(&Record{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *repository) FindSchedule(name, spec string, next time.Time) (*Schedule, error) {
+	schedule := Schedule{Name: name, Spec: spec, NextRunAt: next}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&schedule).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.First(&schedule, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+func (r *repository) AdvanceSchedule(schedule *Schedule, spec string, next time.Time, ranAt *time.Time) (bool, error) {
+	columns := map[string]interface{}{
+		"spec":        spec,
+		"next_run_at": next,
+		"updated_at":  time.Now(),
+	}
+	if ranAt != nil {
+		columns["last_run_at"] = ranAt
+	}
+	result := r.db.Model(&Schedule{}).
+		Where("name = ? AND next_run_at = ?", schedule.Name, schedule.NextRunAt).
+		UpdateColumns(columns)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
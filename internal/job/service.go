@@ -0,0 +1,87 @@
+package job
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"gorm.io/gorm"
+)
+
+// Trigger wakes the workers after a job became due. It is implemented by
+// Queue.
+type Trigger interface {
+	Trigger()
+}
+
+// Service lets admins inspect and retry jobs.
+type Service interface {
+	GetJobs(query ListQuery) ([]JobResponse, *pagination.Meta, apperror.AppErrors)
+	GetJob(id uint) (*JobResponse, apperror.AppErrors)
+	// RetryJob runs a dead or pending job again right away with a fresh
+	// set of attempts.
+	RetryJob(id uint) (*JobResponse, apperror.AppErrors)
+}
+
+type service struct {
+	repo    Repository
+	trigger Trigger
+}
+
+func NewService(repo Repository, trigger Trigger) Service {
+	return &service{repo: repo, trigger: trigger}
+}
+
+func (s *service) GetJobs(query ListQuery) ([]JobResponse, *pagination.Meta, apperror.AppErrors) {
+	query.Params = query.Params.Normalize()
+	records, total, err := s.repo.FindAll(query, query.Offset(), query.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]JobResponse, 0, len(records))
+	for i := range records {
+		responses = append(responses, *records[i].ToResponse())
+	}
+	return responses, pagination.NewMeta(query.Params, total), nil
+}
+
+func (s *service) GetJob(id uint) (*JobResponse, apperror.AppErrors) {
+	record, appErr := s.find(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return record.ToResponse(), nil
+}
+
+func (s *service) RetryJob(id uint) (*JobResponse, apperror.AppErrors) {
+	record, appErr := s.find(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if record.Status != StatusDead && record.Status != StatusPending {
+		return nil, apperror.JobNotRetryable(record.Status)
+	}
+
+	record.Status = StatusPending
+	record.RunAt = time.Now()
+	record.Attempts = 0
+	record.FinishedAt = nil
+	if err := s.repo.SaveResult(record); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	s.trigger.Trigger()
+
+	return record.ToResponse(), nil
+}
+
+func (s *service) find(id uint) (*Record, apperror.AppErrors) {
+	record, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.JobNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	return record, nil
+}
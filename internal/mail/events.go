@@ -0,0 +1,50 @@
+package mail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+// UserReader loads the recipient of an email.
+type UserReader interface {
+	GetUserByID(id uint) (*user.UserResponse, apperror.AppErrors)
+}
+
+// Site is the application the emails are sent on behalf of.
+type Site struct {
+	Name string
+	URL  string
+}
+
+type welcomeData struct {
+	Name string
+	Site Site
+}
+
+// Subscribe sends the emails triggered by outbox events: a welcome email to
+// every registered user.
+func Subscribe(dispatcher *outbox.Dispatcher, service Service, users UserReader, site Site, locale string) {
+	outbox.Subscribe(dispatcher, "mail", func(ctx context.Context, meta outbox.Metadata, event user.UserRegistered) error {
+		u, appErr := users.GetUserByID(event.UserID)
+		if appErr != nil {
+			if appErr.Has("user") {
+				return nil
+			}
+			return fmt.Errorf("load user %d: %v", event.UserID, appErr)
+		}
+
+		// A repeat of the event while its email is still queued is dropped.
+		err := service.Send([]string{u.Email}, "welcome", locale, welcomeData{Name: u.Name, Site: site},
+			job.Unique(fmt.Sprintf("mail.welcome:%d", meta.MessageID)))
+		if errors.Is(err, job.ErrDuplicate) {
+			return nil
+		}
+		return err
+	})
+}
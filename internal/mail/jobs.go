@@ -0,0 +1,23 @@
+package mail
+
+import (
+	"context"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/ardipermana59/go-template/pkg/mailer"
+)
+
+// SendJob delivers a rendered message.
+type SendJob struct {
+	Message mailer.Message `json:"message"`
+}
+
+func (SendJob) JobType() string { return "mail.send" }
+
+// RegisterJobs registers the delivery of queued messages through m.
+func RegisterJobs(queue *job.Queue, m mailer.Mailer) {
+	job.Handle(queue, func(ctx context.Context, j SendJob) error {
+		return m.Send(ctx, &j.Message)
+	}, job.Timeout(time.Minute))
+}
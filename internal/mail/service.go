@@ -0,0 +1,66 @@
+package mail
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"net/mail"
+
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/ardipermana59/go-template/pkg/mailer"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+// Templates parses the email templates shipped with the application.
+func Templates(defaultLocale string) (*mailer.Templates, error) {
+	files, err := fs.Sub(templateFiles, "templates")
+	if err != nil {
+		return nil, err
+	}
+	return mailer.ParseTemplates(files, defaultLocale)
+}
+
+// Service renders emails and queues them for delivery.
+type Service interface {
+	// Send renders the template in locale, falling back to the default
+	// locale, and queues the message to the recipients. The message is sent
+	// by a SendJob, which retries when the mail server is unavailable.
+	Send(to []string, template, locale string, data interface{}, opts ...job.EnqueueOption) error
+}
+
+type service struct {
+	templates *mailer.Templates
+	jobs      job.Enqueuer
+	from      string
+}
+
+func NewService(templates *mailer.Templates, jobs job.Enqueuer, from string) Service {
+	return &service{templates: templates, jobs: jobs, from: from}
+}
+
+func (s *service) Send(to []string, template, locale string, data interface{}, opts ...job.EnqueueOption) error {
+	msg, err := s.templates.Render(template, locale, data)
+	if err != nil {
+		return err
+	}
+	msg.From = s.from
+	msg.To = to
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	if _, err := s.jobs.Enqueue(SendJob{Message: *msg}, opts...); err != nil {
+		return fmt.Errorf("mail: queueing %s: %w", template, err)
+	}
+	return nil
+}
+
+// CheckFrom validates the configured sender address.
+func CheckFrom(from string) error {
+	if _, err := mail.ParseAddress(from); err != nil {
+		return fmt.Errorf("invalid sender address %q", from)
+	}
+	return nil
+}
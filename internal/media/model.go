@@ -0,0 +1,83 @@
+package media
+
+import (
+	"io"
+	"time"
+)
+
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// Image is an uploaded picture such as an avatar or a post cover. The
+// original is only kept until the variants have been generated; clients are
+// only ever served the re-encoded variants, which carry no EXIF metadata.
+type Image struct {
+	ID          uint           `json:"id" gorm:"primaryKey"`
+	UserID      uint           `json:"user_id" gorm:"not null;index"`
+	Prefix      string         `json:"-" gorm:"size:191;uniqueIndex;not null"`
+	OriginalKey string         `json:"-" gorm:"size:255"`
+	Status      string         `json:"status" gorm:"size:16;not null;default:'pending';index"`
+	Width       int            `json:"width"`
+	Height      int            `json:"height"`
+	Variants    []ImageVariant `json:"variants" gorm:"foreignKey:ImageID;constraint:OnDelete:CASCADE"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+type ImageVariant struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	ImageID    uint   `json:"image_id" gorm:"not null;index"`
+	Size       int    `json:"size" gorm:"not null"`
+	Format     string `json:"format" gorm:"size:16;not null"`
+	StorageKey string `json:"-" gorm:"size:255;not null"`
+	URL        string `json:"url" gorm:"size:512;not null"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+}
+
+// Upload describes an image received from a client.
+type Upload struct {
+	FileName string
+	Size     int64
+	Body     io.Reader
+}
+
+type ImageResponse struct {
+	ID       uint              `json:"id"`
+	Status   string            `json:"status"`
+	Width    int               `json:"width"`
+	Height   int               `json:"height"`
+	Variants []VariantResponse `json:"variants"`
+}
+
+type VariantResponse struct {
+	Size   int    `json:"size"`
+	Format string `json:"format"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+func (i *Image) ToResponse() *ImageResponse {
+	variants := make([]VariantResponse, 0, len(i.Variants))
+	for _, v := range i.Variants {
+		variants = append(variants, VariantResponse{
+			Size:   v.Size,
+			Format: v.Format,
+			URL:    v.URL,
+			Width:  v.Width,
+			Height: v.Height,
+		})
+	}
+
+	return &ImageResponse{
+		ID:       i.ID,
+		Status:   i.Status,
+		Width:    i.Width,
+		Height:   i.Height,
+		Variants: variants,
+	}
+}
@@ -0,0 +1,80 @@
+package media
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(image *Image) error
+	FindByID(id uint) (*Image, error)
+	FindPendingIDs() ([]uint, error)
+	// Complete stores the generated variants and marks the image ready.
+	Complete(image *Image, variants []ImageVariant) error
+	MarkFailed(id uint) error
+	Delete(id uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(image *Image) error {
+	return r.db.Omit(clause.Associations).Create(image).Error
+}
+
+func (r *repository) FindByID(id uint) (*Image, error) {
+	var image Image
+	err := r.db.Preload("Variants").First(&image, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+func (r *repository) FindPendingIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&Image{}).Where("status = ?", StatusPending).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *repository) Complete(image *Image, variants []ImageVariant) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&Image{}).
+			Where("id = ? AND status = ?", image.ID, StatusPending).
+			Updates(map[string]interface{}{
+				"status":       StatusReady,
+				"width":        image.Width,
+				"height":       image.Height,
+				"original_key": "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		for i := range variants {
+			variants[i].ImageID = image.ID
+		}
+		if len(variants) == 0 {
+			return nil
+		}
+		return tx.Create(&variants).Error
+	})
+}
+
+func (r *repository) MarkFailed(id uint) error {
+	return r.db.Model(&Image{}).
+		Where("id = ? AND status = ?", id, StatusPending).
+		Updates(map[string]interface{}{"status": StatusFailed, "original_key": ""}).Error
+}
+
+func (r *repository) Delete(id uint) error {
+	return r.db.Select("Variants").Delete(&Image{ID: id}).Error
+}
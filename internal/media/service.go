@@ -0,0 +1,246 @@
+package media
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/pkg/storage"
+	"gorm.io/gorm"
+)
+
+const megabyte = 1 << 20
+
+var allowedTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Queue schedules an uploaded image for processing.
+type Queue interface {
+	Enqueue(imageID uint)
+}
+
+type Service interface {
+	// Upload stores the original and queues the image for processing. The
+	// returned image is still pending and has no variants yet.
+	Upload(ctx context.Context, userID uint, upload Upload) (*Image, apperror.AppErrors)
+	// Process generates the variants of a pending image.
+	Process(ctx context.Context, id uint) error
+	// Delete removes an image and all of its stored files.
+	Delete(ctx context.Context, id uint) error
+	PendingIDs() ([]uint, error)
+}
+
+type service struct {
+	repo      Repository
+	storage   storage.Storage
+	queue     Queue
+	maxSizeMB int
+	sizes     []int
+	formats   []string
+}
+
+func NewService(repo Repository, store storage.Storage, queue Queue, maxSizeMB int, sizes []int, formats []string) Service {
+	return &service{
+		repo:      repo,
+		storage:   store,
+		queue:     queue,
+		maxSizeMB: maxSizeMB,
+		sizes:     sizes,
+		formats:   formats,
+	}
+}
+
+func (s *service) Upload(ctx context.Context, userID uint, upload Upload) (*Image, apperror.AppErrors) {
+	maxSize := int64(s.maxSizeMB) * megabyte
+	if upload.Size > maxSize {
+		return nil, apperror.FileTooLarge(s.maxSizeMB)
+	}
+
+	body := bufio.NewReaderSize(upload.Body, 512)
+	head, _ := body.Peek(512)
+	contentType := http.DetectContentType(head)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return nil, apperror.UnsupportedFileType(contentType)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxSize+1))
+	if err != nil {
+		return nil, apperror.StorageError(err)
+	}
+	if int64(len(data)) > maxSize {
+		return nil, apperror.FileTooLarge(s.maxSizeMB)
+	}
+	if _, _, err := decodeConfig(data); err != nil {
+		return nil, apperror.InvalidImage()
+	}
+
+	prefix, err := newPrefix()
+	if err != nil {
+		return nil, apperror.StorageError(err)
+	}
+	image := &Image{
+		UserID:      userID,
+		Prefix:      prefix,
+		OriginalKey: prefix + "/original" + ext,
+		Status:      StatusPending,
+	}
+
+	if err := s.storage.Put(ctx, image.OriginalKey, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, apperror.StorageError(err)
+	}
+	if err := s.repo.Create(image); err != nil {
+		s.storage.Delete(ctx, image.OriginalKey)
+		return nil, apperror.DatabaseError(err)
+	}
+
+	s.queue.Enqueue(image.ID)
+	return image, nil
+}
+
+func (s *service) Process(ctx context.Context, id uint) error {
+	image, err := s.repo.FindByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if image.Status != StatusPending {
+		return nil
+	}
+
+	if err := s.process(ctx, image); err != nil {
+		if markErr := s.repo.MarkFailed(image.ID); markErr != nil {
+			log.Printf("media: failed to mark image %d as failed: %v", image.ID, markErr)
+		}
+		s.storage.Delete(ctx, image.OriginalKey)
+		return fmt.Errorf("media: processing image %d: %w", image.ID, err)
+	}
+	return nil
+}
+
+func (s *service) process(ctx context.Context, image *Image) error {
+	reader, err := s.storage.Open(ctx, image.OriginalKey)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	img, err := decode(data)
+	if err != nil {
+		return err
+	}
+	image.Width = img.Bounds().Dx()
+	image.Height = img.Bounds().Dy()
+
+	// Each run writes under its own token so a duplicate run of the same
+	// image can never remove the files of the run that completed it.
+	token := make([]byte, 4)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+
+	var variants []ImageVariant
+	var written []string
+	cleanup := func() {
+		for _, key := range written {
+			s.storage.Delete(ctx, key)
+		}
+	}
+
+	for _, size := range s.sizes {
+		resized := resize(img, size)
+		for _, format := range s.formats {
+			enc := encoders[format]
+			var buf bytes.Buffer
+			if err := enc.encode(&buf, resized); err != nil {
+				cleanup()
+				return err
+			}
+
+			key := image.Prefix + "/" + strconv.Itoa(size) + "-" + hex.EncodeToString(token) + enc.ext
+			if err := s.storage.Put(ctx, key, &buf, int64(buf.Len()), enc.contentType); err != nil {
+				cleanup()
+				return err
+			}
+			written = append(written, key)
+
+			variants = append(variants, ImageVariant{
+				Size:       size,
+				Format:     format,
+				StorageKey: key,
+				URL:        s.storage.URL(key),
+				Width:      resized.Bounds().Dx(),
+				Height:     resized.Bounds().Dy(),
+			})
+		}
+	}
+
+	// Complete fails when the image was deleted while it was being
+	// processed; the variants written above are removed again in that case.
+	if err := s.repo.Complete(image, variants); err != nil {
+		cleanup()
+		if err == gorm.ErrRecordNotFound {
+			s.storage.Delete(ctx, image.OriginalKey)
+			return nil
+		}
+		return err
+	}
+
+	// The original still carries the client's metadata, including any GPS
+	// position, so it is not kept once the variants exist.
+	return s.storage.Delete(ctx, image.OriginalKey)
+}
+
+func (s *service) Delete(ctx context.Context, id uint) error {
+	image, err := s.repo.FindByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+
+	for _, v := range image.Variants {
+		if err := s.storage.Delete(ctx, v.StorageKey); err != nil {
+			return err
+		}
+	}
+	if image.OriginalKey != "" {
+		return s.storage.Delete(ctx, image.OriginalKey)
+	}
+	return nil
+}
+
+func (s *service) PendingIDs() ([]uint, error) {
+	return s.repo.FindPendingIDs()
+}
+
+func newPrefix() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "images/" + hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,85 @@
+package media
+
+import (
+	"context"
+	"log"
+	"sync"
+)
+
+// Worker processes uploaded images in the background. It implements Queue.
+type Worker struct {
+	jobs        chan uint
+	concurrency int
+
+	mu     sync.Mutex
+	closed bool
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewWorker(concurrency, buffer int) *Worker {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Worker{
+		jobs:        make(chan uint, buffer),
+		concurrency: concurrency,
+	}
+}
+
+// Enqueue schedules an image. Images that cannot be queued because the
+// worker has stopped stay pending and are picked up on the next Start.
+func (w *Worker) Enqueue(imageID uint) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.closed {
+		return
+	}
+	w.jobs <- imageID
+}
+
+// Start launches the worker goroutines and re-queues images left pending by
+// a previous run.
+func (w *Worker) Start(service Service) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+
+	for i := 0; i < w.concurrency; i++ {
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			for id := range w.jobs {
+				if err := service.Process(ctx, id); err != nil {
+					log.Println(err)
+				}
+			}
+		}()
+	}
+
+	go func() {
+		ids, err := service.PendingIDs()
+		if err != nil {
+			log.Printf("media: failed to load pending images: %v", err)
+			return
+		}
+		for _, id := range ids {
+			w.Enqueue(id)
+		}
+	}()
+}
+
+// Stop stops accepting images, lets the queued ones finish and waits for
+// the worker goroutines to exit.
+func (w *Worker) Stop() {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.jobs)
+	}
+	w.mu.Unlock()
+
+	w.wg.Wait()
+	if w.cancel != nil {
+		w.cancel()
+	}
+}
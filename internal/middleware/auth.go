@@ -43,6 +43,60 @@ func AuthMiddleware(jwtService auth.JWTService) gin.HandlerFunc {
 	}
 }
 
+// QueryTokenMiddleware runs before AuthMiddleware and accepts the bearer
+// token in the access_token query parameter, for clients that cannot set
+// headers such as EventSource and browser WebSockets. URLs end up in logs, so
+// use it only on routes that need it.
+func QueryTokenMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token := c.Query("access_token"); token != "" && c.GetHeader("Authorization") == "" {
+			c.Request.Header.Set("Authorization", "Bearer "+token)
+		}
+		c.Next()
+	}
+}
+
+// AccountChecker reports whether the account behind a valid token may still
+// use the API.
+type AccountChecker interface {
+	IsActive(userID uint) (bool, error)
+}
+
+// ActiveAccountMiddleware runs after AuthMiddleware and rejects tokens of
+// accounts that were deleted or disabled after the token was issued.
+func ActiveAccountMiddleware(checker AccountChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		active, err := checker.IsActive(c.GetUint("user_id"))
+		if err != nil {
+			response.InternalError(c, err)
+			c.Abort()
+			return
+		}
+		if !active {
+			response.Error(c, http.StatusForbidden, "Account disabled", apperror.AccountDisabled())
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware identifies the user when a valid bearer token is
+// sent but lets anonymous requests through, leaving user_id unset.
+func OptionalAuthMiddleware(jwtService auth.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(tokenParts) == 2 && tokenParts[0] == "Bearer" {
+			if claims, err := jwtService.ValidateToken(tokenParts[1]); err == nil {
+				c.Set("user_id", claims.UserID)
+				c.Set("user_email", claims.Email)
+				c.Set("user_role", claims.Role)
+			}
+		}
+		c.Next()
+	}
+}
+
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("user_role")
@@ -0,0 +1,14 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// ImmutableCache marks responses as cacheable for a year. It is meant for
+// files whose keys change whenever their content does, such as uploads.
+func ImmutableCache() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Cache-Control", "public, max-age=31536000, immutable")
+		c.Next()
+	}
+}
@@ -0,0 +1,100 @@
+package moderation
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) ReportPost(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var dto CreateReportDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	report, appErr := h.service.ReportPost(uint(postID), c.GetUint("user_id"), dto)
+	if appErr != nil {
+		switch {
+		case appErr.Has("post"):
+			response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		case appErr.Has("report"):
+			response.Error(c, http.StatusConflict, "Failed to report post", appErr)
+		default:
+			response.Error(c, http.StatusBadRequest, "Failed to report post", appErr)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Post reported successfully", report)
+}
+
+func (h *Handler) GetQueue(c *gin.Context) {
+	var params pagination.Params
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	items, meta, appErr := h.service.GetQueue(params)
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Moderation queue retrieved successfully", items, meta)
+}
+
+func (h *Handler) TakeAction(c *gin.Context) {
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var dto ActionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	action, appErr := h.service.TakeAction(uint(postID), c.GetUint("user_id"), dto)
+	if appErr != nil {
+		if appErr.Has("post") {
+			response.Error(c, http.StatusNotFound, "Post not found", appErr)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to apply moderation action", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Moderation action applied successfully", action)
+}
+
+func (h *Handler) GetMyWarnings(c *gin.Context) {
+	warnings, appErr := h.service.GetWarnings(c.GetUint("user_id"))
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Warnings retrieved successfully", warnings)
+}
@@ -0,0 +1,124 @@
+package moderation
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+
+	ActionHide    = "hide"
+	ActionRestore = "restore"
+	ActionRemove  = "remove"
+	ActionDismiss = "dismiss"
+	ActionWarn    = "warn"
+
+	// ReasonAutomatic marks reports filed by the content filter. They have
+	// no reporter.
+	ReasonAutomatic = "automatic"
+)
+
+// Report flags a post for moderators. Every user can report a post once;
+// reports of the content filter have no ReporterID.
+type Report struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	PostID     uint       `json:"post_id" gorm:"not null;uniqueIndex:idx_reports_post_reporter"`
+	Post       post.Post  `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	ReporterID *uint      `json:"reporter_id" gorm:"uniqueIndex:idx_reports_post_reporter;index"`
+	Reporter   *user.User `json:"-" gorm:"foreignKey:ReporterID;constraint:OnDelete:CASCADE"`
+	Reason     string     `json:"reason" gorm:"size:30;not null"`
+	Details    string     `json:"details" gorm:"type:text"`
+	Status     string     `json:"status" gorm:"size:20;not null;default:'open';index"`
+	ActionID   *uint      `json:"action_id"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Action records a moderator decision. It keeps the post and author IDs
+// without foreign keys so the record outlives removed posts.
+type Action struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	PostID      uint      `json:"post_id" gorm:"not null;index"`
+	AuthorID    uint      `json:"author_id" gorm:"not null;index"`
+	ModeratorID uint      `json:"moderator_id" gorm:"not null"`
+	Action      string    `json:"action" gorm:"size:20;not null"`
+	Note        string    `json:"note" gorm:"type:text"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (Action) TableName() string {
+	return "moderation_actions"
+}
+
+type CreateReportDTO struct {
+	Reason  string `json:"reason" binding:"required,oneof=spam harassment hate violence sexual misinformation other"`
+	Details string `json:"details" binding:"max=1000"`
+}
+
+type ActionDTO struct {
+	Action string `json:"action" binding:"required,oneof=hide restore remove dismiss warn"`
+	Note   string `json:"note" binding:"max=2000"`
+}
+
+type ReportResponse struct {
+	ID         uint       `json:"id"`
+	PostID     uint       `json:"post_id"`
+	ReporterID *uint      `json:"reporter_id"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details"`
+	Status     string     `json:"status"`
+	ActionID   *uint      `json:"action_id"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// QueueItem is a post with open reports, as shown to moderators.
+type QueueItem struct {
+	Post            *post.PostResponse `json:"post"`
+	ReportCount     int64              `json:"report_count"`
+	FirstReportedAt time.Time          `json:"first_reported_at"`
+	Reports         []ReportResponse   `json:"reports"`
+}
+
+// WarningResponse is a warning as shown to the warned author.
+type WarningResponse struct {
+	ID        uint      `json:"id"`
+	PostID    uint      `json:"post_id"`
+	Note      string    `json:"note"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserData is the privacy export section of a user: the reports they filed
+// and the warnings they received.
+type UserData struct {
+	Reports  []ReportResponse  `json:"reports"`
+	Warnings []WarningResponse `json:"warnings"`
+}
+
+func (r *Report) ToResponse() *ReportResponse {
+	return &ReportResponse{
+		ID:         r.ID,
+		PostID:     r.PostID,
+		ReporterID: r.ReporterID,
+		Reason:     r.Reason,
+		Details:    r.Details,
+		Status:     r.Status,
+		ActionID:   r.ActionID,
+		ResolvedAt: r.ResolvedAt,
+		CreatedAt:  r.CreatedAt,
+	}
+}
+
+func (a *Action) ToWarning() *WarningResponse {
+	return &WarningResponse{
+		ID:        a.ID,
+		PostID:    a.PostID,
+		Note:      a.Note,
+		CreatedAt: a.CreatedAt,
+	}
+}
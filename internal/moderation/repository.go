@@ -0,0 +1,114 @@
+package moderation
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// QueueRow aggregates the open reports of one post.
+type QueueRow struct {
+	PostID          uint
+	ReportCount     int64
+	FirstReportedAt time.Time
+}
+
+type Repository interface {
+	// CreateReport returns false when the user already reported the post.
+	CreateReport(report *Report) (bool, error)
+	// FindQueue returns posts with open reports, the most reported first.
+	FindQueue(offset, limit int) ([]QueueRow, int64, error)
+	FindOpenReports(postIDs []uint) ([]Report, error)
+	FindByReporterID(reporterID uint) ([]Report, error)
+	// ResolveReports closes the open reports of a post with status.
+	ResolveReports(postID uint, status string, actionID uint) error
+	CreateAction(action *Action) error
+	FindWarnings(authorID uint) ([]Action, error)
+	WithTx(tx *gorm.DB) Repository
+	Transaction(fn func(tx *gorm.DB) error) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateReport(report *Report) (bool, error) {
+	result := r.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(report)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) FindQueue(offset, limit int) ([]QueueRow, int64, error) {
+	open := r.db.Model(&Report{}).Where("status = ?", ReportStatusOpen)
+
+	var total int64
+	if err := open.Session(&gorm.Session{}).Distinct("post_id").Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var rows []QueueRow
+	err := open.Session(&gorm.Session{}).
+		Select("post_id, COUNT(*) AS report_count, MIN(created_at) AS first_reported_at").
+		Group("post_id").
+		Order("report_count DESC, first_reported_at ASC, post_id ASC").
+		Offset(offset).
+		Limit(limit).
+		Scan(&rows).Error
+	return rows, total, err
+}
+
+func (r *repository) FindOpenReports(postIDs []uint) ([]Report, error) {
+	var reports []Report
+	if len(postIDs) == 0 {
+		return reports, nil
+	}
+	err := r.db.Where("post_id IN ? AND status = ?", postIDs, ReportStatusOpen).
+		Order("created_at ASC, id ASC").
+		Find(&reports).Error
+	return reports, err
+}
+
+func (r *repository) FindByReporterID(reporterID uint) ([]Report, error) {
+	var reports []Report
+	err := r.db.Where("reporter_id = ?", reporterID).Order("id").Find(&reports).Error
+	return reports, err
+}
+
+func (r *repository) ResolveReports(postID uint, status string, actionID uint) error {
+	return r.db.Model(&Report{}).
+		Where("post_id = ? AND status = ?", postID, ReportStatusOpen).
+		UpdateColumns(map[string]interface{}{
+			"status":      status,
+			"action_id":   actionID,
+			"resolved_at": time.Now(),
+		}).Error
+}
+
+func (r *repository) CreateAction(action *Action) error {
+	return r.db.Create(action).Error
+}
+
+func (r *repository) FindWarnings(authorID uint) ([]Action, error) {
+	var actions []Action
+	err := r.db.Where("author_id = ? AND action = ?", authorID, ActionWarn).
+		Order("created_at DESC, id DESC").
+		Find(&actions).Error
+	return actions, err
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
@@ -0,0 +1,213 @@
+package moderation
+
+import (
+	"strings"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/post"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	// ReportPost files a report. Hidden posts can only be reported by
+	// users who can still see them.
+	ReportPost(postID, userID uint, dto CreateReportDTO) (*ReportResponse, apperror.AppErrors)
+	GetQueue(params pagination.Params) ([]QueueItem, *pagination.Meta, apperror.AppErrors)
+	// TakeAction applies a moderator decision to a post and closes its open
+	// reports with it.
+	TakeAction(postID, moderatorID uint, dto ActionDTO) (*Action, apperror.AppErrors)
+	GetWarnings(userID uint) ([]WarningResponse, apperror.AppErrors)
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+type service struct {
+	repo        Repository
+	postRepo    post.Repository
+	postService post.Service
+}
+
+func NewService(repo Repository, postRepo post.Repository, postService post.Service) Service {
+	return &service{
+		repo:        repo,
+		postRepo:    postRepo,
+		postService: postService,
+	}
+}
+
+func (s *service) ReportPost(postID, userID uint, dto CreateReportDTO) (*ReportResponse, apperror.AppErrors) {
+	p, appErr := s.postService.GetPostByID(postID, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if p.UserID == userID {
+		return nil, apperror.CannotReportOwnPost()
+	}
+
+	report := &Report{
+		PostID:     postID,
+		ReporterID: &userID,
+		Reason:     dto.Reason,
+		Details:    dto.Details,
+		Status:     ReportStatusOpen,
+	}
+	created, err := s.repo.CreateReport(report)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	if !created {
+		return nil, apperror.PostAlreadyReported()
+	}
+
+	return report.ToResponse(), nil
+}
+
+func (s *service) GetQueue(params pagination.Params) ([]QueueItem, *pagination.Meta, apperror.AppErrors) {
+	params = params.Normalize()
+	rows, total, err := s.repo.FindQueue(params.Offset(), params.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	ids := make([]uint, 0, len(rows))
+	for _, row := range rows {
+		ids = append(ids, row.PostID)
+	}
+
+	posts, err := s.postRepo.FindByIDs(ids)
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+	byID := make(map[uint]*post.PostResponse, len(posts))
+	for i := range posts {
+		byID[posts[i].ID] = posts[i].ToResponse()
+	}
+
+	reports, err := s.repo.FindOpenReports(ids)
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+	byPost := make(map[uint][]ReportResponse, len(rows))
+	for i := range reports {
+		byPost[reports[i].PostID] = append(byPost[reports[i].PostID], *reports[i].ToResponse())
+	}
+
+	items := make([]QueueItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, QueueItem{
+			Post:            byID[row.PostID],
+			ReportCount:     row.ReportCount,
+			FirstReportedAt: row.FirstReportedAt,
+			Reports:         byPost[row.PostID],
+		})
+	}
+
+	return items, pagination.NewMeta(params, total), nil
+}
+
+func (s *service) TakeAction(postID, moderatorID uint, dto ActionDTO) (*Action, apperror.AppErrors) {
+	p, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	action := &Action{
+		PostID:      postID,
+		AuthorID:    p.UserID,
+		ModeratorID: moderatorID,
+		Action:      dto.Action,
+		Note:        dto.Note,
+	}
+	status := ReportStatusResolved
+	if dto.Action == ActionRestore || dto.Action == ActionDismiss {
+		status = ReportStatusDismissed
+	}
+	// The decision is recorded in the transaction that changes the post, so
+	// neither is kept without the other. Removing the post also removes its
+	// reports.
+	decision := func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if err := repo.CreateAction(action); err != nil {
+			return err
+		}
+		return repo.ResolveReports(postID, status, action.ID)
+	}
+
+	var appErr apperror.AppErrors
+	switch dto.Action {
+	case ActionHide:
+		appErr = s.postService.HidePost(postID, true, decision)
+	case ActionRestore:
+		appErr = s.postService.HidePost(postID, false, decision)
+	case ActionRemove:
+		appErr = s.postService.RemovePost(postID, decision)
+	default:
+		if err := s.repo.Transaction(decision); err != nil {
+			appErr = apperror.DatabaseError(err)
+		}
+	}
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	return action, nil
+}
+
+// ReviewHook returns the post.ReviewHook that queues flagged posts with an
+// automatic report. It only needs the repository, so it can be registered
+// before the post service exists.
+func ReviewHook(repo Repository) post.ReviewHook {
+	return func(tx *gorm.DB, p *post.Post, reasons apperror.AppErrors) error {
+		messages := make([]string, 0, len(reasons))
+		for _, reason := range reasons {
+			messages = append(messages, reason.Message)
+		}
+
+		_, err := repo.WithTx(tx).CreateReport(&Report{
+			PostID:  p.ID,
+			Reason:  ReasonAutomatic,
+			Details: strings.Join(messages, "\n"),
+			Status:  ReportStatusOpen,
+		})
+		return err
+	}
+}
+
+func (s *service) GetWarnings(userID uint) ([]WarningResponse, apperror.AppErrors) {
+	actions, err := s.repo.FindWarnings(userID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	warnings := make([]WarningResponse, 0, len(actions))
+	for i := range actions {
+		warnings = append(warnings, *actions[i].ToWarning())
+	}
+	return warnings, nil
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	reports, err := s.repo.FindByReporterID(userID)
+	if err != nil {
+		return nil, err
+	}
+	warnings, err := s.repo.FindWarnings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data := UserData{
+		Reports:  make([]ReportResponse, 0, len(reports)),
+		Warnings: make([]WarningResponse, 0, len(warnings)),
+	}
+	for i := range reports {
+		data.Reports = append(data.Reports, *reports[i].ToResponse())
+	}
+	for i := range warnings {
+		data.Warnings = append(data.Warnings, *warnings[i].ToWarning())
+	}
+	return data, nil
+}
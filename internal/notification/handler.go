@@ -0,0 +1,73 @@
+package notification
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetNotifications(c *gin.Context) {
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	notifications, meta, appErr := h.service.GetNotifications(c.GetUint("user_id"), query)
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Notifications retrieved successfully", notifications, meta)
+}
+
+func (h *Handler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	notification, appErr := h.service.MarkRead(uint(id), c.GetUint("user_id"))
+	if appErr != nil {
+		if appErr.Has("notification") {
+			response.Error(c, http.StatusNotFound, "Notification not found", appErr)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to mark notification read", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notification marked read", notification)
+}
+
+// MarkAllRead accepts an empty body to mark every notification read.
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	var dto MarkReadDTO
+	if err := c.ShouldBindJSON(&dto); err != nil && !errors.Is(err, io.EOF) {
+		response.ValidationError(c, err)
+		return
+	}
+
+	result, appErr := h.service.MarkAllRead(c.GetUint("user_id"), dto)
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Notifications marked read", result)
+}
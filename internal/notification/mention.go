@@ -0,0 +1,33 @@
+package notification
+
+import (
+	"regexp"
+	"strings"
+)
+
+// MaxMentions caps the users one post can notify.
+const MaxMentions = 20
+
+// mentionPattern matches @username where the @ does not follow a word
+// character, so email addresses are not mistaken for mentions. The trailing
+// \b rejects handles longer than a username can be.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@([A-Za-z0-9]{3,30})\b`)
+
+// ParseMentions returns the lowercased usernames mentioned in text in the
+// order they first appear, without duplicates and at most MaxMentions.
+func ParseMentions(text string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := strings.ToLower(match[1])
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == MaxMentions {
+			break
+		}
+	}
+	return usernames
+}
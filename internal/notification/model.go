@@ -0,0 +1,90 @@
+package notification
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+const (
+	TypeMention  = "mention"
+	TypeFollow   = "follow"
+	TypeReaction = "reaction"
+)
+
+// Notification tells UserID that ActorID did something involving them.
+// A user gets at most one notification of a type per actor and post, so
+// editing a post or reacting again does not notify twice. DedupePostID is
+// PostID or 0 for notifications without a post, as a NULL would make every
+// row unique.
+type Notification struct {
+	ID           uint       `json:"id" gorm:"primaryKey"`
+	UserID       uint       `json:"user_id" gorm:"not null;uniqueIndex:idx_notifications_dedupe_key;index:idx_notifications_user_read"`
+	User         user.User  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Type         string     `json:"type" gorm:"size:20;not null;uniqueIndex:idx_notifications_dedupe_key"`
+	ActorID      uint       `json:"actor_id" gorm:"not null;uniqueIndex:idx_notifications_dedupe_key"`
+	Actor        user.User  `json:"-" gorm:"foreignKey:ActorID;constraint:OnDelete:CASCADE"`
+	PostID       *uint      `json:"post_id" gorm:"index"`
+	Post         *post.Post `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	DedupePostID uint       `json:"-" gorm:"not null;default:0;uniqueIndex:idx_notifications_dedupe_key"`
+	ReadAt       *time.Time `json:"read_at" gorm:"index:idx_notifications_user_read"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// ListQuery selects the notifications of GET /notifications.
+type ListQuery struct {
+	pagination.Params
+	Unread bool `form:"unread"`
+}
+
+// MarkReadDTO marks the listed notifications read, or all of them when IDs
+// is empty.
+type MarkReadDTO struct {
+	IDs []uint `json:"ids" binding:"omitempty,max=100"`
+}
+
+type ActorResponse struct {
+	ID       uint    `json:"id"`
+	Name     string  `json:"name"`
+	Username *string `json:"username"`
+}
+
+type NotificationResponse struct {
+	ID        uint          `json:"id"`
+	Type      string        `json:"type"`
+	Actor     ActorResponse `json:"actor"`
+	PostID    *uint         `json:"post_id"`
+	Read      bool          `json:"read"`
+	ReadAt    *time.Time    `json:"read_at"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// ListMeta is the pagination meta of a notification list with the number
+// of unread notifications of the user.
+type ListMeta struct {
+	*pagination.Meta
+	UnreadCount int64 `json:"unread_count"`
+}
+
+type ReadResponse struct {
+	Updated     int64 `json:"updated"`
+	UnreadCount int64 `json:"unread_count"`
+}
+
+func (n *Notification) ToResponse() *NotificationResponse {
+	return &NotificationResponse{
+		ID:   n.ID,
+		Type: n.Type,
+		Actor: ActorResponse{
+			ID:       n.Actor.ID,
+			Name:     n.Actor.Name,
+			Username: n.Actor.Username,
+		},
+		PostID:    n.PostID,
+		Read:      n.ReadAt != nil,
+		ReadAt:    n.ReadAt,
+		CreatedAt: n.CreatedAt,
+	}
+}
@@ -0,0 +1,103 @@
+package notification
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	// Create returns false when the user already has the same notification.
+	Create(notification *Notification) (bool, error)
+	FindByID(id uint) (*Notification, error)
+	// FindByUserID lists the notifications of a user, newest first.
+	FindByUserID(userID uint, unreadOnly bool, offset, limit int) ([]Notification, int64, error)
+	FindAllByUserID(userID uint) ([]Notification, error)
+	CountUnread(userID uint) (int64, error)
+	// MarkRead marks unread notifications of the user as read and returns
+	// how many were changed. A nil ids marks all of them.
+	MarkRead(userID uint, ids []uint, at time.Time) (int64, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Create(notification *Notification) (bool, error) {
+	notification.DedupePostID = 0
+	if notification.PostID != nil {
+		notification.DedupePostID = *notification.PostID
+	}
+	result := r.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(notification)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) FindByID(id uint) (*Notification, error) {
+	var notification Notification
+	if err := r.db.Preload("Actor").First(&notification, id).Error; err != nil {
+		return nil, err
+	}
+	return &notification, nil
+}
+
+func (r *repository) FindByUserID(userID uint, unreadOnly bool, offset, limit int) ([]Notification, int64, error) {
+	query := r.db.Model(&Notification{}).Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+	query = query.Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var notifications []Notification
+	err := query.Preload("Actor").
+		Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&notifications).Error
+	return notifications, total, err
+}
+
+func (r *repository) FindAllByUserID(userID uint) ([]Notification, error) {
+	var notifications []Notification
+	err := r.db.Preload("Actor").
+		Where("user_id = ?", userID).
+		Order("created_at ASC, id ASC").
+		Find(&notifications).Error
+	return notifications, err
+}
+
+func (r *repository) CountUnread(userID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *repository) MarkRead(userID uint, ids []uint, at time.Time) (int64, error) {
+	query := r.db.Model(&Notification{}).Where("user_id = ? AND read_at IS NULL", userID)
+	if ids != nil {
+		query = query.Where("id IN ?", ids)
+	}
+	result := query.UpdateColumn("read_at", at)
+	return result.RowsAffected, result.Error
+}
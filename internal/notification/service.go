@@ -0,0 +1,200 @@
+package notification
+
+import (
+	"log"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	// Notify stores a notification unless the actor is its recipient or the
+	// recipient already has the same one.
+	Notify(notification *Notification) error
+	GetNotifications(userID uint, query ListQuery) ([]NotificationResponse, *ListMeta, apperror.AppErrors)
+	MarkRead(id, userID uint) (*NotificationResponse, apperror.AppErrors)
+	// MarkAllRead marks the notifications in dto, or all notifications of
+	// the user when it lists none.
+	MarkAllRead(userID uint, dto MarkReadDTO) (*ReadResponse, apperror.AppErrors)
+	// NotifyMentions is registered as a post.SaveHook. It notifies the users
+	// a post mentions. Mentions in hidden posts are skipped because the
+	// mentioned users could not read them; they are sent when the post is
+	// saved again after it was restored.
+	NotifyMentions(tx *gorm.DB, p *post.Post) error
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+// Publisher delivers new notifications to connected clients.
+type Publisher interface {
+	PublishNotification(userID uint, notification *NotificationResponse)
+}
+
+type Option func(*service)
+
+func WithPublisher(publisher Publisher) Option {
+	return func(s *service) {
+		s.publisher = publisher
+	}
+}
+
+type service struct {
+	repo      Repository
+	userRepo  user.Repository
+	publisher Publisher
+}
+
+func NewService(repo Repository, userRepo user.Repository, opts ...Option) Service {
+	s := &service{
+		repo:     repo,
+		userRepo: userRepo,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *service) Notify(notification *Notification) error {
+	if notification.UserID == notification.ActorID {
+		return nil
+	}
+	created, err := s.repo.Create(notification)
+	if err != nil {
+		return err
+	}
+	if created {
+		s.publish(notification.ID)
+	}
+	return nil
+}
+
+func (s *service) GetNotifications(userID uint, query ListQuery) ([]NotificationResponse, *ListMeta, apperror.AppErrors) {
+	params := query.Params.Normalize()
+	notifications, total, err := s.repo.FindByUserID(userID, query.Unread, params.Offset(), params.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]NotificationResponse, 0, len(notifications))
+	for i := range notifications {
+		responses = append(responses, *notifications[i].ToResponse())
+	}
+
+	return responses, &ListMeta{Meta: pagination.NewMeta(params, total), UnreadCount: unread}, nil
+}
+
+func (s *service) MarkRead(id, userID uint) (*NotificationResponse, apperror.AppErrors) {
+	notification, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.NotificationNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	// Other users' notifications are reported as missing rather than
+	// forbidden so their IDs reveal nothing.
+	if notification.UserID != userID {
+		return nil, apperror.NotificationNotFound()
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		if _, err := s.repo.MarkRead(userID, []uint{id}, now); err != nil {
+			return nil, apperror.DatabaseError(err)
+		}
+		notification.ReadAt = &now
+	}
+
+	return notification.ToResponse(), nil
+}
+
+func (s *service) MarkAllRead(userID uint, dto MarkReadDTO) (*ReadResponse, apperror.AppErrors) {
+	var ids []uint
+	if len(dto.IDs) > 0 {
+		ids = dto.IDs
+	}
+
+	updated, err := s.repo.MarkRead(userID, ids, time.Now())
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	unread, err := s.repo.CountUnread(userID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return &ReadResponse{Updated: updated, UnreadCount: unread}, nil
+}
+
+func (s *service) NotifyMentions(tx *gorm.DB, p *post.Post) error {
+	if p.HiddenAt != nil {
+		return nil
+	}
+	usernames := ParseMentions(p.Content)
+	if len(usernames) == 0 {
+		return nil
+	}
+
+	users, err := s.userRepo.FindByUsernames(usernames)
+	if err != nil {
+		return err
+	}
+
+	repo := s.repo.WithTx(tx)
+	postID := p.ID
+	for _, u := range users {
+		if u.ID == p.UserID {
+			continue
+		}
+		notification := &Notification{
+			UserID:  u.ID,
+			Type:    TypeMention,
+			ActorID: p.UserID,
+			PostID:  &postID,
+		}
+		created, err := repo.Create(notification)
+		if err != nil {
+			return err
+		}
+		if created {
+			post.AfterCommit(tx, func() { s.publish(notification.ID) })
+		}
+	}
+	return nil
+}
+
+// publish loads a stored notification with its actor and hands it to the
+// publisher. The notification is already stored, so failures are only
+// logged.
+func (s *service) publish(id uint) {
+	if s.publisher == nil {
+		return
+	}
+	notification, err := s.repo.FindByID(id)
+	if err != nil {
+		log.Printf("notification: failed to load notification %d: %v", id, err)
+		return
+	}
+	s.publisher.PublishNotification(notification.UserID, notification.ToResponse())
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	notifications, err := s.repo.FindAllByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]NotificationResponse, 0, len(notifications))
+	for i := range notifications {
+		responses = append(responses, *notifications[i].ToResponse())
+	}
+	return responses, nil
+}
@@ -0,0 +1,274 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// batchSize is the number of pending messages loaded per query.
+	batchSize = 100
+	// lockDuration is how long a claimed message is skipped by other
+	// dispatchers. A dispatcher that stops while handling a message leaves
+	// it locked until then.
+	lockDuration = 5 * time.Minute
+	// maxRetryDelay caps the backoff between attempts.
+	maxRetryDelay = time.Hour
+	// purgeInterval is how often dispatched messages are cleaned up.
+	purgeInterval = time.Hour
+)
+
+// Settings control polling, retries and cleanup.
+type Settings struct {
+	// Interval is how often pending messages are looked for when no
+	// Trigger arrives, for example messages written by other instances.
+	Interval time.Duration
+	// MaxAttempts is the number of attempts before a message is marked
+	// failed and stops holding back its aggregate.
+	MaxAttempts int
+	// RetryBase is the delay after the first failed attempt. It doubles
+	// with every further failure.
+	RetryBase time.Duration
+	// Retention is how long dispatched messages are kept.
+	Retention time.Duration
+}
+
+// Dispatcher writes events to the outbox and delivers them to the
+// subscribers registered with Subscribe.
+type Dispatcher struct {
+	repo        Repository
+	settings    Settings
+	subscribers map[string][]subscriber
+	lastPurge   time.Time
+
+	wake chan struct{}
+	stop chan struct{}
+	once sync.Once
+	wg   sync.WaitGroup
+}
+
+func NewDispatcher(repo Repository, settings Settings) *Dispatcher {
+	return &Dispatcher{
+		repo:        repo,
+		settings:    settings,
+		subscribers: make(map[string][]subscriber),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+	}
+}
+
+func (d *Dispatcher) Write(tx *gorm.DB, events ...Event) error {
+	now := time.Now()
+	messages := make([]Message, 0, len(events))
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+		messages = append(messages, Message{
+			EventName:     event.EventName(),
+			AggregateType: event.AggregateType(),
+			AggregateID:   event.AggregateID(),
+			Payload:       string(payload),
+			Status:        StatusPending,
+			NextAttemptAt: now,
+		})
+	}
+	return d.repo.WithTx(tx).Create(messages)
+}
+
+// Trigger wakes the dispatcher. It never blocks; wake-ups that arrive while
+// one is pending are merged.
+func (d *Dispatcher) Trigger() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer cancel()
+		ticker := time.NewTicker(d.settings.Interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			case <-d.stop:
+				return
+			}
+			d.dispatch(ctx)
+		}
+	}()
+
+	go func() {
+		<-d.stop
+		cancel()
+	}()
+}
+
+// Stop cancels the handlers in flight and waits for the dispatcher to exit.
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) dispatch(ctx context.Context) {
+	if _, err := d.DispatchDue(ctx); err != nil {
+		log.Printf("outbox: failed to dispatch: %v", err)
+		return
+	}
+
+	if time.Since(d.lastPurge) >= purgeInterval && d.settings.Retention > 0 {
+		d.lastPurge = time.Now()
+		if _, err := d.repo.DeleteDispatchedBefore(d.lastPurge.Add(-d.settings.Retention)); err != nil {
+			log.Printf("outbox: failed to delete dispatched messages: %v", err)
+		}
+	}
+}
+
+type aggregate struct {
+	typ string
+	id  uint
+}
+
+// DispatchDue hands the due messages to their subscribers and returns how
+// many it handled. A message is only handled when every earlier message of
+// its aggregate has been dispatched or has failed for good. The pending
+// messages are read page by page until none are left, so an aggregate that
+// is held back does not keep the messages behind it from the others.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	blocked := make(map[aggregate]bool)
+	handled := 0
+	var lastID uint
+	for ctx.Err() == nil {
+		messages, err := d.repo.FindPending(lastID, batchSize)
+		if err != nil {
+			return handled, err
+		}
+		for i := range messages {
+			if ctx.Err() != nil {
+				break
+			}
+			message := &messages[i]
+			lastID = message.ID
+			key := aggregate{message.AggregateType, message.AggregateID}
+			if blocked[key] {
+				continue
+			}
+			if message.NextAttemptAt.After(now) || (message.LockedUntil != nil && message.LockedUntil.After(now)) {
+				blocked[key] = true
+				continue
+			}
+
+			claimed, err := d.repo.Claim(message.ID, now, time.Now().Add(lockDuration))
+			if err != nil {
+				return handled, err
+			}
+			if !claimed {
+				blocked[key] = true
+				continue
+			}
+
+			if !d.attempt(ctx, message) {
+				blocked[key] = true
+			}
+			handled++
+		}
+		if len(messages) < batchSize {
+			break
+		}
+	}
+	return handled, nil
+}
+
+// attempt hands a message to the subscribers that have not handled it yet
+// and records the outcome. It reports whether the message is done.
+func (d *Dispatcher) attempt(ctx context.Context, message *Message) bool {
+	handleErr := d.deliver(ctx, message)
+
+	now := time.Now()
+	message.Attempts++
+	message.LastError = ""
+	if handleErr == nil {
+		message.Status = StatusDispatched
+		message.DispatchedAt = &now
+	} else {
+		message.LastError = truncate(handleErr.Error(), 500)
+		if message.Attempts >= d.settings.MaxAttempts {
+			message.Status = StatusFailed
+			log.Printf("outbox: giving up on message %d (%s) after %d attempts: %v",
+				message.ID, message.EventName, message.Attempts, handleErr)
+		} else {
+			message.NextAttemptAt = now.Add(backoff(d.settings.RetryBase, message.Attempts))
+		}
+	}
+
+	if err := d.repo.SaveAttempt(message); err != nil {
+		log.Printf("outbox: failed to record message %d: %v", message.ID, err)
+		return false
+	}
+	return message.Status != StatusPending
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, message *Message) error {
+	subscribers := d.subscribers[message.EventName]
+	if len(subscribers) == 0 {
+		return nil
+	}
+
+	processed, err := d.repo.ProcessedBy(message.ID)
+	if err != nil {
+		return err
+	}
+	done := make(map[string]bool, len(processed))
+	for _, name := range processed {
+		done[name] = true
+	}
+
+	meta := Metadata{MessageID: message.ID, OccurredAt: message.CreatedAt}
+	for _, subscriber := range subscribers {
+		if done[subscriber.name] {
+			continue
+		}
+		if err := subscriber.handle(ctx, meta, []byte(message.Payload)); err != nil {
+			return fmt.Errorf("%s: %w", subscriber.name, err)
+		}
+		if err := d.repo.MarkProcessed(message.ID, subscriber.name, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func backoff(base time.Duration, failedAttempts int) time.Duration {
+	delay := base
+	for i := 1; i < failedAttempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max]
+}
@@ -0,0 +1,55 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// Event is a domain event. Events are structs with value receivers, stored
+// as JSON. They identify the aggregate they belong to; events of one
+// aggregate are handled in the order they were written.
+type Event interface {
+	EventName() string
+	AggregateType() string
+	AggregateID() uint
+}
+
+// Writer stores events in the transaction of the change they describe, so
+// an event is kept exactly when the change is.
+type Writer interface {
+	// Write adds events to the outbox inside tx.
+	Write(tx *gorm.DB, events ...Event) error
+	// Trigger is called once the transaction has been committed so the
+	// events are dispatched without waiting for the next poll.
+	Trigger()
+}
+
+type handler func(ctx context.Context, meta Metadata, payload []byte) error
+
+type subscriber struct {
+	name   string
+	handle handler
+}
+
+// Subscribe registers handle for events of type E under a subscriber name,
+// which must be unique and stay the same across releases because it records
+// which messages were handled. Handlers are called at least once per event
+// and should tolerate repeats, using Metadata.MessageID to detect them. A
+// returned error retries the message later and holds back the following
+// events of its aggregate. Subscribe must be called before Start.
+func Subscribe[E Event](d *Dispatcher, name string, handle func(ctx context.Context, meta Metadata, event E) error) {
+	var zero E
+	eventName := zero.EventName()
+	d.subscribers[eventName] = append(d.subscribers[eventName], subscriber{
+		name: name,
+		handle: func(ctx context.Context, meta Metadata, payload []byte) error {
+			var event E
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return err
+			}
+			return handle(ctx, meta, event)
+		},
+	})
+}
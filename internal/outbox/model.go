@@ -0,0 +1,51 @@
+package outbox
+
+import "time"
+
+const (
+	StatusPending    = "pending"
+	StatusDispatched = "dispatched"
+	StatusFailed     = "failed"
+)
+
+// Message is a domain event written in the transaction of the change it
+// describes. Messages of one aggregate are dispatched in ID order.
+type Message struct {
+	ID            uint      `gorm:"primaryKey"`
+	EventName     string    `gorm:"size:100;not null"`
+	AggregateType string    `gorm:"size:50;not null;index:idx_outbox_messages_aggregate"`
+	AggregateID   uint      `gorm:"not null;index:idx_outbox_messages_aggregate"`
+	Payload       string    `gorm:"type:text;not null"`
+	Status        string    `gorm:"size:20;not null;index:idx_outbox_messages_pending"`
+	Attempts      int       `gorm:"not null;default:0"`
+	NextAttemptAt time.Time `gorm:"not null"`
+	LockedUntil   *time.Time
+	LastError     string `gorm:"size:500"`
+	DispatchedAt  *time.Time
+	CreatedAt     time.Time `gorm:"index"`
+}
+
+func (Message) TableName() string {
+	return "outbox_messages"
+}
+
+// Processed records that a subscriber handled a message, so a message that
+// is retried is not handled twice by the subscribers that already succeeded.
+type Processed struct {
+	MessageID   uint      `gorm:"primaryKey"`
+	Message     Message   `gorm:"foreignKey:MessageID;constraint:OnDelete:CASCADE"`
+	Subscriber  string    `gorm:"primaryKey;size:100"`
+	ProcessedAt time.Time `gorm:"not null"`
+}
+
+func (Processed) TableName() string {
+	return "outbox_processed"
+}
+
+// Metadata describes the message an event was read from.
+type Metadata struct {
+	// MessageID is unique per event and stays the same when the event is
+	// handled again, so it can be passed on as an idempotency key.
+	MessageID  uint
+	OccurredAt time.Time
+}
@@ -0,0 +1,92 @@
+package outbox
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(messages []Message) error
+	// FindPending returns the oldest pending messages after the message
+	// afterID, including those that are not due or claimed, so the
+	// dispatcher can hold back later messages of their aggregates.
+	FindPending(afterID uint, limit int) ([]Message, error)
+	// Claim locks a pending message until the given time. It returns false
+	// when the message is locked by another dispatcher.
+	Claim(id uint, now, until time.Time) (bool, error)
+	// ProcessedBy returns the subscribers that already handled a message.
+	ProcessedBy(messageID uint) ([]string, error)
+	MarkProcessed(messageID uint, subscriber string, at time.Time) error
+	// SaveAttempt writes the outcome of an attempt and releases the lock.
+	SaveAttempt(message *Message) error
+	// DeleteDispatchedBefore removes dispatched messages older than before.
+	DeleteDispatchedBefore(before time.Time) (int64, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(messages []Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+	return r.db.Create(&messages).Error
+}
+
+func (r *repository) FindPending(afterID uint, limit int) ([]Message, error) {
+	var messages []Message
+	err := r.db.Where("status = ? AND id > ?", StatusPending, afterID).Order("id").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+func (r *repository) Claim(id uint, now, until time.Time) (bool, error) {
+	result := r.db.Model(&Message{}).
+		Where("id = ? AND status = ? AND (locked_until IS NULL OR locked_until <= ?)", id, StatusPending, now).
+		UpdateColumn("locked_until", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) ProcessedBy(messageID uint) ([]string, error) {
+	var subscribers []string
+	err := r.db.Model(&Processed{}).Where("message_id = ?", messageID).Pluck("subscriber", &subscribers).Error
+	return subscribers, err
+}
+
+func (r *repository) MarkProcessed(messageID uint, subscriber string, at time.Time) error {
+	return r.db.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&Processed{
+		MessageID:   messageID,
+		Subscriber:  subscriber,
+		ProcessedAt: at,
+	}).Error
+}
+
+func (r *repository) SaveAttempt(message *Message) error {
+	return r.db.Model(&Message{}).Where("id = ?", message.ID).UpdateColumns(map[string]interface{}{
+		"status":          message.Status,
+		"attempts":        message.Attempts,
+		"next_attempt_at": message.NextAttemptAt,
+		"locked_until":    nil,
+		"last_error":      message.LastError,
+		"dispatched_at":   message.DispatchedAt,
+	}).Error
+}
+
+func (r *repository) DeleteDispatchedBefore(before time.Time) (int64, error) {
+	result := r.db.Where("status = ? AND dispatched_at < ?", StatusDispatched, before).Delete(&Message{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
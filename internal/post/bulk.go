@@ -0,0 +1,204 @@
+package post
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"gorm.io/gorm"
+)
+
+const (
+	BulkCreate = "create"
+	BulkUpdate = "update"
+	BulkDelete = "delete"
+)
+
+// Statuses reported for each operation of a bulk request.
+const (
+	BulkStatusCreated    = "created"
+	BulkStatusUpdated    = "updated"
+	BulkStatusDeleted    = "deleted"
+	BulkStatusFailed     = "failed"
+	BulkStatusRolledBack = "rolled_back"
+	BulkStatusSkipped    = "skipped"
+)
+
+// BulkDTO is the body of POST /posts/bulk. Operations run in order; with
+// Atomic set they share one transaction and the first failure rolls back
+// all of them, otherwise each operation is applied on its own.
+type BulkDTO struct {
+	Atomic     bool            `json:"atomic"`
+	Operations []BulkOperation `json:"operations" binding:"required,min=1"`
+}
+
+// BulkOperation is one entry of a bulk request. Data holds a CreatePostDTO
+// or UpdatePostDTO; Version is the expected version of an update or delete,
+// the bulk counterpart of If-Match.
+type BulkOperation struct {
+	Op      string          `json:"op" binding:"required,oneof=create update delete"`
+	ID      uint            `json:"id"`
+	Version uint            `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// BulkItem is a decoded BulkOperation. Items with Errors failed validation
+// and are reported without being executed.
+type BulkItem struct {
+	Op      string
+	ID      uint
+	Version uint
+	Create  CreatePostDTO
+	Update  UpdatePostDTO
+	Errors  apperror.AppErrors
+}
+
+type BulkResult struct {
+	Index  int                `json:"index"`
+	Op     string             `json:"op"`
+	ID     uint               `json:"id,omitempty"`
+	Status string             `json:"status"`
+	Post   *PostResponse      `json:"post,omitempty"`
+	Errors apperror.AppErrors `json:"errors,omitempty"`
+}
+
+type BulkResponse struct {
+	Atomic    bool         `json:"atomic"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+var errBulkAborted = errors.New("bulk operation failed")
+
+// Bulk runs the items for userID. Ownership and versions are checked per
+// item exactly as for the single post endpoints. The returned AppErrors are
+// only set when the batch was rejected as a whole.
+func (s *service) Bulk(userID uint, atomic bool, items []BulkItem) (*BulkResponse, apperror.AppErrors) {
+	if s.bulkLimit > 0 && len(items) > s.bulkLimit {
+		return nil, apperror.BulkLimitExceeded(s.bulkLimit)
+	}
+
+	results := make([]BulkResult, len(items))
+	for i, item := range items {
+		results[i] = BulkResult{Index: i, Op: item.Op, ID: item.ID}
+		if s.requireVersion && item.Errors == nil && item.Op != BulkCreate && item.Version == 0 {
+			items[i].Errors = apperror.PreconditionRequired()
+		}
+	}
+
+	if !atomic {
+		for i, item := range items {
+			s.runBulkItem(userID, item, &results[i])
+		}
+		return newBulkResponse(false, results), nil
+	}
+
+	// Reject invalid batches before touching the database.
+	invalid := false
+	for i, item := range items {
+		if item.Errors != nil {
+			results[i].Status = BulkStatusFailed
+			results[i].Errors = item.Errors
+			invalid = true
+		}
+	}
+	if invalid {
+		for i := range results {
+			if results[i].Status == "" {
+				results[i].Status = BulkStatusSkipped
+			}
+		}
+		return newBulkResponse(true, results), nil
+	}
+
+	var pending []func()
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx, &pending)
+		for i, item := range items {
+			if !txService.runBulkItem(userID, item, &results[i]) {
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		for i := range results {
+			switch results[i].Status {
+			case BulkStatusFailed:
+			case "":
+				results[i].Status = BulkStatusSkipped
+			default:
+				results[i].Status = BulkStatusRolledBack
+				results[i].Post = nil
+			}
+		}
+		if err != errBulkAborted {
+			return nil, apperror.DatabaseError(err)
+		}
+		return newBulkResponse(true, results), nil
+	}
+
+	s.committed(pending)
+	return newBulkResponse(true, results), nil
+}
+
+// runBulkItem executes item and records the outcome in result. It reports
+// whether the item succeeded.
+func (s *service) runBulkItem(userID uint, item BulkItem, result *BulkResult) bool {
+	if item.Errors != nil {
+		result.Status = BulkStatusFailed
+		result.Errors = item.Errors
+		return false
+	}
+
+	var post *PostResponse
+	var appErr apperror.AppErrors
+	switch item.Op {
+	case BulkCreate:
+		post, appErr = s.CreatePost(userID, item.Create)
+		result.Status = BulkStatusCreated
+	case BulkUpdate:
+		post, appErr = s.UpdatePost(item.ID, userID, item.Version, item.Update)
+		result.Status = BulkStatusUpdated
+	case BulkDelete:
+		appErr = s.deletePost(item.ID, userID, item.Version)
+		result.Status = BulkStatusDeleted
+	}
+
+	if appErr != nil {
+		result.Status = BulkStatusFailed
+		result.Errors = appErr
+		return false
+	}
+	if post != nil {
+		result.ID = post.ID
+		result.Post = post
+	}
+	return true
+}
+
+// withTx returns a copy of the service whose repository uses tx. Tags are
+// resolved and duplicate content is looked up through that repository, so
+// they see the earlier operations of the batch and are rolled back with it.
+// Work the copy schedules for after the commit is collected in pending
+// instead of running when the nested transaction is released.
+func (s *service) withTx(tx *gorm.DB, pending *[]func()) *service {
+	txService := *s
+	txService.repo = s.repo.WithTx(tx)
+	txService.afterCommit = pending
+	return &txService
+}
+
+func newBulkResponse(atomic bool, results []BulkResult) *BulkResponse {
+	response := &BulkResponse{Atomic: atomic, Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case BulkStatusCreated, BulkStatusUpdated, BulkStatusDeleted:
+			response.Succeeded++
+		default:
+			response.Failed++
+		}
+	}
+	return response
+}
@@ -0,0 +1,39 @@
+package post
+
+// Domain events written to the outbox in the transaction that changes the
+// post. They carry IDs rather than the post so subscribers load the state
+// they need when they handle the event. Hidden is set for posts only their
+// author and moderators can see.
+
+type PostPublished struct {
+	PostID uint `json:"post_id"`
+	UserID uint `json:"user_id"`
+	Hidden bool `json:"hidden"`
+}
+
+func (PostPublished) EventName() string     { return "post.published" }
+func (PostPublished) AggregateType() string { return "post" }
+func (e PostPublished) AggregateID() uint   { return e.PostID }
+
+// PostUpdated is recorded for edits and when a moderator hides or restores
+// the post. WasHidden is the visibility before the change.
+type PostUpdated struct {
+	PostID    uint `json:"post_id"`
+	UserID    uint `json:"user_id"`
+	Hidden    bool `json:"hidden"`
+	WasHidden bool `json:"was_hidden"`
+}
+
+func (PostUpdated) EventName() string     { return "post.updated" }
+func (PostUpdated) AggregateType() string { return "post" }
+func (e PostUpdated) AggregateID() uint   { return e.PostID }
+
+type PostDeleted struct {
+	PostID uint `json:"post_id"`
+	UserID uint `json:"user_id"`
+	Hidden bool `json:"hidden"`
+}
+
+func (PostDeleted) EventName() string     { return "post.deleted" }
+func (PostDeleted) AggregateType() string { return "post" }
+func (e PostDeleted) AggregateID() uint   { return e.PostID }
@@ -1,12 +1,18 @@
 package post
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/common/patch"
 	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/ardipermana59/go-template/internal/media"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 )
 
 type Handler struct {
@@ -28,15 +34,32 @@ func (h *Handler) CreatePost(c *gin.Context) {
 
 	post, appErr := h.service.CreatePost(userID, dto)
 	if appErr != nil {
-		response.InternalError(c, nil)
+		switch {
+		case rejected(appErr):
+			response.Error(c, http.StatusUnprocessableEntity, "Failed to create post", appErr)
+		case appErr.Has("category") || appErr.Has("tag"):
+			response.Error(c, http.StatusBadRequest, "Failed to create post", appErr)
+		default:
+			response.InternalError(c, nil)
+		}
 		return
 	}
 
+	if post.ReviewReasons != nil {
+		response.Success(c, http.StatusAccepted, "Post created and held for review", post)
+		return
+	}
 	response.Success(c, http.StatusCreated, "Post created successfully", post)
 }
 
 func (h *Handler) GetAllPosts(c *gin.Context) {
-	posts, appErr := h.service.GetAllPosts()
+	var filter PostFilter
+	if err := c.ShouldBindQuery(&filter); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	posts, appErr := h.service.GetAllPosts(filter, c.GetUint("user_id"))
 	if appErr != nil {
 		response.InternalError(c, nil)
 		return
@@ -52,12 +75,39 @@ func (h *Handler) GetPostByID(c *gin.Context) {
 		return
 	}
 
-	post, appErr := h.service.GetPostByID(uint(id))
+	post, appErr := h.service.GetPostByID(uint(id), c.GetUint("user_id"))
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		return
+	}
+	h.service.RecordView(post, c.GetUint("user_id"), c.ClientIP())
+
+	etag.Set(c, post.Version)
+	response.Success(c, http.StatusOK, "Post retrieved successfully", post)
+}
+
+// GetPostBySlug answers with a 301 to the canonical slug when the post is
+// requested through one of its previous slugs.
+func (h *Handler) GetPostBySlug(c *gin.Context) {
+	requested := c.Param("slug")
+
+	post, appErr := h.service.GetPostBySlug(requested, c.GetUint("user_id"))
 	if appErr != nil {
 		response.Error(c, http.StatusNotFound, "Post not found", appErr)
 		return
 	}
 
+	if post.Slug != requested {
+		location := strings.TrimSuffix(c.Request.URL.Path, requested) + post.Slug
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Redirect(http.StatusMovedPermanently, location)
+		return
+	}
+	h.service.RecordView(post, c.GetUint("user_id"), c.ClientIP())
+
+	etag.Set(c, post.Version)
 	response.Success(c, http.StatusOK, "Post retrieved successfully", post)
 }
 
@@ -68,7 +118,7 @@ func (h *Handler) GetPostsByUserID(c *gin.Context) {
 		return
 	}
 
-	posts, appErr := h.service.GetPostsByUserID(uint(userID))
+	posts, appErr := h.service.GetPostsByUserID(uint(userID), c.GetUint("user_id"))
 	if appErr != nil {
 		response.InternalError(c, nil)
 		return
@@ -103,12 +153,74 @@ func (h *Handler) UpdatePost(c *gin.Context) {
 		return
 	}
 
-	post, appErr := h.service.UpdatePost(uint(id), userID, dto)
+	post, appErr := h.service.UpdatePost(uint(id), userID, etag.ExpectedVersion(c), dto)
 	if appErr != nil {
+		if appErr.Has("version") {
+			response.Error(c, http.StatusPreconditionFailed, "Post has been modified", appErr)
+			return
+		}
+		if rejected(appErr) {
+			response.Error(c, http.StatusUnprocessableEntity, "Failed to update post", appErr)
+			return
+		}
 		response.Error(c, http.StatusForbidden, "Failed to update post", appErr)
 		return
 	}
 
+	etag.Set(c, post.Version)
+	if post.ReviewReasons != nil {
+		response.Success(c, http.StatusAccepted, "Post updated and held for review", post)
+		return
+	}
+	response.Success(c, http.StatusOK, "Post updated successfully", post)
+}
+
+// PatchPost applies a JSON Merge Patch or JSON Patch to the post's
+// PostDocument. Without If-Match the patch is bound to the version it was
+// applied to, so a concurrent change still results in a 412.
+func (h *Handler) PatchPost(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	current, appErr := h.service.GetPostByID(uint(id), userID)
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		return
+	}
+
+	expected := etag.ExpectedVersion(c)
+	if expected == 0 {
+		expected = current.Version
+	}
+
+	var doc PostDocument
+	if !patch.Bind(c, current.Document(), &doc) {
+		return
+	}
+
+	post, appErr := h.service.ReplacePost(uint(id), userID, expected, doc)
+	if appErr != nil {
+		if appErr.Has("version") {
+			response.Error(c, http.StatusPreconditionFailed, "Post has been modified", appErr)
+			return
+		}
+		if rejected(appErr) {
+			response.Error(c, http.StatusUnprocessableEntity, "Failed to update post", appErr)
+			return
+		}
+		response.Error(c, http.StatusForbidden, "Failed to update post", appErr)
+		return
+	}
+
+	etag.Set(c, post.Version)
+	if post.ReviewReasons != nil {
+		response.Success(c, http.StatusAccepted, "Post updated and held for review", post)
+		return
+	}
 	response.Success(c, http.StatusOK, "Post updated successfully", post)
 }
 
@@ -128,3 +240,137 @@ func (h *Handler) DeletePost(c *gin.Context) {
 
 	response.Success(c, http.StatusOK, "Post deleted successfully", nil)
 }
+
+// Bulk answers 200 when every operation succeeded, 207 when a best-effort
+// batch partly failed and 422 when an atomic batch was rolled back.
+func (h *Handler) Bulk(c *gin.Context) {
+	var dto BulkDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	items := make([]BulkItem, len(dto.Operations))
+	for i, op := range dto.Operations {
+		items[i] = bindBulkItem(op)
+	}
+
+	result, appErr := h.service.Bulk(c.GetUint("user_id"), dto.Atomic, items)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Bulk operation rejected", appErr)
+		return
+	}
+
+	switch {
+	case result.Failed == 0:
+		response.Success(c, http.StatusOK, "Bulk operation completed successfully", result)
+	case result.Atomic:
+		response.ErrorWithData(c, http.StatusUnprocessableEntity, "Bulk operation rolled back", result, apperror.BulkRolledBack())
+	default:
+		response.Success(c, http.StatusMultiStatus, "Bulk operation completed with errors", result)
+	}
+}
+
+// bindBulkItem decodes and validates one operation. Errors are kept on the
+// item so they can be reported next to the results of the other operations.
+func bindBulkItem(op BulkOperation) BulkItem {
+	item := BulkItem{Op: op.Op, ID: op.ID, Version: op.Version}
+	if err := binding.Validator.ValidateStruct(op); err != nil {
+		item.Errors = validationErrors(err)
+		return item
+	}
+
+	switch op.Op {
+	case BulkCreate:
+		item.Errors = decodeBulkData(op.Data, &item.Create)
+	case BulkUpdate:
+		if op.ID == 0 {
+			item.Errors = apperror.InvalidID()
+			return item
+		}
+		item.Errors = decodeBulkData(op.Data, &item.Update)
+	case BulkDelete:
+		if op.ID == 0 {
+			item.Errors = apperror.InvalidID()
+		}
+	}
+	return item
+}
+
+func decodeBulkData(data json.RawMessage, target interface{}) apperror.AppErrors {
+	if len(data) == 0 || json.Unmarshal(data, target) != nil {
+		return apperror.InvalidBulkData()
+	}
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		return validationErrors(err)
+	}
+	return nil
+}
+
+// validationErrors converts validator errors to the apperror format used in
+// bulk results.
+func validationErrors(err error) apperror.AppErrors {
+	if errs := apperror.Validation(err); len(errs) > 0 {
+		return errs
+	}
+	return apperror.InvalidBulkData()
+}
+
+func (h *Handler) UpdateCover(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update cover image", apperror.FileRequired())
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update cover image", apperror.FileRequired())
+		return
+	}
+	defer file.Close()
+
+	post, appErr := h.service.UpdateCover(c.Request.Context(), uint(id), userID, media.Upload{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Body:     file,
+	})
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update cover image", appErr)
+		return
+	}
+
+	etag.Set(c, post.Version)
+	response.Success(c, http.StatusAccepted, "Cover image uploaded, variants are being generated", post)
+}
+
+func (h *Handler) DeleteCover(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	post, appErr := h.service.DeleteCover(c.Request.Context(), uint(id), userID)
+	if appErr != nil {
+		response.Error(c, http.StatusForbidden, "Failed to delete cover image", appErr)
+		return
+	}
+
+	etag.Set(c, post.Version)
+	response.Success(c, http.StatusOK, "Cover image deleted successfully", post)
+}
+
+// rejected reports whether the content filter refused the post. Its
+// reasons name the title or content field.
+func rejected(appErr apperror.AppErrors) bool {
+	return appErr.Has("title") || appErr.Has("content")
+}
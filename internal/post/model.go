@@ -3,47 +3,188 @@ package post
 import (
 	"time"
 
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/media"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
 	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/ardipermana59/go-template/pkg/markdown"
 )
 
 type Post struct {
+	ID            uint               `json:"id" gorm:"primaryKey"`
+	Title         string             `json:"title" gorm:"not null"`
+	Slug          string             `json:"slug" gorm:"size:191;index"`
+	Content       string             `json:"content" gorm:"type:text"`
+	ContentFormat string             `json:"content_format" gorm:"size:20;not null;default:'plain'"`
+	ContentHTML   string             `json:"content_html" gorm:"type:mediumtext"`
+	ContentHash   string             `json:"-" gorm:"size:64;index"`
+	UserID        uint               `json:"user_id" gorm:"not null;uniqueIndex:idx_posts_user_external_id"`
+	User          user.User          `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ExternalID    *string            `json:"external_id" gorm:"size:191;uniqueIndex:idx_posts_user_external_id"`
+	CategoryID    *uint              `json:"category_id" gorm:"index"`
+	Category      *taxonomy.Category `json:"category" gorm:"foreignKey:CategoryID;constraint:OnDelete:SET NULL"`
+	Tags          []taxonomy.Tag     `json:"tags" gorm:"many2many:post_tags;constraint:OnDelete:CASCADE"`
+	CoverID       *uint              `json:"-"`
+	Cover         *media.Image       `json:"-" gorm:"foreignKey:CoverID;constraint:OnDelete:SET NULL"`
+	Version       uint               `json:"version" gorm:"not null;default:1"`
+	// HiddenAt is set when a moderator hides the post. Hidden posts are only
+	// visible to their author and to moderators.
+	HiddenAt  *time.Time `json:"-" gorm:"index"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// PostSlug records every slug a post has had, so links using an old slug can
+// be redirected to the current one.
+type PostSlug struct {
 	ID        uint      `json:"id" gorm:"primaryKey"`
-	Title     string    `json:"title" gorm:"not null"`
-	Content   string    `json:"content" gorm:"type:text"`
-	UserID    uint      `json:"user_id" gorm:"not null"`
-	User      user.User `json:"user" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	Post      Post      `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	Slug      string    `json:"slug" gorm:"size:191;uniqueIndex;not null"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
 }
 
 type CreatePostDTO struct {
-	Title   string `json:"title" binding:"required,min=3"`
-	Content string `json:"content" binding:"required,min=10"`
+	Title         string   `json:"title" binding:"required,min=3"`
+	Content       string   `json:"content" binding:"required,min=10"`
+	ContentFormat string   `json:"content_format" binding:"omitempty,oneof=plain markdown"`
+	CategoryID    *uint    `json:"category_id"`
+	Tags          []string `json:"tags" binding:"omitempty,max=10,dive,required,max=50"`
 }
 
+// UpdatePostDTO leaves Tags untouched when the field is omitted and clears
+// them when an empty list is sent.
 type UpdatePostDTO struct {
-	Title   string `json:"title" binding:"omitempty,min=3"`
-	Content string `json:"content" binding:"omitempty,min=10"`
+	Title         string   `json:"title" binding:"omitempty,min=3"`
+	Content       string   `json:"content" binding:"omitempty,min=10"`
+	ContentFormat string   `json:"content_format" binding:"omitempty,oneof=plain markdown"`
+	CategoryID    *uint    `json:"category_id"`
+	Tags          []string `json:"tags" binding:"omitempty,max=10,dive,required,max=50"`
+}
+
+// ImportPostDTO is a post read from an import file. ExternalID identifies
+// the post in its source, so importing the same file again does not create
+// it twice. CreatedAt keeps the original publication time when set.
+type ImportPostDTO struct {
+	CreatePostDTO
+	ExternalID string     `json:"external_id" binding:"required,max=191"`
+	CreatedAt  *time.Time `json:"created_at"`
+}
+
+// PostDocument is the editable representation of a post that PATCH requests
+// are applied to. Unlike UpdatePostDTO every field is written, so a null
+// category_id removes the category and an empty tags list removes all tags.
+type PostDocument struct {
+	Title         string   `json:"title" binding:"required,min=3"`
+	Content       string   `json:"content" binding:"required,min=10"`
+	ContentFormat string   `json:"content_format" binding:"required,oneof=plain markdown"`
+	CategoryID    *uint    `json:"category_id"`
+	Tags          []string `json:"tags" binding:"max=10,dive,required,max=50"`
+}
+
+// PostFilter holds the optional query parameters accepted by GET /posts.
+type PostFilter struct {
+	Tag      string `form:"tag"`
+	Category string `form:"category"`
+}
+
+// ListQuery is the resolved form of PostFilter used by the repository.
+type ListQuery struct {
+	TagSlug     string
+	CategoryIDs []uint
 }
 
 type PostResponse struct {
-	ID        uint               `json:"id"`
-	Title     string             `json:"title"`
-	Content   string             `json:"content"`
-	UserID    uint               `json:"user_id"`
-	User      *user.UserResponse `json:"user"`
-	CreatedAt time.Time          `json:"created_at"`
-	UpdatedAt time.Time          `json:"updated_at"`
+	ID            uint                       `json:"id"`
+	Title         string                     `json:"title"`
+	Slug          string                     `json:"slug"`
+	Content       string                     `json:"content"`
+	ContentFormat string                     `json:"content_format"`
+	ContentHTML   string                     `json:"content_html"`
+	UserID        uint                       `json:"user_id"`
+	User          *user.UserResponse         `json:"user"`
+	ExternalID    *string                    `json:"external_id,omitempty"`
+	Category      *taxonomy.CategoryResponse `json:"category"`
+	Tags          []taxonomy.TagResponse     `json:"tags"`
+	Cover         *media.ImageResponse       `json:"cover"`
+	CommentCount  int64                      `json:"comment_count"`
+	Reactions     map[string]int64           `json:"reactions"`
+	MyReactions   []string                   `json:"my_reactions"`
+	ViewCount     int64                      `json:"view_count"`
+	Hidden        bool                       `json:"hidden,omitempty"`
+	Version       uint                       `json:"version"`
+	CreatedAt     time.Time                  `json:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at"`
+
+	// ReviewReasons is only set on the response of the create or update that
+	// the content filter flagged.
+	ReviewReasons apperror.AppErrors `json:"review_reasons,omitempty"`
+}
+
+// VisibleTo reports whether viewerID may see the post and the comments,
+// reactions and attachments that belong to it. Zero is an anonymous viewer.
+func (p *Post) VisibleTo(viewerID uint) bool {
+	return p.HiddenAt == nil || p.UserID == viewerID
+}
+
+// RenderContent refreshes ContentHTML from Content and ContentFormat.
+func (p *Post) RenderContent() error {
+	if p.ContentFormat == "" {
+		p.ContentFormat = markdown.FormatPlain
+	}
+
+	contentHTML, err := markdown.Render(p.ContentFormat, p.Content)
+	if err != nil {
+		return err
+	}
+	p.ContentHTML = contentHTML
+	return nil
 }
 
 func (p *Post) ToResponse() *PostResponse {
-	return &PostResponse{
-		ID:        p.ID,
-		Title:     p.Title,
-		Content:   p.Content,
-		UserID:    p.UserID,
-		User:      p.User.ToResponse(),
-		CreatedAt: p.CreatedAt,
-		UpdatedAt: p.UpdatedAt,
+	response := &PostResponse{
+		ID:            p.ID,
+		Title:         p.Title,
+		Slug:          p.Slug,
+		Content:       p.Content,
+		ContentFormat: p.ContentFormat,
+		ContentHTML:   p.ContentHTML,
+		UserID:        p.UserID,
+		User:          p.User.ToResponse(),
+		ExternalID:    p.ExternalID,
+		Tags:          make([]taxonomy.TagResponse, 0, len(p.Tags)),
+		Version:       p.Version,
+		Hidden:        p.HiddenAt != nil,
+		CreatedAt:     p.CreatedAt,
+		UpdatedAt:     p.UpdatedAt,
+	}
+
+	if p.Category != nil {
+		response.Category = p.Category.ToResponse()
+	}
+	if p.Cover != nil {
+		response.Cover = p.Cover.ToResponse()
+	}
+	for _, tag := range p.Tags {
+		response.Tags = append(response.Tags, *tag.ToResponse())
+	}
+
+	return response
+}
+
+// Document returns the editable fields of the post.
+func (p *PostResponse) Document() *PostDocument {
+	doc := &PostDocument{
+		Title:         p.Title,
+		Content:       p.Content,
+		ContentFormat: p.ContentFormat,
+		Tags:          make([]string, 0, len(p.Tags)),
+	}
+	if p.Category != nil {
+		doc.CategoryID = &p.Category.ID
+	}
+	for _, tag := range p.Tags {
+		doc.Tags = append(doc.Tags, tag.Name)
 	}
+	return doc
 }
@@ -0,0 +1,134 @@
+package post
+
+import (
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/contentfilter"
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"gorm.io/gorm"
+)
+
+// Enricher adds data owned by other modules to post responses. It receives
+// every response of a request at once so it can load its data in one query.
+// viewerID is zero for anonymous requests.
+type Enricher interface {
+	EnrichPosts(posts []*PostResponse, viewerID uint) error
+}
+
+// DeleteHook removes data that belongs to a post. It runs inside the
+// transaction that deletes the post, so returning an error aborts the delete.
+type DeleteHook func(tx *gorm.DB, post *Post) error
+
+// ReviewHook queues a post the content filter flagged for a moderator. It
+// runs inside the transaction that saves the post.
+type ReviewHook func(tx *gorm.DB, post *Post, reasons apperror.AppErrors) error
+
+// SaveHook reacts to a created or updated post, for example by notifying
+// the users it mentions. It runs inside the transaction that saves the post,
+// after the post has been written.
+type SaveHook func(tx *gorm.DB, post *Post) error
+
+const (
+	EventPostCreated = "post.created"
+	EventPostUpdated = "post.updated"
+	EventPostDeleted = "post.deleted"
+)
+
+// EventPublisher is told about public posts that were created, updated or
+// deleted once the change has been committed. Deleted posts only carry their
+// ID and author.
+type EventPublisher interface {
+	PublishPostEvent(eventType string, post *PostResponse)
+}
+
+// ViewRecorder counts reads of a post. viewer identifies the reader, a user
+// or a client address, so repeated reads can be ignored.
+type ViewRecorder interface {
+	RecordView(postID uint, viewer string)
+}
+
+type Option func(*service)
+
+func WithEnricher(enricher Enricher) Option {
+	return func(s *service) {
+		s.enrichers = append(s.enrichers, enricher)
+	}
+}
+
+func WithDeleteHook(hook DeleteHook) Option {
+	return func(s *service) {
+		s.deleteHooks = append(s.deleteHooks, hook)
+	}
+}
+
+// WithContentFilter checks the title and content of created and updated
+// posts. Rejected posts are not saved, flagged posts are saved hidden and
+// passed to the review hooks.
+func WithContentFilter(filter contentfilter.Filter) Option {
+	return func(s *service) {
+		s.contentFilter = filter
+	}
+}
+
+func WithReviewHook(hook ReviewHook) Option {
+	return func(s *service) {
+		s.reviewHooks = append(s.reviewHooks, hook)
+	}
+}
+
+func WithSaveHook(hook SaveHook) Option {
+	return func(s *service) {
+		s.saveHooks = append(s.saveHooks, hook)
+	}
+}
+
+func WithViewRecorder(recorder ViewRecorder) Option {
+	return func(s *service) {
+		s.viewRecorder = recorder
+	}
+}
+
+func WithEventPublisher(publisher EventPublisher) Option {
+	return func(s *service) {
+		s.publishers = append(s.publishers, publisher)
+	}
+}
+
+// WithOutbox writes PostPublished, PostUpdated and PostDeleted to the outbox
+// in the transaction of the change.
+func WithOutbox(writer outbox.Writer) Option {
+	return func(s *service) {
+		s.outbox = writer
+	}
+}
+
+// WithBulkLimit caps the number of operations accepted by Bulk. Zero
+// disables the limit.
+func WithBulkLimit(limit int) Option {
+	return func(s *service) {
+		s.bulkLimit = limit
+	}
+}
+
+// WithRequireVersion makes Bulk reject update and delete operations without
+// a version, as REQUIRE_IF_MATCH does for the single post endpoints.
+func WithRequireVersion(required bool) Option {
+	return func(s *service) {
+		s.requireVersion = required
+	}
+}
+
+type afterCommitKey struct{}
+
+// AfterCommit schedules fn to run once the transaction tx belongs to has been
+// committed. DeleteHooks and SaveHooks use it for work that cannot be rolled
+// back, such as removing stored files or publishing events. When tx was not
+// started by this package fn runs immediately.
+func AfterCommit(tx *gorm.DB, fn func()) {
+	if ctx := tx.Statement.Context; ctx != nil {
+		if pending, ok := ctx.Value(afterCommitKey{}).(*[]func()); ok {
+			*pending = append(*pending, fn)
+			return
+		}
+	}
+	fn()
+}
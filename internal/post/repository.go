@@ -1,16 +1,44 @@
 package post
 
 import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
 	Create(post *Post) error
-	FindAll() ([]Post, error)
+	// FindAll and FindLatest leave out hidden posts.
+	FindAll(query ListQuery) ([]Post, error)
 	FindByID(id uint) (*Post, error)
+	FindByIDs(ids []uint) ([]Post, error)
+	FindBySlug(slug string) (*Post, error)
+	// FindSlugs returns the slugs of every post that are base or base with a
+	// collision suffix.
+	FindSlugs(base string) ([]PostSlug, error)
+	// AddSlug returns false when another post already uses the slug.
+	AddSlug(postID uint, slug string) (bool, error)
 	FindByUserID(userID uint) ([]Post, error)
+	FindByExternalID(userID uint, externalID string) (*Post, error)
+	// FindInBatches passes the posts of userID, or of everyone when userID
+	// is zero, to fn in batches of size ordered by ID.
+	FindInBatches(userID uint, size int, fn func([]Post) error) error
+	// FindLatest returns the most recently created posts, optionally limited
+	// to one author when userID is not zero.
+	FindLatest(userID uint, limit int) ([]Post, error)
 	Update(post *Post) error
+	SetCover(id uint, coverID *uint) error
+	SetHidden(id uint, hiddenAt *time.Time) error
+	// ContentHashExists reports whether a post other than excludeID has the
+	// content hash.
+	ContentHashExists(hash string, excludeID uint) (bool, error)
+	ReplaceTags(post *Post, tags []taxonomy.Tag) error
 	Delete(id uint) error
+	WithTx(tx *gorm.DB) Repository
+	Transaction(fn func(tx *gorm.DB) error) error
 }
 
 type repository struct {
@@ -21,35 +49,180 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+func (r *repository) preload() *gorm.DB {
+	return r.db.Preload("User.Avatar.Variants").Preload("Category").Preload("Tags").Preload("Cover.Variants")
+}
+
 func (r *repository) Create(post *Post) error {
 	return r.db.Create(post).Error
 }
 
-func (r *repository) FindAll() ([]Post, error) {
+func (r *repository) FindAll(query ListQuery) ([]Post, error) {
 	var posts []Post
-	err := r.db.Preload("User").Find(&posts).Error
+	db := r.preload().Where("posts.hidden_at IS NULL")
+
+	if query.TagSlug != "" {
+		tagged := r.db.Table(taxonomy.PostTagsTable).
+			Select(taxonomy.PostTagsTable+".post_id").
+			Joins("JOIN tags ON tags.id = "+taxonomy.PostTagsTable+".tag_id").
+			Where("tags.slug = ?", query.TagSlug)
+		db = db.Where("posts.id IN (?)", tagged)
+	}
+	if len(query.CategoryIDs) > 0 {
+		db = db.Where("posts.category_id IN ?", query.CategoryIDs)
+	}
+
+	err := db.Find(&posts).Error
 	return posts, err
 }
 
 func (r *repository) FindByID(id uint) (*Post, error) {
 	var post Post
-	err := r.db.Preload("User").First(&post, id).Error
+	err := r.preload().First(&post, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *repository) FindByIDs(ids []uint) ([]Post, error) {
+	var posts []Post
+	if len(ids) == 0 {
+		return posts, nil
+	}
+	err := r.preload().Where("posts.id IN ?", ids).Find(&posts).Error
+	return posts, err
+}
+
+// FindBySlug finds the post that currently has, or previously had, the slug.
+func (r *repository) FindBySlug(slug string) (*Post, error) {
+	var post Post
+	err := r.preload().
+		Joins("JOIN post_slugs ON post_slugs.post_id = posts.id").
+		Where("post_slugs.slug = ?", slug).
+		First(&post).Error
 	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
 
+func (r *repository) FindSlugs(base string) ([]PostSlug, error) {
+	// Slugs only contain letters, digits and hyphens, so base needs no
+	// escaping. The prefix match narrows the index scan down for the
+	// pattern.
+	var postSlugs []PostSlug
+	err := r.db.Where("slug LIKE ? AND slug REGEXP ?", base+"%", "^"+base+"(-[0-9]+)?$").Find(&postSlugs).Error
+	return postSlugs, err
+}
+
+func (r *repository) AddSlug(postID uint, slug string) (bool, error) {
+	result := r.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&PostSlug{PostID: postID, Slug: slug})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *repository) FindByUserID(userID uint) ([]Post, error) {
 	var posts []Post
-	err := r.db.Preload("User").Where("user_id = ?", userID).Find(&posts).Error
+	err := r.preload().Where("user_id = ?", userID).Find(&posts).Error
 	return posts, err
 }
 
+func (r *repository) FindByExternalID(userID uint, externalID string) (*Post, error) {
+	var post Post
+	err := r.preload().Where("user_id = ? AND external_id = ?", userID, externalID).First(&post).Error
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (r *repository) FindInBatches(userID uint, size int, fn func([]Post) error) error {
+	db := r.db.Preload("User").Preload("Category").Preload("Tags")
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+
+	var batch []Post
+	return db.FindInBatches(&batch, size, func(tx *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+func (r *repository) FindLatest(userID uint, limit int) ([]Post, error) {
+	var posts []Post
+	db := r.preload().Where("posts.hidden_at IS NULL")
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	err := db.Order("created_at DESC").Order("id DESC").Limit(limit).Find(&posts).Error
+	return posts, err
+}
+
+// Update writes the post only if its version is still the one that was
+// loaded and increments the version in the same statement. Otherwise
+// etag.ErrVersionMismatch is returned and nothing is written.
 func (r *repository) Update(post *Post) error {
-	return r.db.Save(post).Error
+	post.UpdatedAt = time.Now()
+	result := r.db.Model(&Post{}).
+		Where("id = ? AND version = ?", post.ID, post.Version).
+		UpdateColumns(map[string]interface{}{
+			"title":          post.Title,
+			"slug":           post.Slug,
+			"content":        post.Content,
+			"content_format": post.ContentFormat,
+			"content_html":   post.ContentHTML,
+			"content_hash":   post.ContentHash,
+			"category_id":    post.CategoryID,
+			"updated_at":     post.UpdatedAt,
+			"version":        gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return etag.ErrVersionMismatch
+	}
+
+	post.Version++
+	return nil
+}
+
+func (r *repository) SetCover(id uint, coverID *uint) error {
+	return r.db.Model(&Post{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"cover_id": coverID,
+		"version":  gorm.Expr("version + 1"),
+	}).Error
+}
+
+// SetHidden does not change the version: hiding a post is not an edit and
+// must not invalidate the author's pending updates.
+func (r *repository) SetHidden(id uint, hiddenAt *time.Time) error {
+	return r.db.Model(&Post{}).Where("id = ?", id).UpdateColumn("hidden_at", hiddenAt).Error
+}
+
+func (r *repository) ContentHashExists(hash string, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&Post{}).Where("content_hash = ? AND id <> ?", hash, excludeID).Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *repository) ReplaceTags(post *Post, tags []taxonomy.Tag) error {
+	return r.db.Model(post).Association("Tags").Replace(tags)
 }
 
 func (r *repository) Delete(id uint) error {
 	return r.db.Delete(&Post{}, id).Error
 }
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
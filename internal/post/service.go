@@ -1,36 +1,180 @@
 package post
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
 	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/contentfilter"
+	"github.com/ardipermana59/go-template/internal/media"
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
+	"github.com/ardipermana59/go-template/pkg/slug"
 	"gorm.io/gorm"
 )
 
+// Service reads leave out posts hidden by a moderator unless viewerID is
+// the author.
 type Service interface {
 	CreatePost(userID uint, dto CreatePostDTO) (*PostResponse, apperror.AppErrors)
-	GetAllPosts() ([]PostResponse, apperror.AppErrors)
-	GetPostByID(id uint) (*PostResponse, apperror.AppErrors)
-	GetPostsByUserID(userID uint) ([]PostResponse, apperror.AppErrors)
+	GetAllPosts(filter PostFilter, viewerID uint) ([]PostResponse, apperror.AppErrors)
+	GetPostByID(id, viewerID uint) (*PostResponse, apperror.AppErrors)
+	GetPostBySlug(slug string, viewerID uint) (*PostResponse, apperror.AppErrors)
+	// RecordView counts a read of the post. Authors reading their own posts
+	// are not counted.
+	RecordView(post *PostResponse, viewerID uint, clientIP string)
+	GetPostsByUserID(userID, viewerID uint) ([]PostResponse, apperror.AppErrors)
+	GetPostsByIDs(ids []uint, viewerID uint) ([]PostResponse, apperror.AppErrors)
 	GetMyPosts(userID uint) ([]PostResponse, apperror.AppErrors)
-	UpdatePost(id, userID uint, dto UpdatePostDTO) (*PostResponse, apperror.AppErrors)
+	// GetLatestPosts returns the newest posts, of one author when userID is
+	// not zero.
+	GetLatestPosts(userID uint, limit int) ([]PostResponse, apperror.AppErrors)
+	// ExportPosts passes every post of userID, or of everyone when userID is
+	// zero, to fn one batch at a time. Responses are not enriched. Errors
+	// returned by fn stop the export and are returned as is.
+	ExportPosts(userID uint, fn func([]PostResponse) error) error
+	// ImportPost creates the post unless userID already has a post with the
+	// same external ID, in which case that post is returned and created is
+	// false.
+	ImportPost(userID uint, dto ImportPostDTO) (post *PostResponse, created bool, appErr apperror.AppErrors)
+	ExportUserData(userID uint) (interface{}, error)
+	// UpdatePost applies dto if the post is still at expectedVersion. A zero
+	// expectedVersion skips the client check; concurrent writes are still
+	// detected against the version that was read.
+	UpdatePost(id, userID, expectedVersion uint, dto UpdatePostDTO) (*PostResponse, apperror.AppErrors)
+	// ReplacePost overwrites every editable field with doc. It backs PATCH
+	// requests, which are applied to the post's PostDocument first.
+	ReplacePost(id, userID, expectedVersion uint, doc PostDocument) (*PostResponse, apperror.AppErrors)
 	DeletePost(id, userID uint) apperror.AppErrors
+	// HidePost hides or restores a post on behalf of a moderator. decision,
+	// when set, records the moderator's decision in the same transaction.
+	HidePost(id uint, hidden bool, decision func(tx *gorm.DB) error) apperror.AppErrors
+	// RemovePost deletes a post on behalf of a moderator, regardless of
+	// ownership. decision is run like in HidePost.
+	RemovePost(id uint, decision func(tx *gorm.DB) error) apperror.AppErrors
+	// Bulk creates, updates and deletes posts of userID in one request.
+	// Atomic batches are applied completely or not at all.
+	Bulk(userID uint, atomic bool, items []BulkItem) (*BulkResponse, apperror.AppErrors)
+	UpdateCover(ctx context.Context, id, userID uint, upload media.Upload) (*PostResponse, apperror.AppErrors)
+	DeleteCover(ctx context.Context, id, userID uint) (*PostResponse, apperror.AppErrors)
 }
 
 type service struct {
-	repo Repository
+	repo        Repository
+	taxonomy    taxonomy.Service
+	media       media.Service
+	enrichers   []Enricher
+	deleteHooks []DeleteHook
+	saveHooks   []SaveHook
+	bulkLimit   int
+
+	requireVersion bool
+
+	viewRecorder  ViewRecorder
+	contentFilter contentfilter.Filter
+	reviewHooks   []ReviewHook
+	publishers    []EventPublisher
+	outbox        outbox.Writer
+
+	// afterCommit collects deferred work while the service runs inside a
+	// bulk transaction; see withTx.
+	afterCommit *[]func()
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo: repo}
+func NewService(repo Repository, taxonomyService taxonomy.Service, mediaService media.Service, opts ...Option) Service {
+	s := &service{
+		repo:     repo,
+		taxonomy: taxonomyService,
+		media:    mediaService,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
 func (s *service) CreatePost(userID uint, dto CreatePostDTO) (*PostResponse, apperror.AppErrors) {
-	post := &Post{
-		Title:   dto.Title,
-		Content: dto.Content,
-		UserID:  userID,
+	return s.create(&Post{UserID: userID}, dto)
+}
+
+func (s *service) ImportPost(userID uint, dto ImportPostDTO) (*PostResponse, bool, apperror.AppErrors) {
+	existing, err := s.repo.FindByExternalID(userID, dto.ExternalID)
+	if err == nil {
+		response, appErr := s.toResponse(existing, userID)
+		return response, false, appErr
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, false, apperror.DatabaseError(err)
+	}
+
+	post := &Post{UserID: userID, ExternalID: &dto.ExternalID}
+	if dto.CreatedAt != nil {
+		post.CreatedAt = *dto.CreatedAt
+	}
+	response, appErr := s.create(post, dto.CreatePostDTO)
+	if appErr != nil {
+		return nil, false, appErr
+	}
+	return response, true, nil
+}
+
+// create fills post from dto and stores it. post carries the owner and any
+// fields dto doesn't have.
+func (s *service) create(post *Post, dto CreatePostDTO) (*PostResponse, apperror.AppErrors) {
+	if dto.CategoryID != nil {
+		if _, appErr := s.taxonomy.GetCategory(*dto.CategoryID); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	post.Title = dto.Title
+	post.Content = dto.Content
+	post.ContentFormat = dto.ContentFormat
+	post.CategoryID = dto.CategoryID
+	if err := post.RenderContent(); err != nil {
+		return nil, apperror.ContentRenderFailed()
+	}
+
+	flagged, appErr := s.screen(post)
+	if appErr != nil {
+		return nil, appErr
 	}
 
-	if err := s.repo.Create(post); err != nil {
+	err := s.transaction(func(tx *gorm.DB) error {
+		tags, err := s.taxonomy.ResolveTags(tx, dto.Tags)
+		if err != nil {
+			return err
+		}
+		post.Tags = tags
+
+		repo := s.repo.WithTx(tx)
+		postSlug, _, err := newSlug(repo, post)
+		if err != nil {
+			return err
+		}
+		post.Slug = postSlug
+
+		// A retried transaction inserts the post again.
+		post.ID = 0
+		if err := repo.Create(post); err != nil {
+			return err
+		}
+		if err := addSlug(repo, post, post.Slug); err != nil {
+			return err
+		}
+		if err := s.requestReview(tx, post, flagged); err != nil {
+			return err
+		}
+		if err := s.saved(tx, post); err != nil {
+			return err
+		}
+		return s.record(tx, PostPublished{PostID: post.ID, UserID: post.UserID, Hidden: post.HiddenAt != nil})
+	})
+	if err != nil {
 		return nil, apperror.DatabaseError(err)
 	}
 
@@ -39,24 +183,40 @@ func (s *service) CreatePost(userID uint, dto CreatePostDTO) (*PostResponse, app
 		return nil, apperror.DatabaseError(err)
 	}
 
-	return createdPost.ToResponse(), nil
+	response, appErr := s.toResponse(createdPost, post.UserID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	s.publish(EventPostCreated, response)
+	response.ReviewReasons = flagged
+	return response, nil
 }
 
-func (s *service) GetAllPosts() ([]PostResponse, apperror.AppErrors) {
-	posts, err := s.repo.FindAll()
-	if err != nil {
-		return nil, apperror.DatabaseError(err)
+func (s *service) GetAllPosts(filter PostFilter, viewerID uint) ([]PostResponse, apperror.AppErrors) {
+	var query ListQuery
+	if filter.Tag != "" {
+		query.TagSlug = taxonomy.Slug(taxonomy.NormalizeTagName(filter.Tag))
+	}
+	if filter.Category != "" {
+		ids, appErr := s.taxonomy.CategoryIDsBySlug(filter.Category)
+		if appErr != nil {
+			return nil, appErr
+		}
+		if len(ids) == 0 {
+			return []PostResponse{}, nil
+		}
+		query.CategoryIDs = ids
 	}
 
-	var responses []PostResponse
-	for _, post := range posts {
-		responses = append(responses, *post.ToResponse())
+	posts, err := s.repo.FindAll(query)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
 	}
 
-	return responses, nil
+	return s.toResponses(posts, viewerID)
 }
 
-func (s *service) GetPostByID(id uint) (*PostResponse, apperror.AppErrors) {
+func (s *service) GetPostByID(id, viewerID uint) (*PostResponse, apperror.AppErrors) {
 	post, err := s.repo.FindByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -64,28 +224,172 @@ func (s *service) GetPostByID(id uint) (*PostResponse, apperror.AppErrors) {
 		}
 		return nil, apperror.DatabaseError(err)
 	}
-	return post.ToResponse(), nil
+	if !post.VisibleTo(viewerID) {
+		return nil, apperror.PostNotFound()
+	}
+	return s.toResponse(post, viewerID)
+}
+
+// GetPostBySlug also resolves slugs the post had before its title changed.
+// Callers can compare the requested slug with PostResponse.Slug to detect
+// that the canonical slug is different.
+func (s *service) GetPostBySlug(postSlug string, viewerID uint) (*PostResponse, apperror.AppErrors) {
+	post, err := s.repo.FindBySlug(postSlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if !post.VisibleTo(viewerID) {
+		return nil, apperror.PostNotFound()
+	}
+	return s.toResponse(post, viewerID)
+}
+
+func (s *service) RecordView(post *PostResponse, viewerID uint, clientIP string) {
+	if s.viewRecorder == nil || post.UserID == viewerID {
+		return
+	}
+
+	viewer := "ip:" + clientIP
+	if viewerID != 0 {
+		viewer = fmt.Sprintf("user:%d", viewerID)
+	}
+	s.viewRecorder.RecordView(post.ID, viewer)
 }
 
-func (s *service) GetPostsByUserID(userID uint) ([]PostResponse, apperror.AppErrors) {
+func (s *service) GetPostsByUserID(userID, viewerID uint) ([]PostResponse, apperror.AppErrors) {
 	posts, err := s.repo.FindByUserID(userID)
 	if err != nil {
 		return nil, apperror.DatabaseError(err)
 	}
 
-	var responses []PostResponse
+	return s.toResponses(visiblePosts(posts, viewerID), viewerID)
+}
+
+// GetPostsByIDs returns the posts in the order of ids, skipping IDs of
+// posts that no longer exist.
+func (s *service) GetPostsByIDs(ids []uint, viewerID uint) ([]PostResponse, apperror.AppErrors) {
+	posts, err := s.repo.FindByIDs(ids)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	byID := make(map[uint]Post, len(posts))
 	for _, post := range posts {
-		responses = append(responses, *post.ToResponse())
+		byID[post.ID] = post
 	}
 
-	return responses, nil
+	ordered := make([]Post, 0, len(posts))
+	for _, id := range ids {
+		if post, ok := byID[id]; ok && post.VisibleTo(viewerID) {
+			ordered = append(ordered, post)
+		}
+	}
+
+	return s.toResponses(ordered, viewerID)
 }
 
 func (s *service) GetMyPosts(userID uint) ([]PostResponse, apperror.AppErrors) {
-	return s.GetPostsByUserID(userID)
+	return s.GetPostsByUserID(userID, userID)
+}
+
+func (s *service) GetLatestPosts(userID uint, limit int) ([]PostResponse, apperror.AppErrors) {
+	posts, err := s.repo.FindLatest(userID, limit)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return s.toResponses(posts, 0)
+}
+
+func (s *service) ExportPosts(userID uint, fn func([]PostResponse) error) error {
+	return s.repo.FindInBatches(userID, 200, func(posts []Post) error {
+		responses := make([]PostResponse, 0, len(posts))
+		for i := range posts {
+			responses = append(responses, *posts[i].ToResponse())
+		}
+		return fn(responses)
+	})
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	posts, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]PostResponse, 0, len(posts))
+	for i := range posts {
+		responses = append(responses, *posts[i].ToResponse())
+	}
+	return responses, nil
 }
 
-func (s *service) UpdatePost(id, userID uint, dto UpdatePostDTO) (*PostResponse, apperror.AppErrors) {
+func (s *service) UpdatePost(id, userID, expectedVersion uint, dto UpdatePostDTO) (*PostResponse, apperror.AppErrors) {
+	post, appErr := s.findForUpdate(id, userID, expectedVersion)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	titleChanged := false
+	if dto.Title != "" {
+		titleChanged = dto.Title != post.Title
+		post.Title = dto.Title
+	}
+	if dto.Content != "" || dto.ContentFormat != "" {
+		if dto.Content != "" {
+			post.Content = dto.Content
+		}
+		if dto.ContentFormat != "" {
+			post.ContentFormat = dto.ContentFormat
+		}
+		if err := post.RenderContent(); err != nil {
+			return nil, apperror.ContentRenderFailed()
+		}
+	}
+	if dto.CategoryID != nil {
+		if _, appErr := s.taxonomy.GetCategory(*dto.CategoryID); appErr != nil {
+			return nil, appErr
+		}
+		post.CategoryID = dto.CategoryID
+	}
+
+	return s.save(post, titleChanged, dto.Tags)
+}
+
+func (s *service) ReplacePost(id, userID, expectedVersion uint, doc PostDocument) (*PostResponse, apperror.AppErrors) {
+	post, appErr := s.findForUpdate(id, userID, expectedVersion)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if doc.CategoryID != nil {
+		if _, appErr := s.taxonomy.GetCategory(*doc.CategoryID); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	titleChanged := doc.Title != post.Title
+	post.Title = doc.Title
+	post.Content = doc.Content
+	post.ContentFormat = doc.ContentFormat
+	post.CategoryID = doc.CategoryID
+	if err := post.RenderContent(); err != nil {
+		return nil, apperror.ContentRenderFailed()
+	}
+
+	tags := doc.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return s.save(post, titleChanged, tags)
+}
+
+// findForUpdate loads a post its owner is about to change and checks the
+// version the client based its change on.
+func (s *service) findForUpdate(id, userID, expectedVersion uint) (*Post, apperror.AppErrors) {
 	post, err := s.repo.FindByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -97,27 +401,155 @@ func (s *service) UpdatePost(id, userID uint, dto UpdatePostDTO) (*PostResponse,
 	if post.UserID != userID {
 		return nil, apperror.OwnershipRequired()
 	}
+	if expectedVersion != 0 && post.Version != expectedVersion {
+		return nil, apperror.VersionConflict()
+	}
+	return post, nil
+}
 
-	if dto.Title != "" {
-		post.Title = dto.Title
+// save writes a changed post. Tags are replaced unless tagNames is nil.
+func (s *service) save(post *Post, titleChanged bool, tagNames []string) (*PostResponse, apperror.AppErrors) {
+	wasHidden := post.HiddenAt != nil
+	flagged, appErr := s.screen(post)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	err := s.transaction(func(tx *gorm.DB) error {
+		repo := s.repo.WithTx(tx)
+		if titleChanged {
+			if err := renameSlug(repo, post); err != nil {
+				return err
+			}
+		}
+		if err := repo.Update(post); err != nil {
+			return err
+		}
+		if tagNames != nil {
+			tags, err := s.taxonomy.ResolveTags(tx, tagNames)
+			if err != nil {
+				return err
+			}
+			if err := repo.ReplaceTags(post, tags); err != nil {
+				return err
+			}
+		}
+		if flagged != nil && !wasHidden {
+			if err := repo.SetHidden(post.ID, post.HiddenAt); err != nil {
+				return err
+			}
+		}
+		if err := s.requestReview(tx, post, flagged); err != nil {
+			return err
+		}
+		if err := s.saved(tx, post); err != nil {
+			return err
+		}
+		return s.record(tx, PostUpdated{PostID: post.ID, UserID: post.UserID, Hidden: post.HiddenAt != nil, WasHidden: wasHidden})
+	})
+	if err == etag.ErrVersionMismatch {
+		return nil, apperror.VersionConflict()
 	}
-	if dto.Content != "" {
-		post.Content = dto.Content
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
 	}
 
-	if err := s.repo.Update(post); err != nil {
+	updatedPost, err := s.repo.FindByID(post.ID)
+	if err != nil {
 		return nil, apperror.DatabaseError(err)
 	}
 
-	updatedPost, err := s.repo.FindByID(id)
+	response, appErr := s.toResponse(updatedPost, post.UserID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if response.Hidden && !wasHidden {
+		s.publishHidden(updatedPost)
+	} else {
+		s.publish(EventPostUpdated, response)
+	}
+	response.ReviewReasons = flagged
+	return response, nil
+}
+
+// screen runs the content filter and records the content hash. Rejections
+// are returned as errors. Flagged posts are marked hidden and the reasons
+// are returned for requestReview once the post is saved.
+func (s *service) screen(post *Post) (flagged apperror.AppErrors, appErr apperror.AppErrors) {
+	post.ContentHash = contentfilter.Hash(post.Content)
+	if s.contentFilter == nil {
+		return nil, nil
+	}
+
+	result, err := s.contentFilter.Check(contentfilter.Content{
+		Context: context.WithValue(context.Background(), repoKey{}, s.repo),
+		PostID:  post.ID,
+		UserID:  post.UserID,
+		Title:   post.Title,
+		Body:    post.Content,
+	})
 	if err != nil {
 		return nil, apperror.DatabaseError(err)
 	}
 
-	return updatedPost.ToResponse(), nil
+	switch result.Verdict {
+	case contentfilter.Reject:
+		return nil, result.Reasons
+	case contentfilter.Flag:
+		if post.HiddenAt == nil {
+			now := time.Now()
+			post.HiddenAt = &now
+		}
+		return result.Reasons, nil
+	}
+	return nil, nil
+}
+
+// repoKey carries the repository of the service that screens a post in
+// the context of the checked content; see HashLookup.
+type repoKey struct{}
+
+// HashLookup returns the contentfilter.HashLookup for posts of repo.
+// Content screened by a service running in a bulk transaction is looked up
+// in that transaction, so posts created earlier in the batch count.
+func HashLookup(repo Repository) contentfilter.HashLookup {
+	return func(ctx context.Context, hash string, excludePostID uint) (bool, error) {
+		lookup := repo
+		if txRepo, ok := ctx.Value(repoKey{}).(Repository); ok {
+			lookup = txRepo
+		}
+		return lookup.ContentHashExists(hash, excludePostID)
+	}
+}
+
+func (s *service) requestReview(tx *gorm.DB, post *Post, reasons apperror.AppErrors) error {
+	if reasons == nil {
+		return nil
+	}
+	for _, hook := range s.reviewHooks {
+		if err := hook(tx, post, reasons); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *service) saved(tx *gorm.DB, post *Post) error {
+	for _, hook := range s.saveHooks {
+		if err := hook(tx, post); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (s *service) DeletePost(id, userID uint) apperror.AppErrors {
+	return s.deletePost(id, userID, 0)
+}
+
+// deletePost removes the post if it is still at expectedVersion. A zero
+// expectedVersion skips the check.
+func (s *service) deletePost(id, userID, expectedVersion uint) apperror.AppErrors {
 	post, err := s.repo.FindByID(id)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -129,10 +561,369 @@ func (s *service) DeletePost(id, userID uint) apperror.AppErrors {
 	if post.UserID != userID {
 		return apperror.OwnershipRequired()
 	}
+	if expectedVersion != 0 && post.Version != expectedVersion {
+		return apperror.VersionConflict()
+	}
+
+	return s.remove(post, nil)
+}
+
+func (s *service) HidePost(id uint, hidden bool, decision func(tx *gorm.DB) error) apperror.AppErrors {
+	post, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.PostNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
 
-	if err := s.repo.Delete(id); err != nil {
+	wasHidden := post.HiddenAt != nil
+	var hiddenAt *time.Time
+	if hidden {
+		now := time.Now()
+		hiddenAt = &now
+	}
+	err = s.transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).SetHidden(id, hiddenAt); err != nil {
+			return err
+		}
+		if decision != nil {
+			if err := decision(tx); err != nil {
+				return err
+			}
+		}
+		if hidden == wasHidden {
+			return nil
+		}
+		return s.record(tx, PostUpdated{PostID: post.ID, UserID: post.UserID, Hidden: hidden, WasHidden: wasHidden})
+	})
+	if err != nil {
 		return apperror.DatabaseError(err)
 	}
 
+	switch {
+	case hidden && !wasHidden:
+		s.publishHidden(post)
+	case !hidden && wasHidden:
+		restored, err := s.repo.FindByID(id)
+		if err != nil {
+			return apperror.DatabaseError(err)
+		}
+		response, appErr := s.toResponse(restored, 0)
+		if appErr != nil {
+			return appErr
+		}
+		s.publish(EventPostCreated, response)
+	}
+	return nil
+}
+
+func (s *service) RemovePost(id uint, decision func(tx *gorm.DB) error) apperror.AppErrors {
+	post, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.PostNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
+
+	return s.remove(post, decision)
+}
+
+// remove deletes the post together with the data of its delete hooks.
+// decision, when set, runs in the same transaction after the delete.
+func (s *service) remove(post *Post, decision func(tx *gorm.DB) error) apperror.AppErrors {
+	err := s.transaction(func(tx *gorm.DB) error {
+		for _, hook := range s.deleteHooks {
+			if err := hook(tx, post); err != nil {
+				return err
+			}
+		}
+		if err := s.repo.WithTx(tx).Delete(post.ID); err != nil {
+			return err
+		}
+		if decision != nil {
+			if err := decision(tx); err != nil {
+				return err
+			}
+		}
+		AfterCommit(tx, func() {
+			s.removeImage(context.Background(), post.CoverID)
+		})
+		return s.record(tx, PostDeleted{PostID: post.ID, UserID: post.UserID, Hidden: post.HiddenAt != nil})
+	})
+	if err != nil {
+		return apperror.DatabaseError(err)
+	}
+
+	s.publish(EventPostDeleted, &PostResponse{ID: post.ID, UserID: post.UserID, Hidden: post.HiddenAt != nil})
+	return nil
+}
+
+// transaction runs fn in a transaction whose hooks can use AfterCommit and
+// runs the work they scheduled once it has been committed. fn is run again
+// when a concurrent writer took the slug it picked.
+func (s *service) transaction(fn func(tx *gorm.DB) error) error {
+	var pending []func()
+	var err error
+	for attempt := 1; attempt <= slugAttempts; attempt++ {
+		pending = nil
+		err = s.repo.Transaction(func(tx *gorm.DB) error {
+			return fn(tx.WithContext(context.WithValue(tx.Statement.Context, afterCommitKey{}, &pending)))
+		})
+		if err != errSlugTaken {
+			break
+		}
+	}
+	if err != nil {
+		return err
+	}
+	s.committed(pending)
+	return nil
+}
+
+// record writes domain events to the outbox inside tx and wakes the
+// dispatcher once tx has been committed.
+func (s *service) record(tx *gorm.DB, events ...outbox.Event) error {
+	if s.outbox == nil {
+		return nil
+	}
+	if err := s.outbox.Write(tx, events...); err != nil {
+		return err
+	}
+	AfterCommit(tx, s.outbox.Trigger)
+	return nil
+}
+
+// publish hands a change of a public post to the event publisher once it
+// has been committed. The viewer specific fields of response are left out.
+func (s *service) publish(eventType string, response *PostResponse) {
+	if len(s.publishers) == 0 || response.Hidden {
+		return
+	}
+	event := *response
+	event.MyReactions = nil
+	event.ReviewReasons = nil
+	s.committed([]func(){func() {
+		for _, publisher := range s.publishers {
+			publisher.PublishPostEvent(eventType, &event)
+		}
+	}})
+}
+
+// publishHidden tells subscribers that a post they may have received is no
+// longer public. They only see public posts, so hiding is sent as
+// post.deleted and restoring, like webhooks, as post.created.
+func (s *service) publishHidden(post *Post) {
+	s.publish(EventPostDeleted, &PostResponse{ID: post.ID, UserID: post.UserID})
+}
+
+// committed runs work scheduled with AfterCommit, or hands it to the
+// enclosing bulk transaction when there is one.
+func (s *service) committed(pending []func()) {
+	if s.afterCommit != nil {
+		*s.afterCommit = append(*s.afterCommit, pending...)
+		return
+	}
+	for _, fn := range pending {
+		fn()
+	}
+}
+
+func (s *service) UpdateCover(ctx context.Context, id, userID uint, upload media.Upload) (*PostResponse, apperror.AppErrors) {
+	post, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	if post.UserID != userID {
+		return nil, apperror.OwnershipRequired()
+	}
+
+	image, appErr := s.media.Upload(ctx, userID, upload)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	response, appErr := s.setCover(ctx, post, &image.ID)
+	if appErr != nil {
+		s.removeImage(ctx, &image.ID)
+		return nil, appErr
+	}
+	return response, nil
+}
+
+func (s *service) DeleteCover(ctx context.Context, id, userID uint) (*PostResponse, apperror.AppErrors) {
+	post, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	if post.UserID != userID {
+		return nil, apperror.OwnershipRequired()
+	}
+
+	return s.setCover(ctx, post, nil)
+}
+
+// setCover replaces the cover of post like any other edit: the version is
+// bumped and the change is recorded and published. The previous image is
+// removed once the new cover has been committed.
+func (s *service) setCover(ctx context.Context, post *Post, coverID *uint) (*PostResponse, apperror.AppErrors) {
+	hidden := post.HiddenAt != nil
+	err := s.transaction(func(tx *gorm.DB) error {
+		if err := s.repo.WithTx(tx).SetCover(post.ID, coverID); err != nil {
+			return err
+		}
+		return s.record(tx, PostUpdated{PostID: post.ID, UserID: post.UserID, Hidden: hidden, WasHidden: hidden})
+	})
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	s.removeImage(ctx, post.CoverID)
+
+	updatedPost, err := s.repo.FindByID(post.ID)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	response, appErr := s.toResponse(updatedPost, post.UserID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	s.publish(EventPostUpdated, response)
+	return response, nil
+}
+
+// removeImage deletes a replaced or orphaned cover image. Failures only leave
+// unreferenced files behind, so they are logged rather than returned.
+func (s *service) removeImage(ctx context.Context, id *uint) {
+	if id == nil {
+		return
+	}
+	if err := s.media.Delete(ctx, *id); err != nil {
+		log.Printf("post: failed to delete image %d: %v", *id, err)
+	}
+}
+
+func visiblePosts(posts []Post, viewerID uint) []Post {
+	kept := posts[:0]
+	for i := range posts {
+		if posts[i].VisibleTo(viewerID) {
+			kept = append(kept, posts[i])
+		}
+	}
+	return kept
+}
+
+func (s *service) toResponse(post *Post, viewerID uint) (*PostResponse, apperror.AppErrors) {
+	response := post.ToResponse()
+	if err := s.enrich([]*PostResponse{response}, viewerID); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	return response, nil
+}
+
+func (s *service) toResponses(posts []Post, viewerID uint) ([]PostResponse, apperror.AppErrors) {
+	var responses []PostResponse
+	for _, post := range posts {
+		responses = append(responses, *post.ToResponse())
+	}
+
+	refs := make([]*PostResponse, len(responses))
+	for i := range responses {
+		refs[i] = &responses[i]
+	}
+	if err := s.enrich(refs, viewerID); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return responses, nil
+}
+
+func (s *service) enrich(responses []*PostResponse, viewerID uint) error {
+	if len(responses) == 0 {
+		return nil
+	}
+	for _, enricher := range s.enrichers {
+		if err := enricher.EnrichPosts(responses, viewerID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// slugAttempts bounds how often a transaction is run again when the slug it
+// picked was taken concurrently.
+const slugAttempts = 3
+
+var errSlugTaken = errors.New("slug taken by another post")
+
+// newSlug derives a slug from the post title that no other post uses, now or
+// in its slug history. Slugs previously used by the post itself are reused,
+// in which case owned is true.
+func newSlug(repo Repository, post *Post) (postSlug string, owned bool, err error) {
+	base := slug.Make(post.Title)
+	if base == "" {
+		base = "post"
+	}
+
+	existing, err := repo.FindSlugs(base)
+	if err != nil {
+		return "", false, err
+	}
+	var taken []string
+	for _, s := range existing {
+		if s.PostID != post.ID {
+			taken = append(taken, s.Slug)
+		}
+	}
+	postSlug = slug.Unique(base, taken)
+	for _, s := range existing {
+		if s.Slug == postSlug {
+			return postSlug, true, nil
+		}
+	}
+	return postSlug, false, nil
+}
+
+// addSlug records a new slug of the post. It fails with errSlugTaken when
+// another post claimed the slug after newSlug picked it.
+func addSlug(repo Repository, post *Post, postSlug string) error {
+	added, err := repo.AddSlug(post.ID, postSlug)
+	if err != nil {
+		return err
+	}
+	if !added {
+		return errSlugTaken
+	}
+	return nil
+}
+
+// renameSlug gives the post a new canonical slug after a title change and
+// keeps the old one in the slug history so existing links can be redirected.
+func renameSlug(repo Repository, post *Post) error {
+	base := slug.Make(post.Title)
+	if base != "" && slug.HasBase(post.Slug, base) {
+		return nil
+	}
+
+	postSlug, owned, err := newSlug(repo, post)
+	if err != nil {
+		return err
+	}
+	if !owned {
+		if err := addSlug(repo, post, postSlug); err != nil {
+			return err
+		}
+	}
+
+	post.Slug = postSlug
 	return nil
 }
@@ -0,0 +1,103 @@
+package privacy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+	appURL  string
+}
+
+func NewHandler(service Service, appURL string) *Handler {
+	return &Handler{service: service, appURL: appURL}
+}
+
+// RequestExport answers with 202; the archive is built in the background
+// and GetExport reports when it can be downloaded.
+func (h *Handler) RequestExport(c *gin.Context) {
+	export, appErr := h.service.RequestExport(c.GetUint("user_id"))
+	if appErr != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to request data export", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Data export requested", h.withDownloadURL(export))
+}
+
+func (h *Handler) GetExport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	export, appErr := h.service.GetExport(uint(id), c.GetUint("user_id"))
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Data export not found", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Data export retrieved successfully", h.withDownloadURL(export))
+}
+
+func (h *Handler) DownloadExport(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	reader, export, appErr := h.service.OpenExport(c.Request.Context(), uint(id), c.GetUint("user_id"))
+	if appErr != nil {
+		if appErr.Has("export") {
+			response.Error(c, http.StatusNotFound, "Data export not available", appErr)
+			return
+		}
+		response.Error(c, http.StatusInternalServerError, "Failed to open data export", appErr)
+		return
+	}
+	defer reader.Close()
+
+	filename := fmt.Sprintf("personal-data-%s.zip", export.CreatedAt.UTC().Format("20060102"))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Cache-Control", "private, no-store")
+	c.DataFromReader(http.StatusOK, export.Size, "application/zip", reader, nil)
+}
+
+// DeleteAccount disables the account right away; it is erased when the
+// grace period ends unless the user restores it by signing in through
+// POST /auth/restore.
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	var dto user.DeleteAccountDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	result, appErr := h.service.RequestDeletion(c.GetUint("user_id"), dto)
+	if appErr != nil {
+		if appErr.Has("password") {
+			response.Error(c, http.StatusForbidden, "Failed to delete account", appErr)
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to delete account", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Account scheduled for deletion", result)
+}
+
+func (h *Handler) withDownloadURL(export *ExportResponse) *ExportResponse {
+	if export.Status == StatusReady {
+		export.DownloadURL = fmt.Sprintf("%s/api/v1/me/exports/%d/download", h.appURL, export.ID)
+	}
+	return export
+}
@@ -0,0 +1,35 @@
+package privacy
+
+import (
+	"context"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/job"
+)
+
+// ExportJob builds the archive of a requested export.
+type ExportJob struct {
+	ExportID uint `json:"export_id"`
+}
+
+func (ExportJob) JobType() string { return "privacy.export" }
+
+// SweepJob runs Service.Sweep.
+type SweepJob struct{}
+
+func (SweepJob) JobType() string { return "privacy.sweep" }
+
+// RegisterJobs registers the privacy jobs with the queue and sweeps every
+// minute. Exports are built one at a time per instance; a failed build marks
+// the export failed, so it is not retried and the user requests a new one.
+func RegisterJobs(queue *job.Queue, service Service) error {
+	job.Handle(queue, func(ctx context.Context, j ExportJob) error {
+		return service.ProcessExport(ctx, j.ExportID)
+	}, job.Concurrency(1), job.Timeout(30*time.Minute), job.Attempts(1))
+
+	job.Handle(queue, func(ctx context.Context, j SweepJob) error {
+		return service.Sweep(ctx)
+	}, job.Timeout(10*time.Minute))
+
+	return queue.Every("privacy.sweep", "@every 1m", SweepJob{})
+}
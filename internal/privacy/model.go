@@ -0,0 +1,66 @@
+package privacy
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+const (
+	StatusPending = "pending"
+	StatusReady   = "ready"
+	StatusFailed  = "failed"
+)
+
+// Erasure policies applied when the grace period of a deletion ends.
+const (
+	// PolicyAnonymize keeps posts and comments but replaces the account's
+	// name, email, password and avatar.
+	PolicyAnonymize = "anonymize"
+	// PolicyDelete removes the account together with everything it owns.
+	PolicyDelete = "delete"
+)
+
+// DataExport is a personal data archive requested by a user.
+type DataExport struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	User       user.User  `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Status     string     `json:"status" gorm:"size:20;not null;index"`
+	StorageKey string     `json:"-" gorm:"size:255"`
+	Size       int64      `json:"size"`
+	ExpiresAt  *time.Time `json:"expires_at" gorm:"index"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type ExportResponse struct {
+	ID          uint       `json:"id"`
+	Status      string     `json:"status"`
+	Size        int64      `json:"size,omitempty"`
+	DownloadURL string     `json:"download_url,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type DeletionResponse struct {
+	DeleteAt time.Time `json:"delete_at"`
+	Policy   string    `json:"policy"`
+}
+
+// manifest is written to every archive next to the section files.
+type manifest struct {
+	UserID      uint      `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+func (e *DataExport) ToResponse() *ExportResponse {
+	return &ExportResponse{
+		ID:        e.ID,
+		Status:    e.Status,
+		Size:      e.Size,
+		ExpiresAt: e.ExpiresAt,
+		CreatedAt: e.CreatedAt,
+	}
+}
@@ -0,0 +1,24 @@
+package privacy
+
+// Exporter is implemented by the services of modules that store personal
+// data. ExportUserData returns everything the module keeps about userID;
+// the value is written to the archive as JSON. main registers each exporter
+// with WithSection.
+type Exporter interface {
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+type section struct {
+	name     string
+	exporter Exporter
+}
+
+type Option func(*service)
+
+// WithSection adds <name>.json, filled by exporter, to every archive.
+// Sections are written in the order they were registered.
+func WithSection(name string, exporter Exporter) Option {
+	return func(s *service) {
+		s.sections = append(s.sections, section{name: name, exporter: exporter})
+	}
+}
@@ -0,0 +1,105 @@
+package privacy
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Create(export *DataExport) error
+	FindByID(id uint) (*DataExport, error)
+	FindByUserID(userID uint) ([]DataExport, error)
+	// FindPendingByUserID returns the user's export that is still being
+	// built, if any.
+	FindPendingByUserID(userID uint) (*DataExport, error)
+	FindPendingIDs() ([]uint, error)
+	// MarkReady records the stored archive of a pending export. It returns
+	// gorm.ErrRecordNotFound when the export is no longer pending.
+	MarkReady(export *DataExport) error
+	// MarkFailed marks a pending export as failed. expiresAt decides when
+	// the record is cleaned up.
+	MarkFailed(id uint, expiresAt time.Time) error
+	FindExpired(now time.Time) ([]DataExport, error)
+	Delete(id uint) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) Create(export *DataExport) error {
+	return r.db.Omit(clause.Associations).Create(export).Error
+}
+
+func (r *repository) FindByID(id uint) (*DataExport, error) {
+	var export DataExport
+	err := r.db.First(&export, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *repository) FindByUserID(userID uint) ([]DataExport, error) {
+	var exports []DataExport
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&exports).Error
+	return exports, err
+}
+
+func (r *repository) FindPendingByUserID(userID uint) (*DataExport, error) {
+	var export DataExport
+	err := r.db.Where("user_id = ? AND status = ?", userID, StatusPending).First(&export).Error
+	if err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+func (r *repository) FindPendingIDs() ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&DataExport{}).Where("status = ?", StatusPending).Order("id").Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *repository) MarkReady(export *DataExport) error {
+	result := r.db.Model(&DataExport{}).
+		Where("id = ? AND status = ?", export.ID, StatusPending).
+		Updates(map[string]interface{}{
+			"status":      StatusReady,
+			"storage_key": export.StorageKey,
+			"size":        export.Size,
+			"expires_at":  export.ExpiresAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *repository) MarkFailed(id uint, expiresAt time.Time) error {
+	return r.db.Model(&DataExport{}).
+		Where("id = ? AND status = ?", id, StatusPending).
+		Updates(map[string]interface{}{
+			"status":     StatusFailed,
+			"expires_at": expiresAt,
+		}).Error
+}
+
+func (r *repository) FindExpired(now time.Time) ([]DataExport, error) {
+	var exports []DataExport
+	err := r.db.Where("expires_at IS NOT NULL AND expires_at <= ?", now).Find(&exports).Error
+	return exports, err
+}
+
+func (r *repository) Delete(id uint) error {
+	return r.db.Delete(&DataExport{}, id).Error
+}
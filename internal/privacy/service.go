@@ -0,0 +1,343 @@
+package privacy
+
+import (
+	"archive/zip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/ardipermana59/go-template/pkg/storage"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	// RequestExport schedules an archive of the user's data. While one is
+	// being built, requesting another returns the pending one.
+	RequestExport(userID uint) (*ExportResponse, apperror.AppErrors)
+	GetExport(id, userID uint) (*ExportResponse, apperror.AppErrors)
+	// OpenExport returns the archive of a ready export. The caller closes
+	// the reader.
+	OpenExport(ctx context.Context, id, userID uint) (io.ReadCloser, *ExportResponse, apperror.AppErrors)
+	// ProcessExport builds the archive of a pending export. It runs as an
+	// ExportJob.
+	ProcessExport(ctx context.Context, id uint) error
+
+	// RequestDeletion disables the account and schedules its erasure once
+	// the grace period has passed.
+	RequestDeletion(userID uint, dto user.DeleteAccountDTO) (*DeletionResponse, apperror.AppErrors)
+	// Sweep erases accounts whose grace period has ended, removes expired
+	// archives and queues pending exports that lost their job. It runs as a
+	// recurring SweepJob.
+	Sweep(ctx context.Context) error
+}
+
+type service struct {
+	repo        Repository
+	storage     storage.Storage
+	jobs        job.Enqueuer
+	userService user.Service
+	postService post.Service
+	policy      string
+	grace       time.Duration
+	exportTTL   time.Duration
+	sections    []section
+}
+
+func NewService(repo Repository, store storage.Storage, jobs job.Enqueuer, userService user.Service, postService post.Service,
+	policy string, grace, exportTTL time.Duration, opts ...Option) Service {
+	s := &service{
+		repo:        repo,
+		storage:     store,
+		jobs:        jobs,
+		userService: userService,
+		postService: postService,
+		policy:      policy,
+		grace:       grace,
+		exportTTL:   exportTTL,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// CheckPolicy returns an error for unknown erasure policies.
+func CheckPolicy(policy string) error {
+	if policy != PolicyAnonymize && policy != PolicyDelete {
+		return fmt.Errorf("unsupported erasure policy %q", policy)
+	}
+	return nil
+}
+
+func (s *service) RequestExport(userID uint) (*ExportResponse, apperror.AppErrors) {
+	pending, err := s.repo.FindPendingByUserID(userID)
+	if err == nil {
+		return pending.ToResponse(), nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	export := &DataExport{UserID: userID, Status: StatusPending}
+	if err := s.repo.Create(export); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	s.enqueueExport(export.ID)
+
+	return export.ToResponse(), nil
+}
+
+func (s *service) GetExport(id, userID uint) (*ExportResponse, apperror.AppErrors) {
+	export, appErr := s.findExport(id, userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return export.ToResponse(), nil
+}
+
+func (s *service) OpenExport(ctx context.Context, id, userID uint) (io.ReadCloser, *ExportResponse, apperror.AppErrors) {
+	export, appErr := s.findExport(id, userID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	if export.Status != StatusReady {
+		return nil, nil, apperror.ExportNotReady()
+	}
+
+	reader, err := s.storage.Open(ctx, export.StorageKey)
+	if err == storage.ErrNotFound {
+		return nil, nil, apperror.ExportNotFound()
+	}
+	if err != nil {
+		return nil, nil, apperror.StorageError(err)
+	}
+	return reader, export.ToResponse(), nil
+}
+
+// findExport loads an export of userID. Exports of other users are reported
+// as not found.
+func (s *service) findExport(id, userID uint) (*DataExport, apperror.AppErrors) {
+	export, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.ExportNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if export.UserID != userID {
+		return nil, apperror.ExportNotFound()
+	}
+	return export, nil
+}
+
+// enqueueExport queues the build of an export. A failure is only logged:
+// the export stays pending and the next sweep queues it again.
+func (s *service) enqueueExport(id uint) {
+	_, err := s.jobs.Enqueue(ExportJob{ExportID: id}, job.Unique(fmt.Sprintf("privacy.export:%d", id)))
+	if err != nil && err != job.ErrDuplicate {
+		log.Printf("privacy: failed to queue export %d: %v", id, err)
+	}
+}
+
+func (s *service) ProcessExport(ctx context.Context, id uint) error {
+	export, err := s.repo.FindByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if export.Status != StatusPending {
+		return nil
+	}
+
+	if err := s.build(ctx, export); err != nil {
+		if markErr := s.repo.MarkFailed(export.ID, time.Now().Add(s.exportTTL)); markErr != nil {
+			log.Printf("privacy: failed to mark export %d as failed: %v", export.ID, markErr)
+		}
+		return fmt.Errorf("privacy: building export %d: %w", export.ID, err)
+	}
+	return nil
+}
+
+// build writes the archive to a temporary file, so its size is known before
+// it is stored, and records it on the export.
+func (s *service) build(ctx context.Context, export *DataExport) error {
+	file, err := os.CreateTemp("", "data-export-*.zip")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	if err := s.writeArchive(file, export.UserID); err != nil {
+		return err
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return err
+	}
+	key := fmt.Sprintf("exports/%d/%s.zip", export.UserID, hex.EncodeToString(token))
+	if err := s.storage.Put(ctx, key, file, size, "application/zip"); err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(s.exportTTL)
+	export.StorageKey = key
+	export.Size = size
+	export.ExpiresAt = &expiresAt
+	if err := s.repo.MarkReady(export); err != nil {
+		s.storage.Delete(ctx, key)
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *service) writeArchive(w io.Writer, userID uint) error {
+	archive := zip.NewWriter(w)
+
+	info := manifest{UserID: userID, GeneratedAt: time.Now().UTC()}
+	for _, section := range s.sections {
+		data, err := section.exporter.ExportUserData(userID)
+		if err != nil {
+			return fmt.Errorf("section %s: %w", section.name, err)
+		}
+		name := section.name + ".json"
+		if err := writeJSON(archive, name, data); err != nil {
+			return err
+		}
+		info.Files = append(info.Files, name)
+	}
+	if err := writeJSON(archive, "manifest.json", info); err != nil {
+		return err
+	}
+
+	return archive.Close()
+}
+
+func writeJSON(archive *zip.Writer, name string, data interface{}) error {
+	w, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(data)
+}
+
+func (s *service) RequestDeletion(userID uint, dto user.DeleteAccountDTO) (*DeletionResponse, apperror.AppErrors) {
+	deleteAt := time.Now().Add(s.grace).UTC().Truncate(time.Second)
+	if appErr := s.userService.ScheduleDeletion(userID, dto, deleteAt); appErr != nil {
+		return nil, appErr
+	}
+	return &DeletionResponse{DeleteAt: deleteAt, Policy: s.policy}, nil
+}
+
+func (s *service) Sweep(ctx context.Context) error {
+	now := time.Now()
+
+	ids, appErr := s.userService.DueForDeletion(now)
+	if appErr != nil {
+		return fmt.Errorf("privacy: loading accounts due for deletion: %w", errorOf(appErr))
+	}
+	for _, id := range ids {
+		if err := s.erase(ctx, id); err != nil {
+			log.Printf("privacy: failed to erase user %d: %v", id, err)
+		}
+	}
+
+	expired, err := s.repo.FindExpired(now)
+	if err != nil {
+		return fmt.Errorf("privacy: loading expired exports: %w", err)
+	}
+	for i := range expired {
+		if err := s.removeExport(ctx, &expired[i]); err != nil {
+			log.Printf("privacy: failed to remove export %d: %v", expired[i].ID, err)
+		}
+	}
+
+	pending, err := s.repo.FindPendingIDs()
+	if err != nil {
+		return fmt.Errorf("privacy: loading pending exports: %w", err)
+	}
+	for _, id := range pending {
+		s.enqueueExport(id)
+	}
+	return nil
+}
+
+// erase applies the erasure policy to the account. Archives are removed
+// first because they hold a copy of everything else.
+func (s *service) erase(ctx context.Context, userID uint) error {
+	exports, err := s.repo.FindByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for i := range exports {
+		if err := s.removeExport(ctx, &exports[i]); err != nil {
+			return err
+		}
+	}
+
+	if s.policy == PolicyAnonymize {
+		return errorOf(s.userService.AnonymizeUser(userID))
+	}
+
+	// Posts are deleted one by one so their delete hooks also remove
+	// attachments and cover images from storage.
+	var postIDs []uint
+	err = s.postService.ExportPosts(userID, func(posts []post.PostResponse) error {
+		for _, p := range posts {
+			postIDs = append(postIDs, p.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, id := range postIDs {
+		if err := errorOf(s.postService.DeletePost(id, userID)); err != nil {
+			return err
+		}
+	}
+
+	return errorOf(s.userService.DeleteUser(userID))
+}
+
+func (s *service) removeExport(ctx context.Context, export *DataExport) error {
+	if export.StorageKey != "" {
+		if err := s.storage.Delete(ctx, export.StorageKey); err != nil {
+			return err
+		}
+	}
+	return s.repo.Delete(export.ID)
+}
+
+// errorOf converts AppErrors returned by other services for logging.
+func errorOf(appErr apperror.AppErrors) error {
+	if appErr == nil {
+		return nil
+	}
+	return fmt.Errorf("%s: %s", appErr[0].Field, appErr[0].Message)
+}
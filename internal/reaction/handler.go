@@ -0,0 +1,66 @@
+package reaction
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) React(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	summary, appErr := h.service.React(uint(postID), userID, c.Param("type"))
+	if appErr != nil {
+		switch {
+		case appErr.Has("post"):
+			response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		case appErr.Has("type"):
+			response.Error(c, http.StatusBadRequest, "Failed to add reaction", appErr)
+		default:
+			response.InternalError(c, nil)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reaction added successfully", summary)
+}
+
+func (h *Handler) Unreact(c *gin.Context) {
+	userID := c.GetUint("user_id")
+	postID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	summary, appErr := h.service.Unreact(uint(postID), userID, c.Param("type"))
+	if appErr != nil {
+		switch {
+		case appErr.Has("post"):
+			response.Error(c, http.StatusNotFound, "Post not found", appErr)
+		case appErr.Has("type"):
+			response.Error(c, http.StatusBadRequest, "Failed to remove reaction", appErr)
+		default:
+			response.InternalError(c, nil)
+		}
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Reaction removed successfully", summary)
+}
@@ -0,0 +1,25 @@
+package reaction
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+type Reaction struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;uniqueIndex:idx_reactions_post_user_type"`
+	Post      post.Post `json:"-" gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE"`
+	UserID    uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_reactions_post_user_type;index"`
+	User      user.User `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Type      string    `json:"type" gorm:"size:20;not null;uniqueIndex:idx_reactions_post_user_type"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SummaryResponse is returned after a reaction was added or removed.
+type SummaryResponse struct {
+	PostID      uint             `json:"post_id"`
+	Reactions   map[string]int64 `json:"reactions"`
+	MyReactions []string         `json:"my_reactions"`
+}
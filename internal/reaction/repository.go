@@ -0,0 +1,102 @@
+package reaction
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	Add(reaction *Reaction) error
+	Remove(postID, userID uint, reactionType string) error
+	DeleteByPostID(postID uint) error
+	CountByPostIDs(postIDs []uint) (map[uint]map[string]int64, error)
+	FindTypesByUser(postIDs []uint, userID uint) (map[uint][]string, error)
+	FindByUserID(userID uint) ([]Reaction, error)
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+// Add stores the reaction unless the user already reacted to the post with
+// the same type, which makes repeated calls harmless.
+func (r *repository) Add(reaction *Reaction) error {
+	return r.db.Omit(clause.Associations).
+		Clauses(clause.OnConflict{DoNothing: true}).
+		Create(reaction).Error
+}
+
+func (r *repository) Remove(postID, userID uint, reactionType string) error {
+	return r.db.
+		Where("post_id = ? AND user_id = ? AND type = ?", postID, userID, reactionType).
+		Delete(&Reaction{}).Error
+}
+
+func (r *repository) DeleteByPostID(postID uint) error {
+	return r.db.Where("post_id = ?", postID).Delete(&Reaction{}).Error
+}
+
+func (r *repository) CountByPostIDs(postIDs []uint) (map[uint]map[string]int64, error) {
+	counts := make(map[uint]map[string]int64, len(postIDs))
+	if len(postIDs) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		PostID uint
+		Type   string
+		Count  int64
+	}
+	err := r.db.Model(&Reaction{}).
+		Select("post_id, type, COUNT(*) AS count").
+		Where("post_id IN ?", postIDs).
+		Group("post_id, type").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		if counts[row.PostID] == nil {
+			counts[row.PostID] = make(map[string]int64)
+		}
+		counts[row.PostID][row.Type] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *repository) FindTypesByUser(postIDs []uint, userID uint) (map[uint][]string, error) {
+	types := make(map[uint][]string, len(postIDs))
+	if len(postIDs) == 0 || userID == 0 {
+		return types, nil
+	}
+
+	var reactions []Reaction
+	err := r.db.Select("post_id, type").
+		Where("post_id IN ? AND user_id = ?", postIDs, userID).
+		Order("type").
+		Find(&reactions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, reaction := range reactions {
+		types[reaction.PostID] = append(types[reaction.PostID], reaction.Type)
+	}
+	return types, nil
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) FindByUserID(userID uint) ([]Reaction, error) {
+	var reactions []Reaction
+	err := r.db.Where("user_id = ?", userID).Order("id").Find(&reactions).Error
+	return reactions, err
+}
@@ -0,0 +1,155 @@
+package reaction
+
+import (
+	"log"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/notification"
+	"github.com/ardipermana59/go-template/internal/post"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	React(postID, userID uint, reactionType string) (*SummaryResponse, apperror.AppErrors)
+	Unreact(postID, userID uint, reactionType string) (*SummaryResponse, apperror.AppErrors)
+
+	// EnrichPosts fills in PostResponse.Reactions and PostResponse.MyReactions
+	// using two queries regardless of the number of posts.
+	EnrichPosts(posts []*post.PostResponse, viewerID uint) error
+	// DeletePostReactions is registered as a post.DeleteHook.
+	DeletePostReactions(tx *gorm.DB, p *post.Post) error
+	ExportUserData(userID uint) (interface{}, error)
+}
+
+type service struct {
+	repo          Repository
+	postRepo      post.Repository
+	notifications notification.Service
+	types         []string
+}
+
+func NewService(repo Repository, postRepo post.Repository, notificationService notification.Service, types []string) Service {
+	return &service{
+		repo:          repo,
+		postRepo:      postRepo,
+		notifications: notificationService,
+		types:         types,
+	}
+}
+
+func (s *service) React(postID, userID uint, reactionType string) (*SummaryResponse, apperror.AppErrors) {
+	p, appErr := s.validate(postID, userID, reactionType)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	reaction := &Reaction{
+		PostID: postID,
+		UserID: userID,
+		Type:   reactionType,
+	}
+	if err := s.repo.Add(reaction); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	// The author is notified once per user, whichever reactions they add.
+	if err := s.notifications.Notify(&notification.Notification{
+		UserID:  p.UserID,
+		Type:    notification.TypeReaction,
+		ActorID: userID,
+		PostID:  &postID,
+	}); err != nil {
+		log.Printf("reaction: failed to notify user %d: %v", p.UserID, err)
+	}
+
+	return s.summary(postID, userID)
+}
+
+func (s *service) Unreact(postID, userID uint, reactionType string) (*SummaryResponse, apperror.AppErrors) {
+	if _, appErr := s.validate(postID, userID, reactionType); appErr != nil {
+		return nil, appErr
+	}
+
+	if err := s.repo.Remove(postID, userID, reactionType); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return s.summary(postID, userID)
+}
+
+func (s *service) EnrichPosts(posts []*post.PostResponse, viewerID uint) error {
+	ids := make([]uint, 0, len(posts))
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+	}
+
+	counts, err := s.repo.CountByPostIDs(ids)
+	if err != nil {
+		return err
+	}
+
+	mine, err := s.repo.FindTypesByUser(ids, viewerID)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range posts {
+		p.Reactions = counts[p.ID]
+		if p.Reactions == nil {
+			p.Reactions = map[string]int64{}
+		}
+		p.MyReactions = mine[p.ID]
+		if p.MyReactions == nil {
+			p.MyReactions = []string{}
+		}
+	}
+	return nil
+}
+
+func (s *service) DeletePostReactions(tx *gorm.DB, p *post.Post) error {
+	return s.repo.WithTx(tx).DeleteByPostID(p.ID)
+}
+
+// validate checks the reaction type and loads the post, which must be
+// visible to userID.
+func (s *service) validate(postID, userID uint, reactionType string) (*post.Post, apperror.AppErrors) {
+	allowed := false
+	for _, t := range s.types {
+		if t == reactionType {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, apperror.InvalidReactionType(s.types)
+	}
+
+	p, err := s.postRepo.FindByID(postID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.PostNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if !p.VisibleTo(userID) {
+		return nil, apperror.PostNotFound()
+	}
+
+	return p, nil
+}
+
+func (s *service) summary(postID, userID uint) (*SummaryResponse, apperror.AppErrors) {
+	response := &post.PostResponse{ID: postID}
+	if err := s.EnrichPosts([]*post.PostResponse{response}, userID); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return &SummaryResponse{
+		PostID:      postID,
+		Reactions:   response.Reactions,
+		MyReactions: response.MyReactions,
+	}, nil
+}
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	return s.repo.FindByUserID(userID)
+}
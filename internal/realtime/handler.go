@@ -0,0 +1,227 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// retryMillis is the reconnect delay suggested to EventSource clients.
+	retryMillis = 3000
+	// writeWait bounds each write to a stream. Streams outlive the server's
+	// read and write timeouts, which are meant for ordinary requests, so
+	// they are lifted and a client that stops reading is noticed here
+	// instead.
+	writeWait = 10 * time.Second
+)
+
+type Handler struct {
+	hub       *Hub
+	heartbeat time.Duration
+}
+
+func NewHandler(hub *Hub, heartbeat time.Duration) *Handler {
+	return &Handler{
+		hub:       hub,
+		heartbeat: heartbeat,
+	}
+}
+
+// Stream serves events as Server-Sent Events. EventSource clients resume
+// through the Last-Event-ID header they send when reconnecting.
+func (h *Handler) Stream(c *gin.Context) {
+	sub, missed, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(time.Time{})
+	// send writes and flushes within writeWait.
+	send := func(write func(w io.Writer)) bool {
+		_ = controller.SetWriteDeadline(time.Now().Add(writeWait))
+		write(c.Writer)
+		return controller.Flush() == nil
+	}
+
+	ok = send(func(w io.Writer) {
+		fmt.Fprintf(w, "retry: %d\n\n", retryMillis)
+		for _, event := range missed {
+			writeEvent(w, event)
+		}
+	})
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case event, open := <-sub.Events():
+			// A closed subscription fell behind; the client reconnects and
+			// resumes from the replay buffer.
+			if !open {
+				return
+			}
+			if !send(func(w io.Writer) { writeEvent(w, event) }) {
+				return
+			}
+		case <-ticker.C:
+			if !send(func(w io.Writer) { fmt.Fprint(w, ": heartbeat\n\n") }) {
+				return
+			}
+		}
+	}
+}
+
+func writeEvent(w io.Writer, event Event) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+// WebSocket serves events as JSON text messages. Clients resume by passing
+// the ID of the last event they received in last_event_id.
+func (h *Handler) WebSocket(c *gin.Context) {
+	sub, missed, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer h.hub.Unsubscribe(sub)
+
+	server := websocket.Server{
+		// The connection is authenticated with a token rather than cookies,
+		// so pages on other origins cannot open it on a user's behalf.
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(conn *websocket.Conn) {
+			defer conn.Close()
+			h.serveWebSocket(conn, sub, missed)
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+func (h *Handler) serveWebSocket(conn *websocket.Conn, sub *Subscription, missed []Event) {
+	// The hijacked connection keeps the deadlines the server set for the
+	// upgrade request.
+	_ = conn.SetReadDeadline(time.Time{})
+	send := func(event Event) bool {
+		_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+		return websocket.JSON.Send(conn, event) == nil
+	}
+
+	// Clients are not expected to send anything; reading notices when they
+	// close the connection.
+	closed := make(chan struct{})
+	go func() {
+		var discard []byte
+		for websocket.Message.Receive(conn, &discard) == nil {
+		}
+		close(closed)
+	}()
+
+	for _, event := range missed {
+		if !send(event) {
+			return
+		}
+	}
+
+	ticker := time.NewTicker(h.heartbeat)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-closed:
+			return
+		case event, open := <-sub.Events():
+			if !open {
+				return
+			}
+			if !send(event) {
+				return
+			}
+		case <-ticker.C:
+			heartbeat := Event{Type: EventHeartbeat, Topics: []string{}, Time: time.Now()}
+			if !send(heartbeat) {
+				return
+			}
+		}
+	}
+}
+
+// subscribe validates the topics and resume position of a stream request
+// and subscribes to the hub. It answers invalid requests itself.
+func (h *Handler) subscribe(c *gin.Context) (*Subscription, []Event, bool) {
+	topics, appErr := parseTopics(c.Query("topics"), c.GetUint("user_id"))
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid stream request", appErr)
+		return nil, nil, false
+	}
+
+	value := c.GetHeader("Last-Event-ID")
+	if value == "" {
+		value = c.Query("last_event_id")
+	}
+	var lastEventID uint64
+	if value != "" {
+		var err error
+		if lastEventID, err = strconv.ParseUint(value, 10, 64); err != nil {
+			response.Error(c, http.StatusBadRequest, "Invalid stream request", apperror.InvalidLastEventID())
+			return nil, nil, false
+		}
+	}
+
+	sub, missed := h.hub.Subscribe(topics, lastEventID)
+	return sub, missed, true
+}
+
+// parseTopics resolves the comma separated topics of a stream request:
+// "posts" for all public posts, "user:<id>" for the posts of one author and
+// "notifications" for the requesting user's own notifications. Without
+// topics a client receives posts and its notifications.
+func parseTopics(value string, userID uint) ([]string, apperror.AppErrors) {
+	if strings.TrimSpace(value) == "" {
+		return []string{TopicPosts, NotificationTopic(userID)}, nil
+	}
+
+	var topics []string
+	for _, topic := range strings.Split(value, ",") {
+		topic = strings.TrimSpace(topic)
+		switch {
+		case topic == TopicPosts:
+			topics = append(topics, TopicPosts)
+		case topic == "notifications":
+			topics = append(topics, NotificationTopic(userID))
+		case strings.HasPrefix(topic, "user:"):
+			id, err := strconv.ParseUint(strings.TrimPrefix(topic, "user:"), 10, 32)
+			if err != nil || id == 0 {
+				return nil, apperror.InvalidStreamTopic(topic)
+			}
+			topics = append(topics, UserTopic(uint(id)))
+		default:
+			return nil, apperror.InvalidStreamTopic(topic)
+		}
+	}
+	return topics, nil
+}
@@ -0,0 +1,192 @@
+package realtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// TopicPosts carries the events of every public post.
+	TopicPosts = "posts"
+
+	// EventReset replaces the replay when the events a client missed are no
+	// longer buffered. The client has to reload its state.
+	EventReset = "reset"
+	// EventHeartbeat keeps idle WebSocket connections open. SSE streams use
+	// comments instead.
+	EventHeartbeat = "heartbeat"
+
+	// subscriberBuffer is the number of events a subscriber may fall
+	// behind before it is dropped.
+	subscriberBuffer = 64
+)
+
+// UserTopic carries the post events of one author.
+func UserTopic(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+// NotificationTopic carries the notifications of one user. Only that user
+// may subscribe to it.
+func NotificationTopic(userID uint) string {
+	return fmt.Sprintf("notifications:%d", userID)
+}
+
+// Event is a change delivered to subscribers of any of its topics. IDs
+// increase by one per event and are only unique within one process.
+type Event struct {
+	ID     uint64      `json:"id"`
+	Type   string      `json:"type"`
+	Topics []string    `json:"topics"`
+	Data   interface{} `json:"data"`
+	Time   time.Time   `json:"time"`
+}
+
+// Subscription receives the events of its topics until it is closed.
+type Subscription struct {
+	topics map[string]bool
+	events chan Event
+}
+
+// Events is closed when the subscription ends, either through Unsubscribe
+// or because the subscriber fell too far behind.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+func (s *Subscription) matches(event Event) bool {
+	for _, topic := range event.Topics {
+		if s.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// Hub is an in-process publish/subscribe hub. It keeps the latest events in
+// a bounded buffer so clients can resume after a reconnect.
+type Hub struct {
+	mu          sync.Mutex
+	lastID      uint64
+	replay      []Event
+	replaySize  int
+	subscribers map[*Subscription]bool
+	closed      bool
+}
+
+func NewHub(replaySize int) *Hub {
+	return &Hub{
+		replaySize:  replaySize,
+		subscribers: make(map[*Subscription]bool),
+	}
+}
+
+// Publish delivers an event to the current subscribers without blocking.
+// Subscribers whose buffer is full are dropped; they can resume from the
+// replay buffer.
+func (h *Hub) Publish(eventType string, topics []string, data interface{}) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{
+		ID:     h.lastID,
+		Type:   eventType,
+		Topics: topics,
+		Data:   data,
+		Time:   time.Now(),
+	}
+
+	if h.replaySize > 0 {
+		if len(h.replay) == h.replaySize {
+			h.replay = append(h.replay[:0], h.replay[1:]...)
+		}
+		h.replay = append(h.replay, event)
+	}
+
+	for sub := range h.subscribers {
+		if !sub.matches(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+	return event
+}
+
+// Subscribe registers a subscriber for topics. With a non-zero lastEventID
+// the buffered events after it are returned for replay, or a single
+// EventReset carrying the latest ID when some of them are no longer buffered.
+func (h *Hub) Subscribe(topics []string, lastEventID uint64) (*Subscription, []Event) {
+	sub := &Subscription{
+		topics: make(map[string]bool, len(topics)),
+		events: make(chan Event, subscriberBuffer),
+	}
+	for _, topic := range topics {
+		sub.topics[topic] = true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []Event
+	if lastEventID != 0 {
+		if h.covers(lastEventID) {
+			for _, event := range h.replay {
+				if event.ID > lastEventID && sub.matches(event) {
+					missed = append(missed, event)
+				}
+			}
+		} else {
+			missed = []Event{{ID: h.lastID, Type: EventReset, Topics: []string{}, Time: time.Now()}}
+		}
+	}
+
+	if h.closed {
+		close(sub.events)
+		return sub, missed
+	}
+	h.subscribers[sub] = true
+	return sub, missed
+}
+
+// covers reports whether every event after id is still buffered. IDs from
+// the future belong to an earlier process.
+func (h *Hub) covers(id uint64) bool {
+	if id > h.lastID {
+		return false
+	}
+	if id == h.lastID {
+		return true
+	}
+	return len(h.replay) > 0 && h.replay[0].ID <= id+1
+}
+
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.remove(sub)
+}
+
+// Close ends every subscription, and the ones made afterwards right away, so
+// open streams finish when the server shuts down. Clients reconnect and
+// resume elsewhere.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for sub := range h.subscribers {
+		h.remove(sub)
+	}
+}
+
+func (h *Hub) remove(sub *Subscription) {
+	if h.subscribers[sub] {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
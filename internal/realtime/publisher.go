@@ -0,0 +1,29 @@
+package realtime
+
+import (
+	"github.com/ardipermana59/go-template/internal/notification"
+	"github.com/ardipermana59/go-template/internal/post"
+)
+
+const EventNotificationCreated = "notification.created"
+
+// DeletedPost is the data of a post.deleted event.
+type DeletedPost struct {
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id"`
+}
+
+// PublishPostEvent implements post.EventPublisher. Post events go to the
+// posts topic and to the topic of their author.
+func (h *Hub) PublishPostEvent(eventType string, p *post.PostResponse) {
+	var data interface{} = p
+	if eventType == post.EventPostDeleted {
+		data = DeletedPost{ID: p.ID, UserID: p.UserID}
+	}
+	h.Publish(eventType, []string{TopicPosts, UserTopic(p.UserID)}, data)
+}
+
+// PublishNotification implements notification.Publisher.
+func (h *Hub) PublishNotification(userID uint, n *notification.NotificationResponse) {
+	h.Publish(EventNotificationCreated, []string{NotificationTopic(userID)}, n)
+}
@@ -0,0 +1,108 @@
+package syndication
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+	appURL  string
+}
+
+func NewHandler(service Service, appURL string) *Handler {
+	return &Handler{service: service, appURL: appURL}
+}
+
+func (h *Handler) PostsRSS(c *gin.Context) {
+	feed, appErr := h.service.PostsFeed(h.selfURL(c))
+	if appErr != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to build feed", appErr)
+		return
+	}
+
+	h.write(c, feed, (*Feed).RSS, RSSContentType)
+}
+
+func (h *Handler) PostsAtom(c *gin.Context) {
+	feed, appErr := h.service.PostsFeed(h.selfURL(c))
+	if appErr != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to build feed", appErr)
+		return
+	}
+
+	h.write(c, feed, (*Feed).Atom, AtomContentType)
+}
+
+func (h *Handler) UserAtom(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("user_id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	feed, appErr := h.service.UserFeed(uint(userID), h.selfURL(c))
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "User not found", appErr)
+		return
+	}
+
+	h.write(c, feed, (*Feed).Atom, AtomContentType)
+}
+
+func (h *Handler) selfURL(c *gin.Context) string {
+	return h.appURL + c.Request.URL.Path
+}
+
+// write renders the feed and answers conditional requests. If-None-Match is
+// checked against a hash of the document, so deleted posts also produce a
+// new ETag; If-Modified-Since is only consulted when no ETag was sent.
+func (h *Handler) write(c *gin.Context, feed *Feed, render func(*Feed) ([]byte, error), contentType string) {
+	body, err := render(feed)
+	if err != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to build feed",
+			apperror.NewErrors(apperror.NewError("feed", err.Error())))
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	lastModified := feed.Updated.UTC().Truncate(time.Second)
+
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", lastModified.Format(http.TimeFormat))
+	c.Header("Cache-Control", "public, max-age=300")
+
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if etagMatches(match, etag) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	} else if since, err := http.ParseTime(c.GetHeader("If-Modified-Since")); err == nil {
+		if !lastModified.After(since) {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// etagMatches implements the weak comparison used for If-None-Match.
+func etagMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
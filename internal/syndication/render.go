@@ -0,0 +1,87 @@
+package syndication
+
+import (
+	"bytes"
+	"encoding/xml"
+	"time"
+)
+
+const (
+	AtomContentType = "application/atom+xml; charset=utf-8"
+	RSSContentType  = "application/rss+xml; charset=utf-8"
+)
+
+// Atom renders the feed as an RFC 4287 Atom document.
+func (f *Feed) Atom() ([]byte, error) {
+	doc := atomFeed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Updated:  f.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.SelfURL},
+			{Rel: "alternate", Href: f.Link},
+		},
+		Author: &atomPerson{Name: f.Author},
+	}
+
+	for _, e := range f.Entries {
+		entry := atomEntry{
+			ID:        e.ID,
+			Title:     e.Title,
+			Updated:   e.Updated.UTC().Format(time.RFC3339),
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Links:     []atomLink{{Rel: "alternate", Href: e.Link}},
+			Author:    &atomPerson{Name: e.Author},
+			Content:   atomContent{Type: "html", Body: e.ContentHTML},
+		}
+		for _, category := range e.Categories {
+			entry.Categories = append(entry.Categories, atomCategory{Term: category})
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return marshal(doc)
+}
+
+// RSS renders the feed as an RSS 2.0 document.
+func (f *Feed) RSS() ([]byte, error) {
+	doc := rssDocument{
+		Version: "2.0",
+		AtomNS:  "http://www.w3.org/2005/Atom",
+		DCNS:    "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          f.Link,
+			Description:   f.Subtitle,
+			LastBuildDate: f.Updated.UTC().Format(time.RFC1123Z),
+			AtomLink:      rssAtomLink{Href: f.SelfURL, Rel: "self", Type: "application/rss+xml"},
+		},
+	}
+
+	for _, e := range f.Entries {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{IsPermaLink: false, Value: e.ID},
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+			Creator:     e.Author,
+			Categories:  e.Categories,
+			Description: e.ContentHTML,
+		})
+	}
+
+	return marshal(doc)
+}
+
+func marshal(doc interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	encoder := xml.NewEncoder(&buf)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package syndication
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+type Service interface {
+	// PostsFeed describes the latest posts of all authors. selfURL is the
+	// address the feed is being served from.
+	PostsFeed(selfURL string) (*Feed, apperror.AppErrors)
+	// UserFeed describes the latest posts of a single author.
+	UserFeed(userID uint, selfURL string) (*Feed, apperror.AppErrors)
+}
+
+type service struct {
+	postService post.Service
+	userService user.Service
+	appName     string
+	appURL      string
+	limit       int
+}
+
+func NewService(postService post.Service, userService user.Service, appName, appURL string, limit int) Service {
+	return &service{
+		postService: postService,
+		userService: userService,
+		appName:     appName,
+		appURL:      appURL,
+		limit:       limit,
+	}
+}
+
+func (s *service) PostsFeed(selfURL string) (*Feed, apperror.AppErrors) {
+	posts, appErr := s.postService.GetLatestPosts(0, s.limit)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	feed := &Feed{
+		ID:       s.appURL + "/api/v1/posts",
+		Title:    s.appName,
+		Subtitle: "Latest posts on " + s.appName,
+		Link:     s.appURL + "/api/v1/posts",
+		SelfURL:  selfURL,
+		Author:   s.appName,
+	}
+	s.addEntries(feed, posts)
+	return feed, nil
+}
+
+func (s *service) UserFeed(userID uint, selfURL string) (*Feed, apperror.AppErrors) {
+	author, appErr := s.userService.GetUserByID(userID)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	posts, appErr := s.postService.GetLatestPosts(userID, s.limit)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	link := fmt.Sprintf("%s/api/v1/users/%d/posts", s.appURL, userID)
+	feed := &Feed{
+		ID:       link,
+		Title:    author.Name + " - " + s.appName,
+		Subtitle: "Latest posts by " + author.Name,
+		Link:     link,
+		SelfURL:  selfURL,
+		Author:   author.Name,
+		Updated:  author.CreatedAt,
+	}
+	s.addEntries(feed, posts)
+	return feed, nil
+}
+
+// addEntries converts posts to entries and moves the feed's updated time
+// forward to the most recently changed post.
+func (s *service) addEntries(feed *Feed, posts []post.PostResponse) {
+	for _, p := range posts {
+		entry := Entry{
+			ID:          fmt.Sprintf("%s/api/v1/posts/%d", s.appURL, p.ID),
+			Title:       p.Title,
+			Link:        s.appURL + "/api/v1/posts/by-slug/" + p.Slug,
+			ContentHTML: p.ContentHTML,
+			Published:   p.CreatedAt,
+			Updated:     p.UpdatedAt,
+		}
+		if p.User != nil {
+			entry.Author = p.User.Name
+		}
+		if p.Category != nil {
+			entry.Categories = append(entry.Categories, p.Category.Name)
+		}
+		for _, tag := range p.Tags {
+			entry.Categories = append(entry.Categories, tag.Name)
+		}
+		if entry.Updated.Before(entry.Published) {
+			entry.Updated = entry.Published
+		}
+
+		if entry.Updated.After(feed.Updated) {
+			feed.Updated = entry.Updated
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+
+	if feed.Updated.IsZero() {
+		feed.Updated = time.Unix(0, 0)
+	}
+}
@@ -0,0 +1,130 @@
+package taxonomy
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetTags(c *gin.Context) {
+	tags, appErr := h.service.GetTags()
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tags retrieved successfully", tags)
+}
+
+func (h *Handler) RenameTag(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var dto RenameTagDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	tag, appErr := h.service.RenameTag(uint(id), dto)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to rename tag", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tag renamed successfully", tag)
+}
+
+func (h *Handler) MergeTags(c *gin.Context) {
+	var dto MergeTagsDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	tag, appErr := h.service.MergeTags(dto)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to merge tags", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Tags merged successfully", tag)
+}
+
+func (h *Handler) GetCategories(c *gin.Context) {
+	categories, appErr := h.service.GetCategoryTree()
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Categories retrieved successfully", categories)
+}
+
+func (h *Handler) CreateCategory(c *gin.Context) {
+	var dto CreateCategoryDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	category, appErr := h.service.CreateCategory(dto)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to create category", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Category created successfully", category)
+}
+
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	var dto UpdateCategoryDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	category, appErr := h.service.UpdateCategory(uint(id), dto)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update category", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category updated successfully", category)
+}
+
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	appErr := h.service.DeleteCategory(uint(id))
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "Failed to delete category", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Category deleted successfully", nil)
+}
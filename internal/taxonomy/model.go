@@ -0,0 +1,110 @@
+package taxonomy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/ardipermana59/go-template/pkg/slug"
+)
+
+// PostTagsTable is the join table between posts and tags.
+const PostTagsTable = "post_tags"
+
+// slugSize is the size of the slug columns of tags and categories.
+const slugSize = 64
+
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"size:50;not null"`
+	Slug      string    `json:"slug" gorm:"size:64;uniqueIndex;not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Category struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	Name      string     `json:"name" gorm:"size:50;not null"`
+	Slug      string     `json:"slug" gorm:"size:64;uniqueIndex;not null"`
+	ParentID  *uint      `json:"parent_id" gorm:"index"`
+	Parent    *Category  `json:"-" gorm:"foreignKey:ParentID;constraint:OnDelete:SET NULL"`
+	Children  []Category `json:"-" gorm:"foreignKey:ParentID"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TagUsage is a tag together with the number of posts using it.
+type TagUsage struct {
+	Tag
+	PostCount int64
+}
+
+type RenameTagDTO struct {
+	Name string `json:"name" binding:"required,min=1,max=50"`
+}
+
+type MergeTagsDTO struct {
+	SourceIDs []uint `json:"source_ids" binding:"required,min=1"`
+	TargetID  uint   `json:"target_id" binding:"required"`
+}
+
+type CreateCategoryDTO struct {
+	Name     string `json:"name" binding:"required,min=2,max=50"`
+	ParentID *uint  `json:"parent_id"`
+}
+
+// UpdateCategoryDTO keeps the parent when ParentID is omitted; Root moves
+// the category back to the top level.
+type UpdateCategoryDTO struct {
+	Name     string `json:"name" binding:"omitempty,min=2,max=50"`
+	ParentID *uint  `json:"parent_id"`
+	Root     bool   `json:"root" binding:"excluded_with=ParentID"`
+}
+
+type TagResponse struct {
+	ID        uint   `json:"id"`
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount *int64 `json:"post_count,omitempty"`
+}
+
+type CategoryResponse struct {
+	ID       uint               `json:"id"`
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	ParentID *uint              `json:"parent_id"`
+	Children []CategoryResponse `json:"children,omitempty"`
+}
+
+// NormalizeTagName trims the name, collapses inner whitespace and lowercases it.
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
+
+// Slug returns the slug of a tag or category name, cut to fit its column.
+func Slug(name string) string {
+	return slug.MakeMax(name, slugSize)
+}
+
+func (t *Tag) ToResponse() *TagResponse {
+	return &TagResponse{
+		ID:   t.ID,
+		Name: t.Name,
+		Slug: t.Slug,
+	}
+}
+
+func (u *TagUsage) ToResponse() *TagResponse {
+	response := u.Tag.ToResponse()
+	count := u.PostCount
+	response.PostCount = &count
+	return response
+}
+
+func (c *Category) ToResponse() *CategoryResponse {
+	return &CategoryResponse{
+		ID:       c.ID,
+		Name:     c.Name,
+		Slug:     c.Slug,
+		ParentID: c.ParentID,
+	}
+}
@@ -0,0 +1,156 @@
+package taxonomy
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	FindOrCreateTags(tags []Tag) ([]Tag, error)
+	FindTagsWithUsage() ([]TagUsage, error)
+	FindTagByID(id uint) (*Tag, error)
+	FindTagBySlug(slug string) (*Tag, error)
+	UpdateTag(tag *Tag) error
+	MergeTags(sourceIDs []uint, targetID uint) error
+
+	CreateCategory(category *Category) error
+	FindAllCategories() ([]Category, error)
+	FindCategoryByID(id uint) (*Category, error)
+	FindCategoryBySlug(slug string) (*Category, error)
+	UpdateCategory(category *Category) error
+	DeleteCategory(category *Category) error
+	WithTx(tx *gorm.DB) Repository
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) FindOrCreateTags(tags []Tag) ([]Tag, error) {
+	if len(tags) == 0 {
+		return []Tag{}, nil
+	}
+
+	slugs := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		slugs = append(slugs, tag.Slug)
+	}
+
+	err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&tags).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var found []Tag
+	err = r.db.Where("slug IN ?", slugs).Order("name").Find(&found).Error
+	return found, err
+}
+
+// FindTagsWithUsage only counts posts that are not hidden, like the tag
+// filter of GET /posts, and leaves out tags no such post uses.
+func (r *repository) FindTagsWithUsage() ([]TagUsage, error) {
+	var tags []TagUsage
+	err := r.db.Model(&Tag{}).
+		Select("tags.*, COUNT(posts.id) AS post_count").
+		Joins("JOIN " + PostTagsTable + " ON " + PostTagsTable + ".tag_id = tags.id").
+		Joins("JOIN posts ON posts.id = " + PostTagsTable + ".post_id AND posts.hidden_at IS NULL").
+		Group("tags.id").
+		Order("post_count DESC, tags.name").
+		Scan(&tags).Error
+	return tags, err
+}
+
+func (r *repository) FindTagByID(id uint) (*Tag, error) {
+	var tag Tag
+	err := r.db.First(&tag, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *repository) FindTagBySlug(slug string) (*Tag, error) {
+	var tag Tag
+	err := r.db.Where("slug = ?", slug).First(&tag).Error
+	if err != nil {
+		return nil, err
+	}
+	return &tag, nil
+}
+
+func (r *repository) UpdateTag(tag *Tag) error {
+	return r.db.Save(tag).Error
+}
+
+// MergeTags moves every post from the source tags onto the target tag and
+// removes the source tags, all in a single transaction.
+func (r *repository) MergeTags(sourceIDs []uint, targetID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("INSERT IGNORE INTO "+PostTagsTable+" (post_id, tag_id) "+
+			"SELECT post_id, ? FROM "+PostTagsTable+" WHERE tag_id IN ?", targetID, sourceIDs).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM "+PostTagsTable+" WHERE tag_id IN ?", sourceIDs).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(&Tag{}, sourceIDs).Error
+	})
+}
+
+func (r *repository) CreateCategory(category *Category) error {
+	return r.db.Create(category).Error
+}
+
+func (r *repository) FindAllCategories() ([]Category, error) {
+	var categories []Category
+	err := r.db.Order("name").Find(&categories).Error
+	return categories, err
+}
+
+func (r *repository) FindCategoryByID(id uint) (*Category, error) {
+	var category Category
+	err := r.db.First(&category, id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *repository) FindCategoryBySlug(slug string) (*Category, error) {
+	var category Category
+	err := r.db.Where("slug = ?", slug).First(&category).Error
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *repository) UpdateCategory(category *Category) error {
+	return r.db.Omit(clause.Associations).Save(category).Error
+}
+
+// DeleteCategory removes the category and re-attaches its children to its
+// parent so the rest of the tree stays intact.
+func (r *repository) DeleteCategory(category *Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&Category{}).
+			Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Delete(&Category{}, category.ID).Error
+	})
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
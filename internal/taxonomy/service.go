@@ -0,0 +1,311 @@
+package taxonomy
+
+import (
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"gorm.io/gorm"
+)
+
+type Service interface {
+	ResolveTags(tx *gorm.DB, names []string) ([]Tag, error)
+	GetTags() ([]TagResponse, apperror.AppErrors)
+	RenameTag(id uint, dto RenameTagDTO) (*TagResponse, apperror.AppErrors)
+	MergeTags(dto MergeTagsDTO) (*TagResponse, apperror.AppErrors)
+
+	GetCategory(id uint) (*Category, apperror.AppErrors)
+	GetCategoryBySlug(slug string) (*Category, apperror.AppErrors)
+	GetCategoryTree() ([]CategoryResponse, apperror.AppErrors)
+	CategoryIDsBySlug(slug string) ([]uint, apperror.AppErrors)
+	CreateCategory(dto CreateCategoryDTO) (*CategoryResponse, apperror.AppErrors)
+	UpdateCategory(id uint, dto UpdateCategoryDTO) (*CategoryResponse, apperror.AppErrors)
+	DeleteCategory(id uint) apperror.AppErrors
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// ResolveTags normalises the given names, drops duplicates and returns the
+// matching tags, creating the ones that don't exist yet. It runs in tx, the
+// transaction saving the post, so tags of a post that is not saved are not
+// created either.
+func (s *service) ResolveTags(tx *gorm.DB, names []string) ([]Tag, error) {
+	seen := make(map[string]bool)
+	var tags []Tag
+	for _, name := range names {
+		name = NormalizeTagName(name)
+		tagSlug := Slug(name)
+		if tagSlug == "" || seen[tagSlug] {
+			continue
+		}
+		seen[tagSlug] = true
+		tags = append(tags, Tag{Name: name, Slug: tagSlug})
+	}
+
+	return s.repo.WithTx(tx).FindOrCreateTags(tags)
+}
+
+func (s *service) GetTags() ([]TagResponse, apperror.AppErrors) {
+	tags, err := s.repo.FindTagsWithUsage()
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]TagResponse, 0, len(tags))
+	for _, tag := range tags {
+		responses = append(responses, *tag.ToResponse())
+	}
+
+	return responses, nil
+}
+
+func (s *service) RenameTag(id uint, dto RenameTagDTO) (*TagResponse, apperror.AppErrors) {
+	tag, err := s.repo.FindTagByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.TagNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	name := NormalizeTagName(dto.Name)
+	tagSlug := Slug(name)
+	if tagSlug == "" {
+		return nil, apperror.InvalidName()
+	}
+
+	existing, _ := s.repo.FindTagBySlug(tagSlug)
+	if existing != nil && existing.ID != tag.ID {
+		return nil, apperror.TagAlreadyExists()
+	}
+
+	tag.Name = name
+	tag.Slug = tagSlug
+	if err := s.repo.UpdateTag(tag); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return tag.ToResponse(), nil
+}
+
+func (s *service) MergeTags(dto MergeTagsDTO) (*TagResponse, apperror.AppErrors) {
+	target, err := s.repo.FindTagByID(dto.TargetID)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.TagNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	var sourceIDs []uint
+	for _, id := range dto.SourceIDs {
+		if id == target.ID {
+			continue
+		}
+		if _, err := s.repo.FindTagByID(id); err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil, apperror.TagNotFound()
+			}
+			return nil, apperror.DatabaseError(err)
+		}
+		sourceIDs = append(sourceIDs, id)
+	}
+
+	if len(sourceIDs) > 0 {
+		if err := s.repo.MergeTags(sourceIDs, target.ID); err != nil {
+			return nil, apperror.DatabaseError(err)
+		}
+	}
+
+	return target.ToResponse(), nil
+}
+
+func (s *service) GetCategory(id uint) (*Category, apperror.AppErrors) {
+	category, err := s.repo.FindCategoryByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.CategoryNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	return category, nil
+}
+
+func (s *service) GetCategoryBySlug(categorySlug string) (*Category, apperror.AppErrors) {
+	category, err := s.repo.FindCategoryBySlug(categorySlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.CategoryNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	return category, nil
+}
+
+func (s *service) GetCategoryTree() ([]CategoryResponse, apperror.AppErrors) {
+	categories, err := s.repo.FindAllCategories()
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return buildTree(categories, nil), nil
+}
+
+// CategoryIDsBySlug returns the ID of the category with the given slug and
+// the IDs of all of its descendants. An unknown slug yields no IDs.
+func (s *service) CategoryIDsBySlug(categorySlug string) ([]uint, apperror.AppErrors) {
+	root, err := s.repo.FindCategoryBySlug(categorySlug)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	categories, err := s.repo.FindAllCategories()
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return append([]uint{root.ID}, descendantIDs(categories, root.ID)...), nil
+}
+
+func (s *service) CreateCategory(dto CreateCategoryDTO) (*CategoryResponse, apperror.AppErrors) {
+	categorySlug := Slug(dto.Name)
+	if categorySlug == "" {
+		return nil, apperror.InvalidName()
+	}
+	existing, err := s.repo.FindCategoryBySlug(categorySlug)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, apperror.DatabaseError(err)
+	}
+	if existing != nil {
+		return nil, apperror.CategoryAlreadyExists()
+	}
+
+	if dto.ParentID != nil {
+		if _, appErr := s.GetCategory(*dto.ParentID); appErr != nil {
+			return nil, appErr
+		}
+	}
+
+	category := &Category{
+		Name:     dto.Name,
+		Slug:     categorySlug,
+		ParentID: dto.ParentID,
+	}
+
+	if err := s.repo.CreateCategory(category); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return category.ToResponse(), nil
+}
+
+func (s *service) UpdateCategory(id uint, dto UpdateCategoryDTO) (*CategoryResponse, apperror.AppErrors) {
+	category, appErr := s.GetCategory(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if dto.Name != "" {
+		categorySlug := Slug(dto.Name)
+		if categorySlug == "" {
+			return nil, apperror.InvalidName()
+		}
+		existing, err := s.repo.FindCategoryBySlug(categorySlug)
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, apperror.DatabaseError(err)
+		}
+		if existing != nil && existing.ID != id {
+			return nil, apperror.CategoryAlreadyExists()
+		}
+		category.Name = dto.Name
+		category.Slug = categorySlug
+	}
+
+	if dto.ParentID != nil {
+		if _, appErr := s.GetCategory(*dto.ParentID); appErr != nil {
+			return nil, appErr
+		}
+
+		categories, err := s.repo.FindAllCategories()
+		if err != nil {
+			return nil, apperror.DatabaseError(err)
+		}
+		if *dto.ParentID == id || containsID(descendantIDs(categories, id), *dto.ParentID) {
+			return nil, apperror.InvalidCategoryParent()
+		}
+		category.ParentID = dto.ParentID
+	}
+	if dto.Root {
+		category.ParentID = nil
+	}
+
+	if err := s.repo.UpdateCategory(category); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	return category.ToResponse(), nil
+}
+
+func (s *service) DeleteCategory(id uint) apperror.AppErrors {
+	category, appErr := s.GetCategory(id)
+	if appErr != nil {
+		return appErr
+	}
+
+	if err := s.repo.DeleteCategory(category); err != nil {
+		return apperror.DatabaseError(err)
+	}
+
+	return nil
+}
+
+func buildTree(categories []Category, parentID *uint) []CategoryResponse {
+	var nodes []CategoryResponse
+	for _, category := range categories {
+		if !sameParent(category.ParentID, parentID) {
+			continue
+		}
+		node := category.ToResponse()
+		node.Children = buildTree(categories, &category.ID)
+		nodes = append(nodes, *node)
+	}
+	return nodes
+}
+
+func descendantIDs(categories []Category, rootID uint) []uint {
+	var ids []uint
+	queue := []uint{rootID}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, category := range categories {
+			if category.ParentID != nil && *category.ParentID == current {
+				ids = append(ids, category.ID)
+				queue = append(queue, category.ID)
+			}
+		}
+	}
+	return ids
+}
+
+func sameParent(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func containsID(ids []uint, id uint) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
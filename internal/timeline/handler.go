@@ -0,0 +1,34 @@
+package timeline
+
+import (
+	"net/http"
+
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+func (h *Handler) GetFeed(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	var query FeedQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	posts, meta, appErr := h.service.GetFeed(userID, query)
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to retrieve feed", appErr)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Feed retrieved successfully", posts, meta)
+}
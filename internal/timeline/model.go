@@ -0,0 +1,60 @@
+package timeline
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Entry is a single post in a user's home timeline.
+type Entry struct {
+	PostID    uint
+	CreatedAt time.Time
+}
+
+// Cursor points at the last entry of a page. The next page starts strictly
+// after it in (created_at DESC, id DESC) order.
+type Cursor struct {
+	CreatedAt time.Time
+	PostID    uint
+}
+
+type FeedQuery struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type FeedMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.PostID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(value string) (*Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var nanos int64
+	var postID uint
+	if _, err := fmt.Sscanf(string(raw), "%d:%d", &nanos, &postID); err != nil {
+		return nil, err
+	}
+	if postID == 0 {
+		return nil, errors.New("cursor without post id")
+	}
+
+	return &Cursor{CreatedAt: time.Unix(0, nanos), PostID: postID}, nil
+}
@@ -0,0 +1,72 @@
+package timeline
+
+import (
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/post"
+)
+
+type Service interface {
+	GetFeed(userID uint, query FeedQuery) ([]post.PostResponse, *FeedMeta, apperror.AppErrors)
+}
+
+type service struct {
+	store       Store
+	postService post.Service
+}
+
+func NewService(store Store, postService post.Service) Service {
+	return &service{
+		store:       store,
+		postService: postService,
+	}
+}
+
+func (s *service) GetFeed(userID uint, query FeedQuery) ([]post.PostResponse, *FeedMeta, apperror.AppErrors) {
+	limit := query.Limit
+	if limit < 1 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	var after *Cursor
+	if query.Cursor != "" {
+		cursor, err := DecodeCursor(query.Cursor)
+		if err != nil {
+			return nil, nil, apperror.InvalidCursor()
+		}
+		after = cursor
+	}
+
+	// Fetch one extra entry to know whether another page exists.
+	entries, err := s.store.Entries(userID, after, limit+1)
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	meta := &FeedMeta{Limit: limit}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		meta.HasMore = true
+	}
+	if meta.HasMore {
+		last := entries[len(entries)-1]
+		meta.NextCursor = Cursor{CreatedAt: last.CreatedAt, PostID: last.PostID}.Encode()
+	}
+
+	ids := make([]uint, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.PostID)
+	}
+
+	posts, appErr := s.postService.GetPostsByIDs(ids, userID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	if posts == nil {
+		posts = []post.PostResponse{}
+	}
+
+	return posts, meta, nil
+}
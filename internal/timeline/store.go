@@ -0,0 +1,43 @@
+package timeline
+
+import (
+	"gorm.io/gorm"
+)
+
+// Store produces the entries of a user's home timeline, newest first.
+// Hidden posts are left out, so pages are not cut short after fetching.
+//
+// The only implementation today builds the timeline on read from the follows
+// and posts tables. A precomputed fan-out-on-write store can replace it by
+// materialising entries per follower when posts are published, without any
+// change to Service or the HTTP layer.
+type Store interface {
+	Entries(userID uint, after *Cursor, limit int) ([]Entry, error)
+}
+
+type fanOutOnReadStore struct {
+	db *gorm.DB
+}
+
+func NewFanOutOnReadStore(db *gorm.DB) Store {
+	return &fanOutOnReadStore{db: db}
+}
+
+func (s *fanOutOnReadStore) Entries(userID uint, after *Cursor, limit int) ([]Entry, error) {
+	query := s.db.Table("posts").
+		Select("posts.id AS post_id, posts.created_at").
+		Joins("JOIN follows ON follows.followee_id = posts.user_id").
+		Where("follows.follower_id = ?", userID).
+		Where("posts.hidden_at IS NULL")
+
+	if after != nil {
+		query = query.Where("posts.created_at < ? OR (posts.created_at = ? AND posts.id < ?)",
+			after.CreatedAt, after.CreatedAt, after.PostID)
+	}
+
+	var entries []Entry
+	err := query.Order("posts.created_at DESC, posts.id DESC").
+		Limit(limit).
+		Scan(&entries).Error
+	return entries, err
+}
@@ -0,0 +1,208 @@
+package transfer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// csvColumns is the header written to CSV exports. Imports only require
+// the columns in requiredCSVColumns and ignore unknown ones.
+var csvColumns = []string{"external_id", "title", "content", "content_format", "category", "tags", "author", "created_at"}
+
+var requiredCSVColumns = []string{"external_id", "title", "content"}
+
+// csvTagSeparator joins tags in the single tags column.
+const csvTagSeparator = "|"
+
+// SyntaxError is returned by Reader.Read for a record that cannot be
+// decoded. Reading can continue with the next record.
+type SyntaxError struct {
+	Line int
+	Err  error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+type Writer interface {
+	Write(record *Record) error
+	// Flush writes buffered records to the underlying writer.
+	Flush() error
+}
+
+type Reader interface {
+	// Read returns the next record and the line it starts on, or io.EOF
+	// after the last one.
+	Read() (*Record, int, error)
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatNDJSON:
+		buffered := bufio.NewWriter(w)
+		return &ndjsonWriter{buffered: buffered, encoder: json.NewEncoder(buffered)}, nil
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(csvColumns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer}, nil
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatNDJSON:
+		return &ndjsonReader{reader: bufio.NewReader(r)}, nil
+	case FormatCSV:
+		return newCSVReader(r)
+	}
+	return nil, fmt.Errorf("unsupported format %q", format)
+}
+
+type ndjsonWriter struct {
+	buffered *bufio.Writer
+	encoder  *json.Encoder
+}
+
+func (w *ndjsonWriter) Write(record *Record) error {
+	return w.encoder.Encode(record)
+}
+
+func (w *ndjsonWriter) Flush() error {
+	return w.buffered.Flush()
+}
+
+type ndjsonReader struct {
+	reader *bufio.Reader
+	line   int
+}
+
+func (r *ndjsonReader) Read() (*Record, int, error) {
+	for {
+		data, err := r.reader.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return nil, r.line, err
+		}
+		if err != nil && err != io.EOF {
+			return nil, r.line, err
+		}
+		r.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil, r.line, &SyntaxError{Line: r.line, Err: err}
+		}
+		return &record, r.line, nil
+	}
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(record *Record) error {
+	createdAt := ""
+	if record.CreatedAt != nil {
+		createdAt = record.CreatedAt.UTC().Format(time.RFC3339)
+	}
+	return w.writer.Write([]string{
+		record.ExternalID,
+		record.Title,
+		record.Content,
+		record.ContentFormat,
+		record.Category,
+		strings.Join(record.Tags, csvTagSeparator),
+		record.Author,
+		createdAt,
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type csvReader struct {
+	reader  *csv.Reader
+	columns map[string]int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("the file is empty")
+		}
+		return nil, err
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range requiredCSVColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("the header has no %s column", name)
+		}
+	}
+
+	return &csvReader{reader: reader, columns: columns}, nil
+}
+
+func (r *csvReader) Read() (*Record, int, error) {
+	fields, err := r.reader.Read()
+	if err == io.EOF {
+		return nil, 0, err
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return nil, parseErr.StartLine, &SyntaxError{Line: parseErr.StartLine, Err: parseErr.Err}
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	line, _ := r.reader.FieldPos(0)
+
+	record := &Record{
+		ExternalID:    r.field(fields, "external_id"),
+		Title:         r.field(fields, "title"),
+		Content:       r.field(fields, "content"),
+		ContentFormat: r.field(fields, "content_format"),
+		Category:      r.field(fields, "category"),
+		Author:        r.field(fields, "author"),
+	}
+	if tags := r.field(fields, "tags"); tags != "" {
+		record.Tags = strings.Split(tags, csvTagSeparator)
+	}
+	if createdAt := r.field(fields, "created_at"); createdAt != "" {
+		t, err := time.Parse(time.RFC3339, createdAt)
+		if err != nil {
+			return nil, line, &SyntaxError{Line: line, Err: fmt.Errorf("created_at must be an RFC 3339 time")}
+		}
+		record.CreatedAt = &t
+	}
+	return record, line, nil
+}
+
+func (r *csvReader) field(fields []string, name string) string {
+	if i, ok := r.columns[name]; ok && i < len(fields) {
+		return fields[i]
+	}
+	return ""
+}
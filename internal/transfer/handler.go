@@ -0,0 +1,120 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+var contentTypes = map[string]string{
+	FormatNDJSON: "application/x-ndjson",
+	FormatCSV:    "text/csv; charset=utf-8",
+}
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// ExportMyPosts streams the posts of the current user.
+func (h *Handler) ExportMyPosts(c *gin.Context) {
+	h.export(c, c.GetUint("user_id"))
+}
+
+// ExportAllPosts streams the posts of every user.
+func (h *Handler) ExportAllPosts(c *gin.Context) {
+	h.export(c, 0)
+}
+
+// ImportPosts reads the file from the multipart field "file" or, for any
+// other content type, from the request body. Partly failed imports are
+// answered with 207 and the failed lines.
+func (h *Handler) ImportPosts(c *gin.Context) {
+	format, ok := requestFormat(c)
+	if !ok {
+		return
+	}
+	clearDeadlines(c)
+
+	var body io.Reader = c.Request.Body
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "File is required", apperror.FileRequired())
+			return
+		}
+		file, err := header.Open()
+		if err != nil {
+			response.Error(c, http.StatusBadRequest, "File is required", apperror.FileRequired())
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	result, appErr := h.service.Import(body, format, c.GetUint("user_id"))
+	if appErr != nil {
+		response.ErrorWithData(c, http.StatusBadRequest, "Import aborted", result, appErr)
+		return
+	}
+
+	if result.Failed > 0 {
+		response.Success(c, http.StatusMultiStatus, "Import completed with errors", result)
+		return
+	}
+	response.Success(c, http.StatusOK, "Import completed successfully", result)
+}
+
+func (h *Handler) export(c *gin.Context, userID uint) {
+	format, ok := requestFormat(c)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("posts-%s.%s", time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", contentTypes[format])
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+	clearDeadlines(c)
+
+	if err := h.service.Export(c.Writer, format, userID); err != nil {
+		if !c.Writer.Written() {
+			c.Writer.Header().Del("Content-Type")
+			c.Writer.Header().Del("Content-Disposition")
+			response.InternalError(c, err)
+			return
+		}
+		// Part of the file has been sent, so the client only sees a
+		// truncated download.
+		log.Printf("transfer: export failed: %v", err)
+		c.Abort()
+	}
+}
+
+// clearDeadlines lifts the server's read and write timeouts for the request,
+// which large files easily outlast.
+func clearDeadlines(c *gin.Context) {
+	controller := http.NewResponseController(c.Writer)
+	_ = controller.SetReadDeadline(time.Time{})
+	_ = controller.SetWriteDeadline(time.Time{})
+}
+
+// requestFormat reads the format query parameter, which defaults to NDJSON.
+func requestFormat(c *gin.Context) (string, bool) {
+	format := c.DefaultQuery("format", FormatNDJSON)
+	if _, ok := contentTypes[format]; !ok {
+		response.Error(c, http.StatusBadRequest, "Unsupported format", apperror.UnsupportedFormat(Formats...))
+		return "", false
+	}
+	return format, true
+}
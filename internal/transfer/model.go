@@ -0,0 +1,43 @@
+package transfer
+
+import (
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+)
+
+const (
+	FormatNDJSON = "ndjson"
+	FormatCSV    = "csv"
+)
+
+// Formats lists the supported file formats.
+var Formats = []string{FormatNDJSON, FormatCSV}
+
+// Record is one post in an export or import file. Category holds the
+// category slug so files can be moved between databases; Author is only
+// informational and ignored on import.
+type Record struct {
+	ExternalID    string     `json:"external_id"`
+	Title         string     `json:"title"`
+	Content       string     `json:"content"`
+	ContentFormat string     `json:"content_format"`
+	Category      string     `json:"category,omitempty"`
+	Tags          []string   `json:"tags"`
+	Author        string     `json:"author,omitempty"`
+	CreatedAt     *time.Time `json:"created_at,omitempty"`
+}
+
+// RowError reports why the record starting on Line was not imported.
+type RowError struct {
+	Line       int                `json:"line"`
+	ExternalID string             `json:"external_id,omitempty"`
+	Errors     apperror.AppErrors `json:"errors"`
+}
+
+type ImportResult struct {
+	Created int        `json:"created"`
+	Skipped int        `json:"skipped"`
+	Failed  int        `json:"failed"`
+	Errors  []RowError `json:"errors"`
+}
@@ -0,0 +1,157 @@
+package transfer
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
+	"github.com/gin-gonic/gin/binding"
+)
+
+type Service interface {
+	// Export writes the posts of userID, or of everyone when userID is zero,
+	// to w. Posts are read and written in batches, so memory use does not
+	// grow with the number of posts.
+	Export(w io.Writer, format string, userID uint) error
+	// Import creates a post for userID from every valid record in r. Records
+	// whose external ID was imported before are skipped. Invalid records are
+	// reported in the result; the returned AppErrors are only set when the
+	// file itself cannot be read, in which case the result covers the
+	// records before the failure.
+	Import(r io.Reader, format string, userID uint) (*ImportResult, apperror.AppErrors)
+}
+
+type service struct {
+	postService     post.Service
+	taxonomyService taxonomy.Service
+}
+
+func NewService(postService post.Service, taxonomyService taxonomy.Service) Service {
+	return &service{
+		postService:     postService,
+		taxonomyService: taxonomyService,
+	}
+}
+
+func (s *service) Export(w io.Writer, format string, userID uint) error {
+	writer, err := NewWriter(format, w)
+	if err != nil {
+		return err
+	}
+
+	return s.postService.ExportPosts(userID, func(posts []post.PostResponse) error {
+		for i := range posts {
+			if err := writer.Write(toRecord(&posts[i])); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+}
+
+func (s *service) Import(r io.Reader, format string, userID uint) (*ImportResult, apperror.AppErrors) {
+	reader, err := NewReader(format, r)
+	if err != nil {
+		return nil, apperror.InvalidImportFile(err)
+	}
+
+	result := &ImportResult{Errors: []RowError{}}
+	categories := make(map[string]uint)
+	for {
+		record, line, err := reader.Read()
+		if err == io.EOF {
+			return result, nil
+		}
+		if syntaxErr, ok := err.(*SyntaxError); ok {
+			result.fail(line, "", apperror.InvalidImportRecord(syntaxErr.Err))
+			continue
+		}
+		if err != nil {
+			return result, apperror.InvalidImportFile(err)
+		}
+
+		dto, appErr := s.toDTO(record, categories)
+		if appErr != nil {
+			result.fail(line, record.ExternalID, appErr)
+			continue
+		}
+
+		_, created, appErr := s.postService.ImportPost(userID, *dto)
+		switch {
+		case appErr != nil:
+			result.fail(line, record.ExternalID, appErr)
+		case created:
+			result.Created++
+		default:
+			result.Skipped++
+		}
+	}
+}
+
+// toDTO validates record with the CreatePostDTO rules and resolves its
+// category slug, caching the IDs of slugs seen before.
+func (s *service) toDTO(record *Record, categories map[string]uint) (*post.ImportPostDTO, apperror.AppErrors) {
+	dto := &post.ImportPostDTO{
+		CreatePostDTO: post.CreatePostDTO{
+			Title:         record.Title,
+			Content:       record.Content,
+			ContentFormat: record.ContentFormat,
+			Tags:          record.Tags,
+		},
+		ExternalID: record.ExternalID,
+		CreatedAt:  record.CreatedAt,
+	}
+	if err := binding.Validator.ValidateStruct(dto); err != nil {
+		return nil, apperror.Validation(err)
+	}
+
+	if record.Category != "" {
+		id, ok := categories[record.Category]
+		if !ok {
+			category, appErr := s.taxonomyService.GetCategoryBySlug(record.Category)
+			if appErr != nil {
+				return nil, appErr
+			}
+			id = category.ID
+			categories[record.Category] = id
+		}
+		dto.CategoryID = &id
+	}
+
+	return dto, nil
+}
+
+func (r *ImportResult) fail(line int, externalID string, errors apperror.AppErrors) {
+	r.Failed++
+	r.Errors = append(r.Errors, RowError{Line: line, ExternalID: externalID, Errors: errors})
+}
+
+// toRecord describes a post for export. Posts that were not imported get
+// an external ID derived from their ID, so importing an export twice still
+// creates each post once.
+func toRecord(p *post.PostResponse) *Record {
+	record := &Record{
+		Title:         p.Title,
+		Content:       p.Content,
+		ContentFormat: p.ContentFormat,
+		Tags:          make([]string, 0, len(p.Tags)),
+		CreatedAt:     &p.CreatedAt,
+	}
+	if p.ExternalID != nil {
+		record.ExternalID = *p.ExternalID
+	} else {
+		record.ExternalID = fmt.Sprintf("post-%d", p.ID)
+	}
+	if p.Category != nil {
+		record.Category = p.Category.Slug
+	}
+	if p.User != nil {
+		record.Author = p.User.Email
+	}
+	for _, tag := range p.Tags {
+		record.Tags = append(record.Tags, tag.Name)
+	}
+	return record
+}
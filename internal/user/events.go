@@ -0,0 +1,30 @@
+package user
+
+// Domain events written to the outbox in the transaction that changes the
+// user. Subscribers load the user when they need more than the ID.
+
+type UserRegistered struct {
+	UserID uint `json:"user_id"`
+}
+
+func (UserRegistered) EventName() string     { return "user.registered" }
+func (UserRegistered) AggregateType() string { return "user" }
+func (e UserRegistered) AggregateID() uint   { return e.UserID }
+
+// UserUpdated covers changes of the profile and the avatar and the
+// anonymization of an erased account.
+type UserUpdated struct {
+	UserID uint `json:"user_id"`
+}
+
+func (UserUpdated) EventName() string     { return "user.updated" }
+func (UserUpdated) AggregateType() string { return "user" }
+func (e UserUpdated) AggregateID() uint   { return e.UserID }
+
+type UserDeleted struct {
+	UserID uint `json:"user_id"`
+}
+
+func (UserDeleted) EventName() string     { return "user.deleted" }
+func (UserDeleted) AggregateType() string { return "user" }
+func (e UserDeleted) AggregateID() uint   { return e.UserID }
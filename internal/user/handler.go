@@ -5,7 +5,10 @@ import (
 	"strconv"
 
 	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/common/patch"
 	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/ardipermana59/go-template/internal/media"
 	"github.com/gin-gonic/gin"
 )
 
@@ -42,6 +45,10 @@ func (h *Handler) Login(c *gin.Context) {
 
 	result, appErr := h.service.Login(dto)
 	if appErr != nil {
+		if appErr.Has("account") {
+			response.Error(c, http.StatusForbidden, "Account disabled", appErr)
+			return
+		}
 		response.Error(c, http.StatusUnauthorized, "Login failed", appErr)
 		return
 	}
@@ -49,6 +56,27 @@ func (h *Handler) Login(c *gin.Context) {
 	response.Success(c, http.StatusOK, "Login successful", result)
 }
 
+// RestoreAccount cancels a pending account deletion and signs the user in.
+func (h *Handler) RestoreAccount(c *gin.Context) {
+	var dto LoginDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	result, appErr := h.service.RestoreAccount(dto)
+	if appErr != nil {
+		if appErr.Has("account") {
+			response.Error(c, http.StatusConflict, "Account cannot be restored", appErr)
+			return
+		}
+		response.Error(c, http.StatusUnauthorized, "Restore failed", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Account restored successfully", result)
+}
+
 func (h *Handler) GetProfile(c *gin.Context) {
 	userID := c.GetUint("user_id")
 
@@ -58,6 +86,7 @@ func (h *Handler) GetProfile(c *gin.Context) {
 		return
 	}
 
+	etag.Set(c, user.Version)
 	response.Success(c, http.StatusOK, "Profile retrieved successfully", user)
 }
 
@@ -84,6 +113,7 @@ func (h *Handler) GetUserByID(c *gin.Context) {
 		return
 	}
 
+	etag.Set(c, user.Version)
 	response.Success(c, http.StatusOK, "User retrieved successfully", user)
 }
 
@@ -96,15 +126,60 @@ func (h *Handler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
-	user, appErr := h.service.UpdateUser(userID, dto)
+	user, appErr := h.service.UpdateUser(userID, etag.ExpectedVersion(c), dto)
 	if appErr != nil {
+		if appErr.Has("version") {
+			response.Error(c, http.StatusPreconditionFailed, "Profile has been modified", appErr)
+			return
+		}
 		response.Error(c, http.StatusBadRequest, "Failed to update profile", appErr)
 		return
 	}
 
+	etag.Set(c, user.Version)
 	response.Success(c, http.StatusOK, "Profile updated successfully", user)
 }
 
+func (h *Handler) UpdateAvatar(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update avatar", apperror.FileRequired())
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update avatar", apperror.FileRequired())
+		return
+	}
+	defer file.Close()
+
+	user, appErr := h.service.UpdateAvatar(c.Request.Context(), userID, media.Upload{
+		FileName: header.Filename,
+		Size:     header.Size,
+		Body:     file,
+	})
+	if appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to update avatar", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Avatar uploaded, variants are being generated", user)
+}
+
+func (h *Handler) DeleteAvatar(c *gin.Context) {
+	userID := c.GetUint("user_id")
+
+	if appErr := h.service.DeleteAvatar(c.Request.Context(), userID); appErr != nil {
+		response.Error(c, http.StatusBadRequest, "Failed to delete avatar", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Avatar deleted successfully", nil)
+}
+
 func (h *Handler) UpdateUser(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
@@ -118,12 +193,65 @@ func (h *Handler) UpdateUser(c *gin.Context) {
 		return
 	}
 
-	user, appErr := h.service.UpdateUser(uint(id), dto)
+	user, appErr := h.service.UpdateUser(uint(id), etag.ExpectedVersion(c), dto)
 	if appErr != nil {
+		if appErr.Has("version") {
+			response.Error(c, http.StatusPreconditionFailed, "User has been modified", appErr)
+			return
+		}
 		response.Error(c, http.StatusBadRequest, "Failed to update user", appErr)
 		return
 	}
 
+	etag.Set(c, user.Version)
+	response.Success(c, http.StatusOK, "User updated successfully", user)
+}
+
+func (h *Handler) PatchProfile(c *gin.Context) {
+	h.patchUser(c, c.GetUint("user_id"), "profile")
+}
+
+func (h *Handler) PatchUser(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return
+	}
+
+	h.patchUser(c, uint(id), "user")
+}
+
+// patchUser applies a JSON Merge Patch or JSON Patch to the user's
+// UserDocument. Without If-Match the patch is bound to the version it was
+// applied to.
+func (h *Handler) patchUser(c *gin.Context, id uint, resource string) {
+	current, appErr := h.service.GetUserByID(id)
+	if appErr != nil {
+		response.Error(c, http.StatusNotFound, "User not found", appErr)
+		return
+	}
+
+	expected := etag.ExpectedVersion(c)
+	if expected == 0 {
+		expected = current.Version
+	}
+
+	var doc UserDocument
+	if !patch.Bind(c, current.Document(), &doc) {
+		return
+	}
+
+	user, appErr := h.service.ReplaceUser(id, expected, doc)
+	if appErr != nil {
+		if appErr.Has("version") {
+			response.Error(c, http.StatusPreconditionFailed, "User has been modified", appErr)
+			return
+		}
+		response.Error(c, http.StatusBadRequest, "Failed to update "+resource, appErr)
+		return
+	}
+
+	etag.Set(c, user.Version)
 	response.Success(c, http.StatusOK, "User updated successfully", user)
 }
 
@@ -3,21 +3,35 @@ package user
 import (
 	"time"
 
+	"github.com/ardipermana59/go-template/internal/media"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type User struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	Name      string    `json:"name" gorm:"not null"`
-	Email     string    `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string    `json:"-" gorm:"not null"`
-	Role      string    `json:"role" gorm:"default:'user'"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       uint         `json:"id" gorm:"primaryKey"`
+	Name     string       `json:"name" gorm:"not null"`
+	Email    string       `json:"email" gorm:"uniqueIndex;not null"`
+	Password string       `json:"-" gorm:"not null"`
+	Role     string       `json:"role" gorm:"default:'user'"`
+	AvatarID *uint        `json:"-"`
+	Avatar   *media.Image `json:"-" gorm:"foreignKey:AvatarID;constraint:OnDelete:SET NULL"`
+	Version  uint         `json:"version" gorm:"not null;default:1"`
+	// Username is the optional handle other users @mention. It is unique
+	// ignoring case.
+	Username *string `json:"username" gorm:"size:30;uniqueIndex"`
+	// DeletionScheduledAt is set while a deletion request waits out its
+	// grace period. The account is disabled in the meantime.
+	DeletionScheduledAt *time.Time `json:"-" gorm:"index"`
+	// AnonymizedAt is set once the personal data of a deleted account has
+	// been replaced under the anonymize erasure policy.
+	AnonymizedAt *time.Time `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
 }
 
 type RegisterDTO struct {
 	Name            string `json:"name" binding:"required,min=3"`
+	Username        string `json:"username" binding:"omitempty,min=3,max=30,alphanum"`
 	Email           string `json:"email" binding:"required,email"`
 	Password        string `json:"password" binding:"required,min=6"`
 	PasswordConfirm string `json:"password_confirm" binding:"required,eqfield=Password"`
@@ -29,8 +43,22 @@ type LoginDTO struct {
 }
 
 type UpdateUserDTO struct {
-	Name  string `json:"name" binding:"omitempty,min=3"`
-	Email string `json:"email" binding:"omitempty,email"`
+	Name     string `json:"name" binding:"omitempty,min=3"`
+	Email    string `json:"email" binding:"omitempty,email"`
+	Username string `json:"username" binding:"omitempty,min=3,max=30,alphanum"`
+}
+
+// UserDocument is the editable representation of a user that PATCH
+// requests are applied to.
+type UserDocument struct {
+	Name     string `json:"name" binding:"required,min=3"`
+	Email    string `json:"email" binding:"required,email"`
+	Username string `json:"username" binding:"omitempty,min=3,max=30,alphanum"`
+}
+
+// DeleteAccountDTO confirms a deletion request with the current password.
+type DeleteAccountDTO struct {
+	Password string `json:"password" binding:"required"`
 }
 
 type ChangePasswordDTO struct {
@@ -40,12 +68,15 @@ type ChangePasswordDTO struct {
 }
 
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Name      string    `json:"name"`
-	Email     string    `json:"email"`
-	Role      string    `json:"role"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint                 `json:"id"`
+	Name      string               `json:"name"`
+	Username  *string              `json:"username"`
+	Email     string               `json:"email"`
+	Role      string               `json:"role"`
+	Avatar    *media.ImageResponse `json:"avatar"`
+	Version   uint                 `json:"version"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
 }
 
 type LoginResponse struct {
@@ -67,13 +98,36 @@ func (u *User) CheckPassword(password string) bool {
 	return err == nil
 }
 
+// Active reports whether the account may sign in and use its tokens.
+func (u *User) Active() bool {
+	return u.DeletionScheduledAt == nil && u.AnonymizedAt == nil
+}
+
 func (u *User) ToResponse() *UserResponse {
-	return &UserResponse{
+	response := &UserResponse{
 		ID:        u.ID,
 		Name:      u.Name,
+		Username:  u.Username,
 		Email:     u.Email,
 		Role:      u.Role,
+		Version:   u.Version,
 		CreatedAt: u.CreatedAt,
 		UpdatedAt: u.UpdatedAt,
 	}
+	if u.Avatar != nil {
+		response.Avatar = u.Avatar.ToResponse()
+	}
+	return response
+}
+
+// Document returns the editable fields of the user.
+func (u *UserResponse) Document() *UserDocument {
+	doc := &UserDocument{
+		Name:  u.Name,
+		Email: u.Email,
+	}
+	if u.Username != nil {
+		doc.Username = *u.Username
+	}
+	return doc
 }
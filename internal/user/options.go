@@ -0,0 +1,13 @@
+package user
+
+import "github.com/ardipermana59/go-template/internal/outbox"
+
+type Option func(*service)
+
+// WithOutbox writes UserRegistered, UserUpdated and UserDeleted to the
+// outbox in the transaction of the change.
+func WithOutbox(writer outbox.Writer) Option {
+	return func(s *service) {
+		s.outbox = writer
+	}
+}
@@ -1,17 +1,42 @@
 package user
 
 import (
+	"errors"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/go-sql-driver/mysql"
 	"gorm.io/gorm"
 )
 
+// errUsernameTaken is returned by Create and Update when the unique index
+// rejects the username, which happens when another request took it after
+// checkUsername ran.
+var errUsernameTaken = errors.New("username taken by another user")
+
 type Repository interface {
 	Create(user *User) error
 	FindAll() ([]User, error)
 	FindByID(id uint) (*User, error)
 	FindByEmail(email string) (*User, error)
+	FindByUsername(username string) (*User, error)
+	// FindByUsernames returns the active users with one of the usernames.
+	// Usernames are compared ignoring case.
+	FindByUsernames(usernames []string) ([]User, error)
 	Update(user *User) error
+	SetAvatar(id uint, avatarID *uint) error
 	Delete(id uint) error
 	EmailExists(email string) bool
+	// ScheduleDeletion sets or, with a nil at, clears the deletion time.
+	ScheduleDeletion(id uint, at *time.Time) error
+	// FindDueForDeletion returns the IDs of accounts whose deletion time is
+	// not after before.
+	FindDueForDeletion(before time.Time) ([]uint, error)
+	// Anonymize writes the replaced personal data of user and marks it as
+	// anonymized.
+	Anonymize(user *User) error
+	WithTx(tx *gorm.DB) Repository
+	Transaction(fn func(tx *gorm.DB) error) error
 }
 
 type repository struct {
@@ -22,19 +47,23 @@ func NewRepository(db *gorm.DB) Repository {
 	return &repository{db: db}
 }
 
+func (r *repository) preload() *gorm.DB {
+	return r.db.Preload("Avatar.Variants")
+}
+
 func (r *repository) Create(user *User) error {
-	return r.db.Create(user).Error
+	return r.usernameError(r.db.Create(user).Error, user)
 }
 
 func (r *repository) FindAll() ([]User, error) {
 	var users []User
-	err := r.db.Find(&users).Error
+	err := r.preload().Find(&users).Error
 	return users, err
 }
 
 func (r *repository) FindByID(id uint) (*User, error) {
 	var user User
-	err := r.db.First(&user, id).Error
+	err := r.preload().First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -43,15 +72,66 @@ func (r *repository) FindByID(id uint) (*User, error) {
 
 func (r *repository) FindByEmail(email string) (*User, error) {
 	var user User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.preload().Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
+func (r *repository) FindByUsername(username string) (*User, error) {
+	var user User
+	err := r.preload().Where("username = ?", username).First(&user).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *repository) FindByUsernames(usernames []string) ([]User, error) {
+	var users []User
+	if len(usernames) == 0 {
+		return users, nil
+	}
+	err := r.db.
+		Where("username IN ?", usernames).
+		Where("deletion_scheduled_at IS NULL AND anonymized_at IS NULL").
+		Find(&users).Error
+	return users, err
+}
+
+// Update writes the user only if its version is still the one that was
+// loaded and increments the version in the same statement. Otherwise
+// etag.ErrVersionMismatch is returned and nothing is written.
 func (r *repository) Update(user *User) error {
-	return r.db.Save(user).Error
+	user.UpdatedAt = time.Now()
+	result := r.db.Model(&User{}).
+		Where("id = ? AND version = ?", user.ID, user.Version).
+		UpdateColumns(map[string]interface{}{
+			"name":       user.Name,
+			"email":      user.Email,
+			"username":   user.Username,
+			"password":   user.Password,
+			"role":       user.Role,
+			"updated_at": user.UpdatedAt,
+			"version":    gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return r.usernameError(result.Error, user)
+	}
+	if result.RowsAffected == 0 {
+		return etag.ErrVersionMismatch
+	}
+
+	user.Version++
+	return nil
+}
+
+func (r *repository) SetAvatar(id uint, avatarID *uint) error {
+	return r.db.Model(&User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"avatar_id": avatarID,
+		"version":   gorm.Expr("version + 1"),
+	}).Error
 }
 
 func (r *repository) Delete(id uint) error {
@@ -63,3 +143,58 @@ func (r *repository) EmailExists(email string) bool {
 	r.db.Model(&User{}).Where("email = ?", email).Count(&count)
 	return count > 0
 }
+
+func (r *repository) ScheduleDeletion(id uint, at *time.Time) error {
+	return r.db.Model(&User{}).Where("id = ?", id).UpdateColumns(map[string]interface{}{
+		"deletion_scheduled_at": at,
+		"version":               gorm.Expr("version + 1"),
+	}).Error
+}
+
+func (r *repository) FindDueForDeletion(before time.Time) ([]uint, error) {
+	var ids []uint
+	err := r.db.Model(&User{}).
+		Where("deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= ?", before).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+func (r *repository) Anonymize(user *User) error {
+	return r.db.Model(&User{}).Where("id = ?", user.ID).UpdateColumns(map[string]interface{}{
+		"name":                  user.Name,
+		"email":                 user.Email,
+		"username":              nil,
+		"password":              user.Password,
+		"avatar_id":             nil,
+		"deletion_scheduled_at": nil,
+		"anonymized_at":         user.AnonymizedAt,
+		"updated_at":            time.Now(),
+		"version":               gorm.Expr("version + 1"),
+	}).Error
+}
+
+func (r *repository) WithTx(tx *gorm.DB) Repository {
+	return &repository{db: tx}
+}
+
+func (r *repository) Transaction(fn func(tx *gorm.DB) error) error {
+	return r.db.Transaction(fn)
+}
+
+// usernameError returns errUsernameTaken when err is a duplicate key error
+// and another user holds the username of user; every other error is
+// returned unchanged. The username is looked up instead of parsing the
+// error message, which names the index differently across servers.
+func (r *repository) usernameError(err error, user *User) error {
+	var mysqlErr *mysql.MySQLError
+	if !errors.As(err, &mysqlErr) || mysqlErr.Number != 1062 || user.Username == nil {
+		return err
+	}
+
+	var count int64
+	if r.db.Model(&User{}).Where("username = ? AND id <> ?", *user.Username, user.ID).Count(&count).Error == nil && count > 0 {
+		return errUsernameTaken
+	}
+	return err
+}
@@ -1,8 +1,18 @@
 package user
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"time"
+
 	"github.com/ardipermana59/go-template/internal/auth"
 	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/media"
+	"github.com/ardipermana59/go-template/internal/outbox"
 	"gorm.io/gorm"
 )
 
@@ -12,21 +22,65 @@ type Service interface {
 	GetAllUsers() ([]UserResponse, apperror.AppErrors)
 	GetUserByID(id uint) (*UserResponse, apperror.AppErrors)
 	GetProfile(id uint) (*UserResponse, apperror.AppErrors)
-	UpdateUser(id uint, dto UpdateUserDTO) (*UserResponse, apperror.AppErrors)
+	// UpdateUser applies dto if the user is still at expectedVersion; zero
+	// skips the client check.
+	UpdateUser(id, expectedVersion uint, dto UpdateUserDTO) (*UserResponse, apperror.AppErrors)
+	// ReplaceUser overwrites every editable field with doc, so an empty
+	// username is cleared. It backs PATCH requests, which are applied to
+	// the user's UserDocument first.
+	ReplaceUser(id, expectedVersion uint, doc UserDocument) (*UserResponse, apperror.AppErrors)
 	ChangePassword(id uint, dto ChangePasswordDTO) apperror.AppErrors
 	DeleteUser(id uint) apperror.AppErrors
+	UpdateAvatar(ctx context.Context, id uint, upload media.Upload) (*UserResponse, apperror.AppErrors)
+	DeleteAvatar(ctx context.Context, id uint) apperror.AppErrors
+
+	// IsActive reports whether the user exists and its account is enabled.
+	IsActive(id uint) (bool, error)
+	// ScheduleDeletion disables the account until it is erased at deleteAt.
+	ScheduleDeletion(id uint, dto DeleteAccountDTO, deleteAt time.Time) apperror.AppErrors
+	// RestoreAccount signs in a user whose account is waiting for deletion
+	// and cancels the deletion.
+	RestoreAccount(dto LoginDTO) (*LoginResponse, apperror.AppErrors)
+	DueForDeletion(before time.Time) ([]uint, apperror.AppErrors)
+	// AnonymizeUser replaces the user's name, email, password and avatar so
+	// the remaining content can no longer be linked to the person.
+	AnonymizeUser(id uint) apperror.AppErrors
+	ExportUserData(userID uint) (interface{}, error)
 }
 
 type service struct {
-	repo       Repository
-	jwtService auth.JWTService
+	repo         Repository
+	jwtService   auth.JWTService
+	mediaService media.Service
+	outbox       outbox.Writer
 }
 
-func NewService(repo Repository, jwtService auth.JWTService) Service {
-	return &service{
-		repo:       repo,
-		jwtService: jwtService,
+func NewService(repo Repository, jwtService auth.JWTService, mediaService media.Service, opts ...Option) Service {
+	s := &service{
+		repo:         repo,
+		jwtService:   jwtService,
+		mediaService: mediaService,
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	return s
+}
+
+// write runs fn in a transaction and writes the event it returns to the
+// outbox in the same transaction.
+func (s *service) write(fn func(repo Repository) (outbox.Event, error)) error {
+	err := s.repo.Transaction(func(tx *gorm.DB) error {
+		event, err := fn(s.repo.WithTx(tx))
+		if err != nil || s.outbox == nil {
+			return err
+		}
+		return s.outbox.Write(tx, event)
+	})
+	if err == nil && s.outbox != nil {
+		s.outbox.Trigger()
+	}
+	return err
 }
 
 func (s *service) Register(dto RegisterDTO) (*UserResponse, apperror.AppErrors) {
@@ -39,13 +93,29 @@ func (s *service) Register(dto RegisterDTO) (*UserResponse, apperror.AppErrors)
 		Email:    dto.Email,
 		Password: dto.Password,
 		Role:     "user",
+		Version:  1,
+	}
+	if dto.Username != "" {
+		if appErr := s.checkUsername(dto.Username, 0); appErr != nil {
+			return nil, appErr
+		}
+		user.Username = &dto.Username
 	}
 
 	if err := user.HashPassword(); err != nil {
 		return nil, apperror.DatabaseError(err)
 	}
 
-	if err := s.repo.Create(user); err != nil {
+	err := s.write(func(repo Repository) (outbox.Event, error) {
+		if err := repo.Create(user); err != nil {
+			return nil, err
+		}
+		return UserRegistered{UserID: user.ID}, nil
+	})
+	if err != nil {
+		if err == errUsernameTaken {
+			return nil, apperror.UsernameAlreadyExists()
+		}
 		return nil, apperror.DatabaseError(err)
 	}
 
@@ -61,7 +131,39 @@ func (s *service) Login(dto LoginDTO) (*LoginResponse, apperror.AppErrors) {
 	if !user.CheckPassword(dto.Password) {
 		return nil, apperror.InvalidCredentials()
 	}
+	if user.AnonymizedAt != nil {
+		return nil, apperror.InvalidCredentials()
+	}
+	if user.DeletionScheduledAt != nil {
+		return nil, apperror.AccountPendingDeletion(*user.DeletionScheduledAt)
+	}
+
+	return s.login(user)
+}
+
+func (s *service) RestoreAccount(dto LoginDTO) (*LoginResponse, apperror.AppErrors) {
+	user, err := s.repo.FindByEmail(dto.Email)
+	if err != nil || !user.CheckPassword(dto.Password) || user.AnonymizedAt != nil {
+		return nil, apperror.InvalidCredentials()
+	}
+	if user.DeletionScheduledAt == nil {
+		return nil, apperror.AccountNotPendingDeletion()
+	}
+	// Past the deadline the account may already be being erased.
+	if !time.Now().Before(*user.DeletionScheduledAt) {
+		return nil, apperror.AccountPendingDeletion(*user.DeletionScheduledAt)
+	}
+
+	if err := s.repo.ScheduleDeletion(user.ID, nil); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	user.DeletionScheduledAt = nil
+	user.Version++
 
+	return s.login(user)
+}
+
+func (s *service) login(user *User) (*LoginResponse, apperror.AppErrors) {
 	token, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, apperror.DatabaseError(err)
@@ -102,13 +204,10 @@ func (s *service) GetProfile(id uint) (*UserResponse, apperror.AppErrors) {
 	return s.GetUserByID(id)
 }
 
-func (s *service) UpdateUser(id uint, dto UpdateUserDTO) (*UserResponse, apperror.AppErrors) {
-	user, err := s.repo.FindByID(id)
-	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return nil, apperror.UserNotFound()
-		}
-		return nil, apperror.DatabaseError(err)
+func (s *service) UpdateUser(id, expectedVersion uint, dto UpdateUserDTO) (*UserResponse, apperror.AppErrors) {
+	user, appErr := s.findForUpdate(id, expectedVersion)
+	if appErr != nil {
+		return nil, appErr
 	}
 
 	if dto.Name != "" {
@@ -121,8 +220,88 @@ func (s *service) UpdateUser(id uint, dto UpdateUserDTO) (*UserResponse, apperro
 		}
 		user.Email = dto.Email
 	}
+	if dto.Username != "" {
+		if appErr := s.checkUsername(dto.Username, id); appErr != nil {
+			return nil, appErr
+		}
+		user.Username = &dto.Username
+	}
 
-	if err := s.repo.Update(user); err != nil {
+	return s.save(user)
+}
+
+func (s *service) ReplaceUser(id, expectedVersion uint, doc UserDocument) (*UserResponse, apperror.AppErrors) {
+	user, appErr := s.findForUpdate(id, expectedVersion)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if doc.Email != user.Email {
+		existingUser, _ := s.repo.FindByEmail(doc.Email)
+		if existingUser != nil && existingUser.ID != id {
+			return nil, apperror.EmailAlreadyExists()
+		}
+	}
+	user.Name = doc.Name
+	user.Email = doc.Email
+	user.Username = nil
+	if doc.Username != "" {
+		if appErr := s.checkUsername(doc.Username, id); appErr != nil {
+			return nil, appErr
+		}
+		user.Username = &doc.Username
+	}
+
+	return s.save(user)
+}
+
+// findForUpdate loads a user that is about to change and checks the version
+// the client based its change on.
+func (s *service) findForUpdate(id, expectedVersion uint) (*User, apperror.AppErrors) {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.UserNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	if expectedVersion != 0 && user.Version != expectedVersion {
+		return nil, apperror.VersionConflict()
+	}
+	return user, nil
+}
+
+// checkUsername fails unless username is free for the user id; zero stands
+// for a user that doesn't exist yet.
+func (s *service) checkUsername(username string, id uint) apperror.AppErrors {
+	existingUser, err := s.repo.FindByUsername(username)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return apperror.DatabaseError(err)
+	}
+	if existingUser.ID != id {
+		return apperror.UsernameAlreadyExists()
+	}
+	return nil
+}
+
+// save writes a changed user and records UserUpdated.
+func (s *service) save(user *User) (*UserResponse, apperror.AppErrors) {
+	err := s.write(func(repo Repository) (outbox.Event, error) {
+		if err := repo.Update(user); err != nil {
+			return nil, err
+		}
+		return UserUpdated{UserID: user.ID}, nil
+	})
+	if err != nil {
+		switch err {
+		case etag.ErrVersionMismatch:
+			return nil, apperror.VersionConflict()
+		case errUsernameTaken:
+			return nil, apperror.UsernameAlreadyExists()
+		}
 		return nil, apperror.DatabaseError(err)
 	}
 
@@ -148,6 +327,9 @@ func (s *service) ChangePassword(id uint, dto ChangePasswordDTO) apperror.AppErr
 	}
 
 	if err := s.repo.Update(user); err != nil {
+		if err == etag.ErrVersionMismatch {
+			return apperror.VersionConflict()
+		}
 		return apperror.DatabaseError(err)
 	}
 
@@ -163,9 +345,168 @@ func (s *service) DeleteUser(id uint) apperror.AppErrors {
 		return apperror.DatabaseError(err)
 	}
 
-	if err := s.repo.Delete(user.ID); err != nil {
+	err = s.write(func(repo Repository) (outbox.Event, error) {
+		if err := repo.Delete(user.ID); err != nil {
+			return nil, err
+		}
+		return UserDeleted{UserID: user.ID}, nil
+	})
+	if err != nil {
+		return apperror.DatabaseError(err)
+	}
+	s.removeImage(context.Background(), user.AvatarID)
+
+	return nil
+}
+
+func (s *service) UpdateAvatar(ctx context.Context, id uint, upload media.Upload) (*UserResponse, apperror.AppErrors) {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.UserNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+
+	image, appErr := s.mediaService.Upload(ctx, id, upload)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	err = s.write(func(repo Repository) (outbox.Event, error) {
+		if err := repo.SetAvatar(id, &image.ID); err != nil {
+			return nil, err
+		}
+		return UserUpdated{UserID: id}, nil
+	})
+	if err != nil {
+		s.removeImage(ctx, &image.ID)
+		return nil, apperror.DatabaseError(err)
+	}
+	s.removeImage(ctx, user.AvatarID)
+
+	user.Version++
+	user.AvatarID = &image.ID
+	user.Avatar = image
+	return user.ToResponse(), nil
+}
+
+func (s *service) DeleteAvatar(ctx context.Context, id uint) apperror.AppErrors {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.UserNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
+
+	err = s.write(func(repo Repository) (outbox.Event, error) {
+		if err := repo.SetAvatar(id, nil); err != nil {
+			return nil, err
+		}
+		return UserUpdated{UserID: id}, nil
+	})
+	if err != nil {
+		return apperror.DatabaseError(err)
+	}
+	s.removeImage(ctx, user.AvatarID)
+
+	return nil
+}
+
+// removeImage deletes a replaced or orphaned image. Failures only leave
+// unreferenced files behind, so they are logged rather than returned.
+func (s *service) removeImage(ctx context.Context, id *uint) {
+	if id == nil {
+		return
+	}
+	if err := s.mediaService.Delete(ctx, *id); err != nil {
+		log.Printf("user: failed to delete image %d: %v", *id, err)
+	}
+}
+
+func (s *service) IsActive(id uint) (bool, error) {
+	user, err := s.repo.FindByID(id)
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.Active(), nil
+}
+
+func (s *service) ScheduleDeletion(id uint, dto DeleteAccountDTO, deleteAt time.Time) apperror.AppErrors {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.UserNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
+
+	if !user.CheckPassword(dto.Password) {
+		return apperror.PasswordIncorrect()
+	}
+
+	if err := s.repo.ScheduleDeletion(id, &deleteAt); err != nil {
+		return apperror.DatabaseError(err)
+	}
+	return nil
+}
+
+func (s *service) DueForDeletion(before time.Time) ([]uint, apperror.AppErrors) {
+	ids, err := s.repo.FindDueForDeletion(before)
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	return ids, nil
+}
+
+func (s *service) AnonymizeUser(id uint) apperror.AppErrors {
+	user, err := s.repo.FindByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return apperror.UserNotFound()
+		}
+		return apperror.DatabaseError(err)
+	}
+
+	// A random password nobody knows keeps the account from being used.
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return apperror.DatabaseError(err)
+	}
+	now := time.Now()
+	avatarID := user.AvatarID
+
+	user.Name = "Deleted user"
+	user.Email = fmt.Sprintf("deleted-%d@users.invalid", user.ID)
+	user.Username = nil
+	user.Password = hex.EncodeToString(secret)
+	user.AnonymizedAt = &now
+	if err := user.HashPassword(); err != nil {
 		return apperror.DatabaseError(err)
 	}
 
+	err = s.write(func(repo Repository) (outbox.Event, error) {
+		if err := repo.Anonymize(user); err != nil {
+			return nil, err
+		}
+		return UserUpdated{UserID: user.ID}, nil
+	})
+	if err != nil {
+		return apperror.DatabaseError(err)
+	}
+	s.removeImage(context.Background(), avatarID)
+
 	return nil
 }
+
+func (s *service) ExportUserData(userID uint) (interface{}, error) {
+	user, err := s.repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return user.ToResponse(), nil
+}
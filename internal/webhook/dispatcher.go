@@ -0,0 +1,86 @@
+package webhook
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// Dispatcher attempts due deliveries every interval and as soon as new ones
+// are queued.
+type Dispatcher struct {
+	interval time.Duration
+	wake     chan struct{}
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+func NewDispatcher(interval time.Duration) *Dispatcher {
+	return &Dispatcher{
+		interval: interval,
+		wake:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+	}
+}
+
+// Trigger wakes the dispatcher. It never blocks; wake-ups that arrive while
+// one is pending are merged.
+func (d *Dispatcher) Trigger() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (d *Dispatcher) Start(service Service) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		defer cancel()
+		ticker := time.NewTicker(d.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-d.wake:
+			case <-d.stop:
+				return
+			}
+			d.deliver(ctx, service)
+		}
+	}()
+
+	// Stopping cancels attempts in flight; they are retried after the
+	// lease expires.
+	go func() {
+		<-d.stop
+		cancel()
+	}()
+}
+
+// Stop waits for the attempts in flight to be cancelled and recorded.
+func (d *Dispatcher) Stop() {
+	d.once.Do(func() {
+		close(d.stop)
+	})
+	d.wg.Wait()
+}
+
+// deliver keeps going while full batches are returned so a backlog drains
+// without waiting for the next tick.
+func (d *Dispatcher) deliver(ctx context.Context, service Service) {
+	for ctx.Err() == nil {
+		attempted, err := service.DeliverDue(ctx)
+		if err != nil {
+			log.Printf("webhook: failed to deliver: %v", err)
+			return
+		}
+		if attempted < batchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+)
+
+// Event types sent to subscribers.
+const (
+	EventPostCreated = "post.created"
+	EventPostUpdated = "post.updated"
+	EventPostDeleted = "post.deleted"
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// PostReader and UserReader load the current state of a changed post or user
+// for the payload.
+type PostReader interface {
+	GetPostByID(id, viewerID uint) (*post.PostResponse, apperror.AppErrors)
+}
+
+type UserReader interface {
+	GetUserByID(id uint) (*user.UserResponse, apperror.AppErrors)
+}
+
+// deletedPost and deletedUser are the data of delete events, which only
+// identify the removed resource.
+type deletedPost struct {
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id"`
+}
+
+type deletedUser struct {
+	ID uint `json:"id"`
+}
+
+// Subscribe queues deliveries for the post and user events of the outbox.
+// Payloads carry the state at the time the event is handled; events of posts
+// that are hidden or gone by then are skipped, as a later event follows.
+// Subscribers only see public posts, so hiding a post is sent as
+// post.deleted and restoring it as post.created.
+func Subscribe(dispatcher *outbox.Dispatcher, service Service, posts PostReader, users UserReader) {
+	const name = "webhook"
+
+	outbox.Subscribe(dispatcher, name, func(ctx context.Context, meta outbox.Metadata, event post.PostPublished) error {
+		return enqueuePost(service, posts, meta, EventPostCreated, event.PostID, event.Hidden)
+	})
+	outbox.Subscribe(dispatcher, name, func(ctx context.Context, meta outbox.Metadata, event post.PostUpdated) error {
+		switch {
+		case event.Hidden && !event.WasHidden:
+			return service.Enqueue(eventID(meta), EventPostDeleted, deletedPost{ID: event.PostID, UserID: event.UserID})
+		case !event.Hidden && event.WasHidden:
+			return enqueuePost(service, posts, meta, EventPostCreated, event.PostID, false)
+		}
+		return enqueuePost(service, posts, meta, EventPostUpdated, event.PostID, event.Hidden)
+	})
+	outbox.Subscribe(dispatcher, name, func(ctx context.Context, meta outbox.Metadata, event post.PostDeleted) error {
+		// post.deleted went out when the post was hidden.
+		if event.Hidden {
+			return nil
+		}
+		return service.Enqueue(eventID(meta), EventPostDeleted, deletedPost{ID: event.PostID, UserID: event.UserID})
+	})
+
+	outbox.Subscribe(dispatcher, name, func(ctx context.Context, meta outbox.Metadata, event user.UserRegistered) error {
+		return enqueueUser(service, users, meta, EventUserCreated, event.UserID)
+	})
+	outbox.Subscribe(dispatcher, name, func(ctx context.Context, meta outbox.Metadata, event user.UserUpdated) error {
+		return enqueueUser(service, users, meta, EventUserUpdated, event.UserID)
+	})
+	outbox.Subscribe(dispatcher, name, func(ctx context.Context, meta outbox.Metadata, event user.UserDeleted) error {
+		return service.Enqueue(eventID(meta), EventUserDeleted, deletedUser{ID: event.UserID})
+	})
+}
+
+func enqueuePost(service Service, posts PostReader, meta outbox.Metadata, eventType string, id uint, hidden bool) error {
+	if hidden {
+		return nil
+	}
+	p, appErr := posts.GetPostByID(id, 0)
+	if appErr != nil {
+		if appErr.Has("post") {
+			return nil
+		}
+		return fmt.Errorf("load post %d: %v", id, appErr)
+	}
+	return service.Enqueue(eventID(meta), eventType, p)
+}
+
+func enqueueUser(service Service, users UserReader, meta outbox.Metadata, eventType string, id uint) error {
+	u, appErr := users.GetUserByID(id)
+	if appErr != nil {
+		if appErr.Has("user") {
+			return nil
+		}
+		return fmt.Errorf("load user %d: %v", id, appErr)
+	}
+	return service.Enqueue(eventID(meta), eventType, u)
+}
+
+// eventID derives the event ID from the outbox message, so an event that is
+// handled twice is queued with the same ID.
+func eventID(meta outbox.Metadata) string {
+	return fmt.Sprintf("evt_%d", meta.MessageID)
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"github.com/ardipermana59/go-template/internal/common/response"
+	"github.com/gin-gonic/gin"
+)
+
+type Handler struct {
+	service Service
+}
+
+func NewHandler(service Service) *Handler {
+	return &Handler{service: service}
+}
+
+// CreateSubscription returns the signing secret; later responses omit it.
+func (h *Handler) CreateSubscription(c *gin.Context) {
+	var dto CreateSubscriptionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	subscription, appErr := h.service.CreateSubscription(dto)
+	if appErr != nil {
+		response.Error(c, http.StatusInternalServerError, "Failed to create webhook", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusCreated, "Webhook created successfully", subscription)
+}
+
+func (h *Handler) GetSubscriptions(c *gin.Context) {
+	subscriptions, appErr := h.service.GetSubscriptions()
+	if appErr != nil {
+		response.InternalError(c, nil)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhooks retrieved successfully", subscriptions)
+}
+
+func (h *Handler) GetSubscription(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	subscription, appErr := h.service.GetSubscription(id)
+	if appErr != nil {
+		h.error(c, "Failed to retrieve webhook", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook retrieved successfully", subscription)
+}
+
+func (h *Handler) UpdateSubscription(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var dto UpdateSubscriptionDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	subscription, appErr := h.service.UpdateSubscription(id, dto)
+	if appErr != nil {
+		h.error(c, "Failed to update webhook", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook updated successfully", subscription)
+}
+
+func (h *Handler) DeleteSubscription(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	if appErr := h.service.DeleteSubscription(id); appErr != nil {
+		h.error(c, "Failed to delete webhook", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+
+	var params pagination.Params
+	if err := c.ShouldBindQuery(&params); err != nil {
+		response.ValidationError(c, err)
+		return
+	}
+
+	deliveries, meta, appErr := h.service.GetDeliveries(id, params)
+	if appErr != nil {
+		h.error(c, "Failed to retrieve webhook deliveries", appErr)
+		return
+	}
+
+	response.Paginated(c, http.StatusOK, "Webhook deliveries retrieved successfully", deliveries, meta)
+}
+
+// Redeliver answers with 202; the new delivery is attempted in the
+// background and shows up in the delivery log.
+func (h *Handler) Redeliver(c *gin.Context) {
+	id, ok := parseID(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseID(c, "delivery_id")
+	if !ok {
+		return
+	}
+
+	delivery, appErr := h.service.Redeliver(id, deliveryID)
+	if appErr != nil {
+		h.error(c, "Failed to redeliver webhook", appErr)
+		return
+	}
+
+	response.Success(c, http.StatusAccepted, "Webhook redelivery queued", delivery)
+}
+
+func (h *Handler) error(c *gin.Context, message string, appErr apperror.AppErrors) {
+	switch {
+	case appErr.Has("delivery"):
+		response.Error(c, http.StatusNotFound, "Webhook delivery not found", appErr)
+	case appErr.Has("active"):
+		response.Error(c, http.StatusConflict, message, appErr)
+	case appErr.Has("webhook"):
+		response.Error(c, http.StatusNotFound, "Webhook not found", appErr)
+	default:
+		response.Error(c, http.StatusInternalServerError, message, appErr)
+	}
+}
+
+func parseID(c *gin.Context, param string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(param), 10, 32)
+	if err != nil {
+		response.Error(c, http.StatusBadRequest, "Invalid ID", apperror.InvalidID())
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,160 @@
+package webhook
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Subscription receives the events listed in Events at URL. Requests are
+// signed with Secret. A subscription that fails too often in a row is
+// disabled until an admin enables it again.
+type Subscription struct {
+	ID                  uint       `json:"id" gorm:"primaryKey"`
+	URL                 string     `json:"url" gorm:"size:500;not null"`
+	Secret              string     `json:"-" gorm:"size:100;not null"`
+	Events              string     `json:"events" gorm:"size:500;not null"`
+	Description         string     `json:"description" gorm:"size:255"`
+	Active              bool       `json:"active" gorm:"not null;default:true;index"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func (Subscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Delivery is one event queued for one subscription, together with the
+// outcome of its latest attempt. Redeliveries keep the EventID so receivers
+// can ignore events they already processed.
+type Delivery struct {
+	ID             uint         `json:"id" gorm:"primaryKey"`
+	SubscriptionID uint         `json:"subscription_id" gorm:"not null;index"`
+	Subscription   Subscription `json:"-" gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE"`
+	EventID        string       `json:"event_id" gorm:"size:40;not null;index"`
+	EventType      string       `json:"event_type" gorm:"size:50;not null"`
+	Payload        string       `json:"-" gorm:"type:mediumtext;not null"`
+	Status         string       `json:"status" gorm:"size:20;not null;index:idx_webhook_deliveries_due"`
+	Attempts       int          `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  *time.Time   `json:"next_attempt_at" gorm:"index:idx_webhook_deliveries_due"`
+	LastStatusCode int          `json:"last_status_code"`
+	LastError      string       `json:"last_error" gorm:"size:500"`
+	DeliveredAt    *time.Time   `json:"delivered_at"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+}
+
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Payload is the JSON body sent to subscribers.
+type Payload struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// CreateSubscriptionDTO creates a subscription. Without a secret one is
+// generated; it is only returned by this request.
+type CreateSubscriptionDTO struct {
+	URL         string   `json:"url" binding:"required,http_url,max=500"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=100"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=post.created post.updated post.deleted user.created user.updated user.deleted"`
+	Description string   `json:"description" binding:"max=255"`
+}
+
+// UpdateSubscriptionDTO leaves omitted fields unchanged. Enabling a
+// subscription resets its failure count.
+type UpdateSubscriptionDTO struct {
+	URL         string   `json:"url" binding:"omitempty,http_url,max=500"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=100"`
+	Events      []string `json:"events" binding:"omitempty,min=1,dive,oneof=post.created post.updated post.deleted user.created user.updated user.deleted"`
+	Description *string  `json:"description" binding:"omitempty,max=255"`
+	Active      *bool    `json:"active"`
+}
+
+type SubscriptionResponse struct {
+	ID                  uint       `json:"id"`
+	URL                 string     `json:"url"`
+	Events              []string   `json:"events"`
+	Description         string     `json:"description"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+
+	// Secret is only set in the response that created or changed it.
+	Secret string `json:"secret,omitempty"`
+}
+
+type DeliveryResponse struct {
+	ID             uint            `json:"id"`
+	SubscriptionID uint            `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	LastStatusCode int             `json:"last_status_code"`
+	LastError      string          `json:"last_error"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	Payload        json.RawMessage `json:"payload"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+// EventList returns the event types of the subscription.
+func (s *Subscription) EventList() []string {
+	return strings.Split(s.Events, ",")
+}
+
+// Wants reports whether the subscription receives eventType.
+func (s *Subscription) Wants(eventType string) bool {
+	for _, event := range s.EventList() {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscription) ToResponse() *SubscriptionResponse {
+	return &SubscriptionResponse{
+		ID:                  s.ID,
+		URL:                 s.URL,
+		Events:              s.EventList(),
+		Description:         s.Description,
+		Active:              s.Active,
+		ConsecutiveFailures: s.ConsecutiveFailures,
+		DisabledAt:          s.DisabledAt,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+func (d *Delivery) ToResponse() *DeliveryResponse {
+	return &DeliveryResponse{
+		ID:             d.ID,
+		SubscriptionID: d.SubscriptionID,
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		LastStatusCode: d.LastStatusCode,
+		LastError:      d.LastError,
+		DeliveredAt:    d.DeliveredAt,
+		Payload:        json.RawMessage(d.Payload),
+		CreatedAt:      d.CreatedAt,
+	}
+}
@@ -0,0 +1,172 @@
+package webhook
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Repository interface {
+	CreateSubscription(subscription *Subscription) error
+	FindSubscriptions() ([]Subscription, error)
+	FindActiveSubscriptions() ([]Subscription, error)
+	FindSubscriptionByID(id uint) (*Subscription, error)
+	UpdateSubscription(subscription *Subscription) error
+	DeleteSubscription(id uint) error
+	// RecordSuccess resets the failure count of a subscription.
+	RecordSuccess(subscriptionID uint) error
+	// RecordFailure counts a failed attempt and disables the subscription
+	// once maxFailures attempts in a row have failed. It reports whether the
+	// subscription was disabled by this call.
+	RecordFailure(subscriptionID uint, maxFailures int, at time.Time) (bool, error)
+
+	CreateDeliveries(deliveries []Delivery) error
+	FindDeliveryByID(id uint) (*Delivery, error)
+	FindDeliveries(subscriptionID uint, offset, limit int) ([]Delivery, int64, error)
+	// FindDue returns pending deliveries of active subscriptions whose next
+	// attempt is due, the oldest first.
+	FindDue(now time.Time, limit int) ([]Delivery, error)
+	// Claim moves the next attempt of a due delivery to until, so other
+	// dispatchers skip it while it is attempted. It returns false when
+	// another dispatcher claimed it first.
+	Claim(delivery *Delivery, until time.Time) (bool, error)
+	// SaveAttempt writes the outcome of an attempt.
+	SaveAttempt(delivery *Delivery) error
+}
+
+type repository struct {
+	db *gorm.DB
+}
+
+func NewRepository(db *gorm.DB) Repository {
+	return &repository{db: db}
+}
+
+func (r *repository) CreateSubscription(subscription *Subscription) error {
+	return r.db.Create(subscription).Error
+}
+
+func (r *repository) FindSubscriptions() ([]Subscription, error) {
+	var subscriptions []Subscription
+	err := r.db.Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *repository) FindActiveSubscriptions() ([]Subscription, error) {
+	var subscriptions []Subscription
+	err := r.db.Where("active = ?", true).Order("id").Find(&subscriptions).Error
+	return subscriptions, err
+}
+
+func (r *repository) FindSubscriptionByID(id uint) (*Subscription, error) {
+	var subscription Subscription
+	if err := r.db.First(&subscription, id).Error; err != nil {
+		return nil, err
+	}
+	return &subscription, nil
+}
+
+func (r *repository) UpdateSubscription(subscription *Subscription) error {
+	subscription.UpdatedAt = time.Now()
+	return r.db.Model(&Subscription{}).Where("id = ?", subscription.ID).UpdateColumns(map[string]interface{}{
+		"url":                  subscription.URL,
+		"secret":               subscription.Secret,
+		"events":               subscription.Events,
+		"description":          subscription.Description,
+		"active":               subscription.Active,
+		"consecutive_failures": subscription.ConsecutiveFailures,
+		"disabled_at":          subscription.DisabledAt,
+		"updated_at":           subscription.UpdatedAt,
+	}).Error
+}
+
+func (r *repository) DeleteSubscription(id uint) error {
+	return r.db.Delete(&Subscription{}, id).Error
+}
+
+func (r *repository) RecordSuccess(subscriptionID uint) error {
+	return r.db.Model(&Subscription{}).
+		Where("id = ? AND consecutive_failures > 0", subscriptionID).
+		UpdateColumn("consecutive_failures", 0).Error
+}
+
+func (r *repository) RecordFailure(subscriptionID uint, maxFailures int, at time.Time) (bool, error) {
+	err := r.db.Model(&Subscription{}).
+		Where("id = ?", subscriptionID).
+		UpdateColumn("consecutive_failures", gorm.Expr("consecutive_failures + 1")).Error
+	if err != nil || maxFailures <= 0 {
+		return false, err
+	}
+
+	result := r.db.Model(&Subscription{}).
+		Where("id = ? AND active = ? AND consecutive_failures >= ?", subscriptionID, true, maxFailures).
+		UpdateColumns(map[string]interface{}{
+			"active":      false,
+			"disabled_at": at,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *repository) CreateDeliveries(deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return r.db.Omit(clause.Associations).Create(&deliveries).Error
+}
+
+func (r *repository) FindDeliveryByID(id uint) (*Delivery, error) {
+	var delivery Delivery
+	if err := r.db.First(&delivery, id).Error; err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+func (r *repository) FindDeliveries(subscriptionID uint, offset, limit int) ([]Delivery, int64, error) {
+	query := r.db.Model(&Delivery{}).Where("subscription_id = ?", subscriptionID).Session(&gorm.Session{})
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var deliveries []Delivery
+	err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
+
+func (r *repository) FindDue(now time.Time, limit int) ([]Delivery, error) {
+	var deliveries []Delivery
+	err := r.db.Preload("Subscription").
+		Joins("JOIN webhook_subscriptions ON webhook_subscriptions.id = webhook_deliveries.subscription_id").
+		Where("webhook_deliveries.status = ? AND webhook_deliveries.next_attempt_at <= ?", DeliveryPending, now).
+		Where("webhook_subscriptions.active = ?", true).
+		Order("webhook_deliveries.next_attempt_at ASC, webhook_deliveries.id ASC").
+		Limit(limit).
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *repository) Claim(delivery *Delivery, until time.Time) (bool, error) {
+	result := r.db.Model(&Delivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", delivery.ID, DeliveryPending, delivery.NextAttemptAt).
+		UpdateColumn("next_attempt_at", until)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *repository) SaveAttempt(delivery *Delivery) error {
+	delivery.UpdatedAt = time.Now()
+	return r.db.Model(&Delivery{}).Where("id = ?", delivery.ID).UpdateColumns(map[string]interface{}{
+		"status":           delivery.Status,
+		"attempts":         delivery.Attempts,
+		"next_attempt_at":  delivery.NextAttemptAt,
+		"last_status_code": delivery.LastStatusCode,
+		"last_error":       delivery.LastError,
+		"delivered_at":     delivery.DeliveredAt,
+		"updated_at":       delivery.UpdatedAt,
+	}).Error
+}
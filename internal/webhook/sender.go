@@ -0,0 +1,106 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery.
+const (
+	HeaderEventID   = "X-Webhook-ID"
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// maxRetryDelay caps the exponential backoff between attempts.
+const maxRetryDelay = 12 * time.Hour
+
+// Sign returns the signature header value of a body sent at timestamp: the
+// hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret.
+// Receivers recompute it and reject old timestamps to stop replays.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%d.", timestamp)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature made by Sign and that timestamp is at most
+// tolerance away from now.
+func Verify(secret, signature string, timestamp int64, body []byte, tolerance time.Duration, now time.Time) bool {
+	sent := time.Unix(timestamp, 0)
+	if now.Sub(sent) > tolerance || sent.Sub(now) > tolerance {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, timestamp, body)))
+}
+
+// Backoff returns the delay before the attempt after the given number of
+// failed attempts: base, then doubled for every further failure.
+func Backoff(base time.Duration, failedAttempts int) time.Duration {
+	delay := base
+	for i := 1; i < failedAttempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// Sender posts signed payloads to subscribers.
+type Sender struct {
+	client *http.Client
+}
+
+func NewSender(timeout time.Duration) *Sender {
+	return &Sender{
+		client: &http.Client{
+			Timeout: timeout,
+			// Redirects are not followed so a subscriber cannot point
+			// deliveries somewhere the admin did not configure.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send delivers body to url. Any 2xx response is a success; other responses
+// return their status code together with an error.
+func (s *Sender) Send(ctx context.Context, url, secret, eventID, eventType string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "go-template-webhooks/1.0")
+	req.Header.Set(HeaderEventID, eventID)
+	req.Header.Set(HeaderEvent, eventType)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Reading a bounded part of the body lets the connection be reused.
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
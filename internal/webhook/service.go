@@ -0,0 +1,377 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/common/pagination"
+	"gorm.io/gorm"
+)
+
+// batchSize is the number of due deliveries loaded per DeliverDue call.
+const batchSize = 50
+
+// Trigger is told when deliveries were queued so they are attempted without
+// waiting for the next poll. It is implemented by Dispatcher.
+type Trigger interface {
+	Trigger()
+}
+
+// Settings control retries and failure handling.
+type Settings struct {
+	// MaxAttempts is the number of attempts before a delivery fails.
+	MaxAttempts int
+	// RetryBase is the delay after the first failed attempt. It doubles
+	// with every further failure.
+	RetryBase time.Duration
+	// DisableAfter disables a subscription after this many failed attempts
+	// in a row. Zero never disables.
+	DisableAfter int
+	// Timeout limits one attempt.
+	Timeout time.Duration
+}
+
+type Service interface {
+	CreateSubscription(dto CreateSubscriptionDTO) (*SubscriptionResponse, apperror.AppErrors)
+	GetSubscriptions() ([]SubscriptionResponse, apperror.AppErrors)
+	GetSubscription(id uint) (*SubscriptionResponse, apperror.AppErrors)
+	UpdateSubscription(id uint, dto UpdateSubscriptionDTO) (*SubscriptionResponse, apperror.AppErrors)
+	DeleteSubscription(id uint) apperror.AppErrors
+	GetDeliveries(subscriptionID uint, params pagination.Params) ([]DeliveryResponse, *pagination.Meta, apperror.AppErrors)
+	// Redeliver queues the payload of a delivery again under the same event
+	// ID.
+	Redeliver(subscriptionID, deliveryID uint) (*DeliveryResponse, apperror.AppErrors)
+
+	// Enqueue queues a delivery of the event for every active subscription
+	// to its type. eventID is sent to receivers so they can skip events
+	// they already received.
+	Enqueue(eventID, eventType string, data interface{}) error
+	// DeliverDue attempts the deliveries that are due and returns how many
+	// it attempted. It is called by the Dispatcher.
+	DeliverDue(ctx context.Context) (int, error)
+}
+
+type service struct {
+	repo     Repository
+	sender   *Sender
+	trigger  Trigger
+	settings Settings
+}
+
+func NewService(repo Repository, trigger Trigger, settings Settings) Service {
+	return &service{
+		repo:     repo,
+		sender:   NewSender(settings.Timeout),
+		trigger:  trigger,
+		settings: settings,
+	}
+}
+
+func (s *service) CreateSubscription(dto CreateSubscriptionDTO) (*SubscriptionResponse, apperror.AppErrors) {
+	secret := dto.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newSecret(); err != nil {
+			return nil, apperror.DatabaseError(err)
+		}
+	}
+
+	subscription := &Subscription{
+		URL:         dto.URL,
+		Secret:      secret,
+		Events:      joinEvents(dto.Events),
+		Description: dto.Description,
+		Active:      true,
+	}
+	if err := s.repo.CreateSubscription(subscription); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	response := subscription.ToResponse()
+	response.Secret = secret
+	return response, nil
+}
+
+func (s *service) GetSubscriptions() ([]SubscriptionResponse, apperror.AppErrors) {
+	subscriptions, err := s.repo.FindSubscriptions()
+	if err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]SubscriptionResponse, 0, len(subscriptions))
+	for i := range subscriptions {
+		responses = append(responses, *subscriptions[i].ToResponse())
+	}
+	return responses, nil
+}
+
+func (s *service) GetSubscription(id uint) (*SubscriptionResponse, apperror.AppErrors) {
+	subscription, appErr := s.findSubscription(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+	return subscription.ToResponse(), nil
+}
+
+func (s *service) UpdateSubscription(id uint, dto UpdateSubscriptionDTO) (*SubscriptionResponse, apperror.AppErrors) {
+	subscription, appErr := s.findSubscription(id)
+	if appErr != nil {
+		return nil, appErr
+	}
+
+	if dto.URL != "" {
+		subscription.URL = dto.URL
+	}
+	if dto.Secret != "" {
+		subscription.Secret = dto.Secret
+	}
+	if dto.Events != nil {
+		subscription.Events = joinEvents(dto.Events)
+	}
+	if dto.Description != nil {
+		subscription.Description = *dto.Description
+	}
+	if dto.Active != nil {
+		if *dto.Active && !subscription.Active {
+			subscription.ConsecutiveFailures = 0
+			subscription.DisabledAt = nil
+		}
+		subscription.Active = *dto.Active
+	}
+
+	if err := s.repo.UpdateSubscription(subscription); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	// Deliveries held back while the subscription was disabled are due.
+	if subscription.Active {
+		s.trigger.Trigger()
+	}
+
+	return subscription.ToResponse(), nil
+}
+
+func (s *service) DeleteSubscription(id uint) apperror.AppErrors {
+	if _, appErr := s.findSubscription(id); appErr != nil {
+		return appErr
+	}
+	if err := s.repo.DeleteSubscription(id); err != nil {
+		return apperror.DatabaseError(err)
+	}
+	return nil
+}
+
+func (s *service) GetDeliveries(subscriptionID uint, params pagination.Params) ([]DeliveryResponse, *pagination.Meta, apperror.AppErrors) {
+	if _, appErr := s.findSubscription(subscriptionID); appErr != nil {
+		return nil, nil, appErr
+	}
+
+	params = params.Normalize()
+	deliveries, total, err := s.repo.FindDeliveries(subscriptionID, params.Offset(), params.Limit())
+	if err != nil {
+		return nil, nil, apperror.DatabaseError(err)
+	}
+
+	responses := make([]DeliveryResponse, 0, len(deliveries))
+	for i := range deliveries {
+		responses = append(responses, *deliveries[i].ToResponse())
+	}
+	return responses, pagination.NewMeta(params, total), nil
+}
+
+func (s *service) Redeliver(subscriptionID, deliveryID uint) (*DeliveryResponse, apperror.AppErrors) {
+	subscription, appErr := s.findSubscription(subscriptionID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if !subscription.Active {
+		return nil, apperror.WebhookDisabled()
+	}
+
+	original, err := s.repo.FindDeliveryByID(deliveryID)
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, apperror.DatabaseError(err)
+	}
+	if original == nil || original.SubscriptionID != subscriptionID {
+		return nil, apperror.DeliveryNotFound()
+	}
+
+	now := time.Now()
+	deliveries := []Delivery{{
+		SubscriptionID: subscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         DeliveryPending,
+		NextAttemptAt:  &now,
+	}}
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return nil, apperror.DatabaseError(err)
+	}
+	s.trigger.Trigger()
+
+	return deliveries[0].ToResponse(), nil
+}
+
+func (s *service) findSubscription(id uint) (*Subscription, apperror.AppErrors) {
+	subscription, err := s.repo.FindSubscriptionByID(id)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperror.WebhookNotFound()
+		}
+		return nil, apperror.DatabaseError(err)
+	}
+	return subscription, nil
+}
+
+func (s *service) Enqueue(eventID, eventType string, data interface{}) error {
+	subscriptions, err := s.repo.FindActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+
+	var deliveries []Delivery
+	for i := range subscriptions {
+		if subscriptions[i].Wants(eventType) {
+			deliveries = append(deliveries, Delivery{SubscriptionID: subscriptions[i].ID})
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(Payload{ID: eventID, Type: eventType, CreatedAt: now, Data: data})
+	if err != nil {
+		return err
+	}
+
+	for i := range deliveries {
+		deliveries[i] = Delivery{
+			SubscriptionID: deliveries[i].SubscriptionID,
+			EventID:        eventID,
+			EventType:      eventType,
+			Payload:        string(payload),
+			Status:         DeliveryPending,
+			NextAttemptAt:  &now,
+		}
+	}
+
+	if err := s.repo.CreateDeliveries(deliveries); err != nil {
+		return err
+	}
+	s.trigger.Trigger()
+	return nil
+}
+
+func (s *service) DeliverDue(ctx context.Context) (int, error) {
+	deliveries, err := s.repo.FindDue(time.Now(), batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	attempted := 0
+	for i := range deliveries {
+		if ctx.Err() != nil {
+			break
+		}
+		// The lease outlasts the attempt, so a crashed dispatcher's claim
+		// expires and the delivery is retried.
+		claimed, err := s.repo.Claim(&deliveries[i], time.Now().Add(2*s.settings.Timeout))
+		if err != nil {
+			return attempted, err
+		}
+		if !claimed {
+			continue
+		}
+		s.attempt(ctx, &deliveries[i])
+		attempted++
+	}
+	return attempted, nil
+}
+
+// attempt sends a delivery once and records the outcome. Failures of the
+// receiver are part of the outcome; only failures to record it are logged.
+func (s *service) attempt(ctx context.Context, delivery *Delivery) {
+	subscription := &delivery.Subscription
+	statusCode, sendErr := s.sender.Send(ctx, subscription.URL, subscription.Secret,
+		delivery.EventID, delivery.EventType, []byte(delivery.Payload))
+	if sendErr != nil && ctx.Err() != nil {
+		// Cut short by shutdown, which is not the receiver's fault. Saving
+		// the unchanged delivery releases the claim for the next start.
+		if err := s.repo.SaveAttempt(delivery); err != nil {
+			log.Printf("webhook: failed to release delivery %d: %v", delivery.ID, err)
+		}
+		return
+	}
+
+	now := time.Now()
+	delivery.Attempts++
+	delivery.LastStatusCode = statusCode
+	delivery.LastError = ""
+	if sendErr == nil {
+		delivery.Status = DeliverySucceeded
+		delivery.NextAttemptAt = nil
+		delivery.DeliveredAt = &now
+	} else {
+		delivery.LastError = truncate(sendErr.Error(), 500)
+		if delivery.Attempts >= s.settings.MaxAttempts {
+			delivery.Status = DeliveryFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			next := now.Add(Backoff(s.settings.RetryBase, delivery.Attempts))
+			delivery.NextAttemptAt = &next
+		}
+	}
+
+	if err := s.repo.SaveAttempt(delivery); err != nil {
+		log.Printf("webhook: failed to record delivery %d: %v", delivery.ID, err)
+		return
+	}
+
+	if sendErr == nil {
+		if err := s.repo.RecordSuccess(subscription.ID); err != nil {
+			log.Printf("webhook: failed to reset failures of subscription %d: %v", subscription.ID, err)
+		}
+		return
+	}
+	disabled, err := s.repo.RecordFailure(subscription.ID, s.settings.DisableAfter, now)
+	if err != nil {
+		log.Printf("webhook: failed to count failure of subscription %d: %v", subscription.ID, err)
+	}
+	if disabled {
+		log.Printf("webhook: disabled subscription %d after %d failed attempts", subscription.ID, s.settings.DisableAfter)
+	}
+}
+
+func joinEvents(events []string) string {
+	seen := make(map[string]bool, len(events))
+	unique := make([]string, 0, len(events))
+	for _, event := range events {
+		if !seen[event] {
+			seen[event] = true
+			unique = append(unique, event)
+		}
+	}
+	return strings.Join(unique, ",")
+}
+
+func newSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(secret), nil
+}
+
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	return value[:max]
+}
@@ -1,6 +1,8 @@
 package database
 
 import (
+	"context"
+
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
@@ -13,3 +15,21 @@ func NewDatabase(dsn string) (*gorm.DB, error) {
 
 	return db, nil
 }
+
+// Ping checks that the database answers within the deadline of ctx.
+func Ping(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// Close closes the connection pool of db.
+func Close(db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
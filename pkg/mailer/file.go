@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+type fileMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+}
+
+// NewFileMailer writes every message as an .eml file into dir instead of
+// sending it, so mail can be inspected during development with any mail
+// client.
+func NewFileMailer(dir, from string) (Mailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &fileMailer{dir: dir, from: from, now: time.Now}, nil
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg *Message) error {
+	now := m.now()
+	data, err := Build(withFrom(msg, m.from), now)
+	if err != nil {
+		return err
+	}
+
+	random := make([]byte, 4)
+	_, _ = rand.Read(random)
+	name := fmt.Sprintf("%s-%s.eml", now.UTC().Format("20060102T150405.000Z"), hex.EncodeToString(random))
+
+	// Written under a temporary name first so readers never see a partial
+	// file.
+	tmp, err := os.CreateTemp(m.dir, ".mail-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(m.dir, name))
+}
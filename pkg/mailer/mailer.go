@@ -0,0 +1,153 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message is an email with a plain text and an HTML body. At least one of
+// the bodies is set.
+type Message struct {
+	From    string   `json:"from,omitempty"`
+	To      []string `json:"to"`
+	ReplyTo string   `json:"reply_to,omitempty"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text,omitempty"`
+	HTML    string   `json:"html,omitempty"`
+}
+
+// Mailer sends messages. Implementations fill in From when it is empty.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Validate checks the addresses and that the message has a body.
+func (m *Message) Validate() error {
+	if len(m.To) == 0 {
+		return errors.New("mailer: message has no recipients")
+	}
+	for _, address := range append([]string{m.From}, m.To...) {
+		if _, err := mail.ParseAddress(address); err != nil {
+			return fmt.Errorf("mailer: invalid address %q", address)
+		}
+	}
+	if m.ReplyTo != "" {
+		if _, err := mail.ParseAddress(m.ReplyTo); err != nil {
+			return fmt.Errorf("mailer: invalid address %q", m.ReplyTo)
+		}
+	}
+	if m.Text == "" && m.HTML == "" {
+		return errors.New("mailer: message has no body")
+	}
+	return nil
+}
+
+// Build encodes the message as a MIME document ready to be sent over SMTP
+// or saved as an .eml file. A message with both bodies is sent as
+// multipart/alternative so clients pick the one they can show.
+func Build(msg *Message, now time.Time) ([]byte, error) {
+	if err := msg.Validate(); err != nil {
+		return nil, err
+	}
+	from, _ := mail.ParseAddress(msg.From)
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	to := make([]string, len(msg.To))
+	for i, address := range msg.To {
+		parsed, _ := mail.ParseAddress(address)
+		to[i] = parsed.String()
+	}
+	header("To", strings.Join(to, ", "))
+	if msg.ReplyTo != "" {
+		replyTo, _ := mail.ParseAddress(msg.ReplyTo)
+		header("Reply-To", replyTo.String())
+	}
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain", msg.Text
+		if msg.Text == "" {
+			contentType, body = "text/html", msg.HTML
+		}
+		header("Content-Type", contentType+"; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	header("Content-Type", "multipart/alternative; boundary="+parts.Boundary())
+	buf.WriteString("\r\n")
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", msg.Text},
+		{"text/html", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType + "; charset=utf-8"},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, host, ok := strings.Cut(from, "@"); ok {
+		domain = host
+	}
+	random := make([]byte, 12)
+	_, _ = rand.Read(random)
+	return "<" + hex.EncodeToString(random) + "@" + domain + ">"
+}
+
+// withFrom returns msg with From set to from when it is empty, leaving msg
+// untouched.
+func withFrom(msg *Message, from string) *Message {
+	if msg.From != "" {
+		return msg
+	}
+	copied := *msg
+	copied.From = from
+	return &copied
+}
@@ -0,0 +1,55 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer keeps sent messages in memory. It is meant for tests.
+type MemoryMailer struct {
+	from string
+
+	mu       sync.Mutex
+	messages []Message
+	err      error
+}
+
+func NewMemoryMailer(from string) *MemoryMailer {
+	return &MemoryMailer{from: from}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, msg *Message) error {
+	msg = withFrom(msg, m.from)
+	if err := msg.Validate(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// Messages returns the messages sent so far, oldest first.
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.messages...)
+}
+
+// Fail makes Send return err until it is called again with nil.
+func (m *MemoryMailer) Fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.err = err
+}
+
+// Reset forgets the sent messages.
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = nil
+}
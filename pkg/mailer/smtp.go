@@ -0,0 +1,135 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+const (
+	// TLSNone sends in plain text, e.g. to a local catch-all server.
+	TLSNone = "none"
+	// TLSStartTLS upgrades the connection with STARTTLS and fails when the
+	// server does not offer it.
+	TLSStartTLS = "starttls"
+	// TLSImplicit connects over TLS right away, usually on port 465.
+	TLSImplicit = "tls"
+)
+
+// SMTPConfig configures delivery through an SMTP server.
+type SMTPConfig struct {
+	Host string
+	Port int
+	// Username and Password are used for PLAIN authentication when the
+	// username is set.
+	Username string
+	Password string
+	// TLS is one of TLSNone, TLSStartTLS and TLSImplicit.
+	TLS  string
+	From string
+	// Timeout bounds the whole delivery unless the context ends first.
+	Timeout time.Duration
+}
+
+type smtpMailer struct {
+	cfg SMTPConfig
+	now func() time.Time
+}
+
+func NewSMTPMailer(cfg SMTPConfig) (Mailer, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("mailer: SMTP host is required")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("mailer: invalid from address %q", cfg.From)
+	}
+	switch cfg.TLS {
+	case TLSNone, TLSStartTLS, TLSImplicit:
+	default:
+		return nil, fmt.Errorf("mailer: unknown SMTP TLS mode %q", cfg.TLS)
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 30 * time.Second
+	}
+	return &smtpMailer{cfg: cfg, now: time.Now}, nil
+}
+
+func (m *smtpMailer) Send(ctx context.Context, msg *Message) error {
+	msg = withFrom(msg, m.cfg.From)
+	data, err := Build(msg, m.now())
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.Timeout)
+	defer cancel()
+
+	conn, err := m.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("mailer: connecting to %s: %w", m.cfg.Host, err)
+	}
+	// The deadline covers the whole SMTP conversation, which net/smtp does
+	// not bound on its own.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("mailer: %w", err)
+	}
+	defer client.Close()
+
+	if m.cfg.TLS == TLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("mailer: %s does not support STARTTLS", m.cfg.Host)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return fmt.Errorf("mailer: STARTTLS: %w", err)
+		}
+	}
+	if m.cfg.Username != "" {
+		auth := smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("mailer: authentication: %w", err)
+		}
+	}
+
+	from, _ := mail.ParseAddress(msg.From)
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("mailer: MAIL FROM: %w", err)
+	}
+	for _, address := range msg.To {
+		to, _ := mail.ParseAddress(address)
+		if err := client.Rcpt(to.Address); err != nil {
+			return fmt.Errorf("mailer: RCPT TO %s: %w", to.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("mailer: DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("mailer: DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("mailer: DATA: %w", err)
+	}
+	return client.Quit()
+}
+
+func (m *smtpMailer) dial(ctx context.Context) (net.Conn, error) {
+	address := net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port))
+	if m.cfg.TLS == TLSImplicit {
+		dialer := &tls.Dialer{Config: &tls.Config{ServerName: m.cfg.Host}}
+		return dialer.DialContext(ctx, "tcp", address)
+	}
+	var dialer net.Dialer
+	return dialer.DialContext(ctx, "tcp", address)
+}
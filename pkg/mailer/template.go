@@ -0,0 +1,132 @@
+package mailer
+
+import (
+	"bytes"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+// Templates renders messages from templates named "<name>.<locale>.txt" and
+// "<name>.<locale>.html", e.g. "welcome.en.txt". The text template is
+// required and defines the subject with {{define "subject"}}; the HTML
+// template is optional. Locales are lower case, like "en" or "pt-br".
+type Templates struct {
+	defaultLocale string
+	text          map[string]*texttemplate.Template
+	html          map[string]*htmltemplate.Template
+}
+
+// ParseTemplates parses the templates at the root of fsys. Messages in a
+// locale without its own variant fall back to the base language and then to
+// defaultLocale.
+func ParseTemplates(fsys fs.FS, defaultLocale string) (*Templates, error) {
+	t := &Templates{
+		defaultLocale: normalizeLocale(defaultLocale),
+		text:          make(map[string]*texttemplate.Template),
+		html:          make(map[string]*htmltemplate.Template),
+	}
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		file := entry.Name()
+		ext := path.Ext(file)
+		if ext != ".txt" && ext != ".html" {
+			continue
+		}
+		name, locale, ok := strings.Cut(strings.TrimSuffix(file, ext), ".")
+		if !ok || name == "" || locale != normalizeLocale(locale) {
+			return nil, fmt.Errorf("mailer: template %s is not named <name>.<locale>%s", file, ext)
+		}
+
+		content, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		key := name + "." + locale
+		if ext == ".txt" {
+			tmpl, err := texttemplate.New(file).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("mailer: %w", err)
+			}
+			if tmpl.Lookup("subject") == nil {
+				return nil, fmt.Errorf("mailer: template %s does not define a subject", file)
+			}
+			t.text[key] = tmpl
+		} else {
+			tmpl, err := htmltemplate.New(file).Option("missingkey=error").Parse(string(content))
+			if err != nil {
+				return nil, fmt.Errorf("mailer: %w", err)
+			}
+			t.html[key] = tmpl
+		}
+	}
+
+	for key := range t.html {
+		if _, ok := t.text[key]; !ok {
+			return nil, fmt.Errorf("mailer: template %s.html has no text variant", key)
+		}
+	}
+	return t, nil
+}
+
+// Render renders the message name in locale. The recipients are left to the
+// caller.
+func (t *Templates) Render(name, locale string, data interface{}) (*Message, error) {
+	for _, candidate := range t.locales(locale) {
+		key := name + "." + candidate
+		text, ok := t.text[key]
+		if !ok {
+			continue
+		}
+
+		msg := &Message{}
+		var buf bytes.Buffer
+		if err := text.ExecuteTemplate(&buf, "subject", data); err != nil {
+			return nil, fmt.Errorf("mailer: rendering %s: %w", key, err)
+		}
+		msg.Subject = strings.Join(strings.Fields(buf.String()), " ")
+
+		buf.Reset()
+		if err := text.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("mailer: rendering %s: %w", key, err)
+		}
+		msg.Text = strings.TrimSpace(buf.String()) + "\n"
+
+		if html, ok := t.html[key]; ok {
+			buf.Reset()
+			if err := html.Execute(&buf, data); err != nil {
+				return nil, fmt.Errorf("mailer: rendering %s: %w", key, err)
+			}
+			msg.HTML = buf.String()
+		}
+		return msg, nil
+	}
+	return nil, fmt.Errorf("mailer: unknown template %q", name)
+}
+
+// locales lists the variants tried for locale, most specific first.
+func (t *Templates) locales(locale string) []string {
+	locale = normalizeLocale(locale)
+	var candidates []string
+	if locale != "" {
+		candidates = append(candidates, locale)
+		if base, _, ok := strings.Cut(locale, "-"); ok {
+			candidates = append(candidates, base)
+		}
+	}
+	return append(candidates, t.defaultLocale)
+}
+
+func normalizeLocale(locale string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(locale), "_", "-"))
+}
@@ -0,0 +1,80 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+const (
+	FormatPlain    = "plain"
+	FormatMarkdown = "markdown"
+)
+
+var (
+	renderer = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+		// Raw HTML is passed through here and filtered by policy below, so
+		// authors can use the safe subset of inline HTML.
+		goldmark.WithRendererOptions(goldmarkhtml.WithUnsafe()),
+	)
+
+	policy = newPolicy()
+)
+
+// newPolicy builds the allow-list used for every rendered post. Anything not
+// explicitly allowed, such as script and style elements, on* event handler
+// attributes and javascript: or data: URLs, is removed.
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowURLSchemes("http", "https", "mailto")
+	p.RequireParseableURLs(true)
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	p.AllowAttrs("class").Matching(bluemonday.SpaceSeparatedTokens).OnElements("code")
+	p.AllowAttrs("type", "checked", "disabled").OnElements("input")
+	return p
+}
+
+// Render converts source in the given format to sanitised HTML. Unknown
+// formats are treated as plain text.
+func Render(format, source string) (string, error) {
+	if format != FormatMarkdown {
+		return Sanitize(renderPlain(source)), nil
+	}
+
+	var buf bytes.Buffer
+	if err := renderer.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+
+	return Sanitize(buf.String()), nil
+}
+
+// Sanitize strips everything from s that is not allowed by the policy.
+func Sanitize(s string) string {
+	return policy.Sanitize(s)
+}
+
+// renderPlain escapes the text and keeps its paragraphs and line breaks.
+func renderPlain(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(source, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		lines := strings.Split(html.EscapeString(paragraph), "\n")
+		b.WriteString("<p>")
+		b.WriteString(strings.Join(lines, "<br>"))
+		b.WriteString("</p>\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,116 @@
+package slug
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the maximum length of a slug produced by Make, not counting
+// the collision suffix added by Unique.
+const MaxLength = 80
+
+// transliterations covers letters that do not decompose into an ASCII base
+// letter under NFKD, including the Cyrillic and Greek alphabets.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'þ': "th",
+	'ł': "l", 'ı': "i", 'ħ': "h", 'ŧ': "t", 'ŋ': "ng", 'ĸ': "k",
+
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Make converts s into a lowercase, hyphen separated ASCII slug of at most
+// MaxLength characters. Accented letters are reduced to their base letter,
+// Cyrillic and Greek are transliterated and any other character that is not
+// a letter or digit acts as a separator.
+func Make(s string) string {
+	return MakeMax(s, MaxLength)
+}
+
+// MakeMax is Make for slugs stored in columns shorter than MaxLength.
+func MakeMax(s string, max int) string {
+	var b strings.Builder
+	pendingDash := false
+
+	write := func(r rune) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if pendingDash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			pendingDash = false
+			b.WriteRune(r)
+			return
+		}
+		pendingDash = true
+	}
+
+	for _, r := range norm.NFKD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+
+		r = unicode.ToLower(r)
+		if replacement, ok := transliterations[r]; ok {
+			for _, t := range replacement {
+				write(t)
+			}
+			continue
+		}
+		write(r)
+	}
+
+	return truncate(b.String(), max)
+}
+
+// Unique returns base if it is not taken, otherwise the first candidate of
+// base-2, base-3 and so on that is not. taken holds the slugs in use that
+// have base as their base (see HasBase); others are ignored.
+func Unique(base string, taken []string) string {
+	used := make(map[string]bool, len(taken))
+	for _, s := range taken {
+		used[s] = true
+	}
+
+	candidate := base
+	for i := 2; used[candidate]; i++ {
+		candidate = base + "-" + strconv.Itoa(i)
+	}
+	return candidate
+}
+
+// HasBase reports whether s equals base or is base with a numeric collision
+// suffix as produced by Unique.
+func HasBase(s, base string) bool {
+	if s == base {
+		return true
+	}
+	suffix := strings.TrimPrefix(s, base+"-")
+	if suffix == s || suffix == "" {
+		return false
+	}
+	_, err := strconv.Atoi(suffix)
+	return err == nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	if i := strings.LastIndexByte(s, '-'); i > 0 {
+		s = s[:i]
+	}
+	return strings.TrimSuffix(s, "-")
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned by Open when the object does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// Storage stores uploaded files under slash separated keys such as
+// "posts/12/3f9a.png".
+type Storage interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object. Deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// URL returns the address clients can download the object from.
+	URL(key string) string
+}
+
+// CleanKey normalises a key and rejects keys that would escape the storage
+// root.
+func CleanKey(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", errors.New("storage: invalid key")
+	}
+	return strings.TrimPrefix(cleaned, "/"), nil
+}
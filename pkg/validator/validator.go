@@ -39,6 +39,8 @@ func formatErrorMessage(e validator.FieldError) string {
 		return fmt.Sprintf("The %s must be at least %s characters", field, e.Param())
 	case "max":
 		return fmt.Sprintf("The %s must not exceed %s characters", field, e.Param())
+	case "oneof":
+		return fmt.Sprintf("The %s must be one of: %s", field, strings.ReplaceAll(e.Param(), " ", ", "))
 	case "eqfield":
 		return fmt.Sprintf("The %s must match %s", field, toSnakeCase(e.Param()))
 	default:
@@ -46,13 +48,23 @@ func formatErrorMessage(e validator.FieldError) string {
 	}
 }
 
+// toSnakeCase keeps acronyms together, so ExternalID becomes external_id.
 func toSnakeCase(str string) string {
+	runes := []rune(str)
 	var result strings.Builder
-	for i, r := range str {
-		if i > 0 && r >= 'A' && r <= 'Z' {
-			result.WriteRune('_')
+	for i, r := range runes {
+		if i > 0 && isUpper(r) {
+			prevLower := !isUpper(runes[i-1])
+			nextLower := i+1 < len(runes) && !isUpper(runes[i+1])
+			if prevLower || nextLower {
+				result.WriteRune('_')
+			}
 		}
 		result.WriteRune(r)
 	}
 	return strings.ToLower(result.String())
 }
+
+func isUpper(r rune) bool {
+	return r >= 'A' && r <= 'Z'
+}
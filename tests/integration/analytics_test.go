@@ -0,0 +1,101 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/analytics"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostStats(t *testing.T) {
+	setupTestDB(t)
+	testConfig.TrustedProxies = nil
+	setupTestRouter()
+
+	_, authorToken := registerUser(t, "Author User", "author@example.com", "user")
+	_, readerToken := registerUser(t, "Reader User", "reader@example.com", "user")
+
+	p := createPost(t, authorToken, map[string]interface{}{
+		"title":   "Counted Post",
+		"content": "Every distinct viewer is counted once.",
+	})
+	path := postPath(p)
+	postID := uint(p["id"].(float64))
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	daysAgo := func(n int) time.Time { return today.AddDate(0, 0, -n) }
+	testDB.Create(&[]analytics.PostViewDay{
+		{PostID: postID, Day: daysAgo(2), Views: 5},
+		{PostID: postID, Day: daysAgo(10), Views: 3},
+		{PostID: postID, Day: daysAgo(40), Views: 7},
+	})
+
+	view := func(token, remoteAddr, forwardedFor string) {
+		req := newRequest("GET", path, token, nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w, _ := serve(req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	stats := func(t *testing.T, query string) map[string]interface{} {
+		w, response := send("GET", path+"/stats"+query, authorToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return response["data"].(map[string]interface{})
+	}
+	daily := func(data map[string]interface{}) map[string]float64 {
+		views := make(map[string]float64)
+		for _, d := range data["daily"].([]interface{}) {
+			d := d.(map[string]interface{})
+			views[d["date"].(string)] = d["views"].(float64)
+		}
+		return views
+	}
+
+	// The reader and two addresses count once each; the author, repeated
+	// views and forged X-Forwarded-For headers do not count.
+	view(readerToken, "10.0.0.1:1234", "")
+	view(readerToken, "10.0.0.2:1234", "")
+	view("", "192.0.2.1:1234", "")
+	view("", "192.0.2.1:4321", "203.0.113.9")
+	view("", "192.0.2.2:1234", "")
+	view(authorToken, "10.0.0.3:1234", "")
+
+	t.Run("Success - Views are counted per day", func(t *testing.T) {
+		data := stats(t, "?days=7")
+		assert.Equal(t, daysAgo(6).Format("2006-01-02"), data["from"])
+		assert.Equal(t, today.Format("2006-01-02"), data["to"])
+		assert.Equal(t, float64(18), data["view_count"])
+
+		views := daily(data)
+		assert.Len(t, views, 7)
+		assert.Equal(t, float64(3), views[today.Format("2006-01-02")])
+		assert.Equal(t, float64(5), views[daysAgo(2).Format("2006-01-02")])
+		assert.Equal(t, float64(0), views[daysAgo(1).Format("2006-01-02")])
+	})
+
+	t.Run("Success - Thirty days by default", func(t *testing.T) {
+		views := daily(stats(t, ""))
+		assert.Len(t, views, analytics.DefaultStatsDays)
+		assert.Equal(t, float64(3), views[daysAgo(10).Format("2006-01-02")])
+		assert.NotContains(t, views, daysAgo(40).Format("2006-01-02"))
+	})
+
+	t.Run("Success - View counts are part of the post", func(t *testing.T) {
+		_, response := send("GET", path, authorToken, nil)
+		assert.Equal(t, float64(18), response["data"].(map[string]interface{})["view_count"])
+	})
+
+	t.Run("Fail - Only the author reads the statistics", func(t *testing.T) {
+		w, _ := send("GET", path+"/stats", readerToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Fail - Too many days", func(t *testing.T) {
+		w, _ := send("GET", path+"/stats?days=400", authorToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
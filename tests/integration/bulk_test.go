@@ -0,0 +1,154 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkPosts(t *testing.T) {
+	setupTestDB(t)
+	testConfig.RequireIfMatch = true
+	testConfig.BulkMaxOperations = 3
+	setupTestRouter()
+
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+	_, otherToken := registerUser(t, "Other User", "other@example.com", "user")
+
+	mine := createPost(t, token, map[string]interface{}{
+		"title":   "My Post",
+		"content": "Changed through the bulk endpoint.",
+	})
+	theirs := createPost(t, otherToken, map[string]interface{}{
+		"title":   "Their Post",
+		"content": "Nobody else may change this post.",
+	})
+
+	bulk := func(t *testing.T, payload map[string]interface{}) (int, map[string]interface{}) {
+		w, response := send("POST", "/api/v1/posts/bulk", token, payload)
+		return w.Code, response
+	}
+	statuses := func(response map[string]interface{}) []string {
+		var statuses []string
+		for _, result := range response["data"].(map[string]interface{})["results"].([]interface{}) {
+			statuses = append(statuses, result.(map[string]interface{})["status"].(string))
+		}
+		return statuses
+	}
+	countPosts := func() int64 {
+		var count int64
+		testDB.Model(&post.Post{}).Count(&count)
+		return count
+	}
+
+	t.Run("Success - Every operation succeeds", func(t *testing.T) {
+		code, response := bulk(t, map[string]interface{}{
+			"operations": []map[string]interface{}{
+				{"op": "create", "data": map[string]interface{}{"title": "Bulk Post", "content": "Created through the bulk endpoint."}},
+				{"op": "update", "id": mine["id"], "version": 1, "data": map[string]interface{}{"title": "Renamed in Bulk"}},
+			},
+		})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"created", "updated"}, statuses(response))
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, float64(2), data["succeeded"])
+		assert.Equal(t, float64(0), data["failed"])
+	})
+
+	t.Run("Success - Best effort batches report each failure with 207", func(t *testing.T) {
+		code, response := bulk(t, map[string]interface{}{
+			"operations": []map[string]interface{}{
+				{"op": "update", "id": mine["id"], "version": 1, "data": map[string]interface{}{"title": "Stale Update"}},
+				{"op": "delete", "id": theirs["id"], "version": 1},
+				{"op": "delete", "id": mine["id"]},
+			},
+		})
+		assert.Equal(t, http.StatusMultiStatus, code)
+		assert.Equal(t, []string{"failed", "failed", "failed"}, statuses(response))
+
+		results := response["data"].(map[string]interface{})["results"].([]interface{})
+		fields := func(i int) []string {
+			return errorFields(map[string]interface{}{"error": results[i].(map[string]interface{})["errors"]})
+		}
+		assert.Equal(t, []string{"version"}, fields(0))
+		assert.Equal(t, []string{"ownership"}, fields(1))
+		assert.Equal(t, []string{"if_match"}, fields(2))
+	})
+
+	t.Run("Success - Best effort batches keep what succeeded", func(t *testing.T) {
+		before := countPosts()
+		code, response := bulk(t, map[string]interface{}{
+			"operations": []map[string]interface{}{
+				{"op": "create", "data": map[string]interface{}{"title": "Kept Post", "content": "Kept although the next one fails."}},
+				{"op": "update", "id": theirs["id"], "version": 1, "data": map[string]interface{}{"title": "Not Mine"}},
+			},
+		})
+		assert.Equal(t, http.StatusMultiStatus, code)
+		assert.Equal(t, []string{"created", "failed"}, statuses(response))
+		assert.Equal(t, before+1, countPosts())
+	})
+
+	t.Run("Fail - Atomic batches are rolled back with 422", func(t *testing.T) {
+		before := countPosts()
+		code, response := bulk(t, map[string]interface{}{
+			"atomic": true,
+			"operations": []map[string]interface{}{
+				{"op": "create", "data": map[string]interface{}{"title": "Rolled Back Post", "content": "Removed again by the rollback.", "tags": []string{"rolled-back"}}},
+				{"op": "update", "id": theirs["id"], "version": 1, "data": map[string]interface{}{"title": "Not Mine"}},
+				{"op": "delete", "id": mine["id"], "version": 2},
+			},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, code)
+		assert.Equal(t, []string{"operations"}, errorFields(response))
+		assert.Equal(t, []string{"rolled_back", "failed", "skipped"}, statuses(response))
+		assert.Equal(t, before, countPosts())
+
+		var tags int64
+		testDB.Table("tags").Where("slug = ?", "rolled-back").Count(&tags)
+		assert.Zero(t, tags)
+	})
+
+	t.Run("Success - Atomic batches see their own posts as duplicates", func(t *testing.T) {
+		operation := map[string]interface{}{"op": "create", "data": map[string]interface{}{"title": "Twin Post", "content": "Posted twice in one batch."}}
+		code, response := bulk(t, map[string]interface{}{
+			"atomic":     true,
+			"operations": []map[string]interface{}{operation, operation},
+		})
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, []string{"created", "created"}, statuses(response))
+
+		results := response["data"].(map[string]interface{})["results"].([]interface{})
+		first := results[0].(map[string]interface{})["post"].(map[string]interface{})
+		second := results[1].(map[string]interface{})["post"].(map[string]interface{})
+		assert.Nil(t, first["hidden"])
+		assert.Equal(t, true, second["hidden"])
+		assert.Equal(t, []string{"content"}, errorFields(map[string]interface{}{"error": second["review_reasons"]}))
+	})
+
+	t.Run("Fail - Invalid atomic batches are not started", func(t *testing.T) {
+		code, response := bulk(t, map[string]interface{}{
+			"atomic": true,
+			"operations": []map[string]interface{}{
+				{"op": "delete", "id": mine["id"], "version": 2},
+				{"op": "create", "data": map[string]interface{}{"title": "ab", "content": "The title is too short."}},
+			},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, code)
+		assert.Equal(t, []string{"skipped", "failed"}, statuses(response))
+
+		w, _ := send("GET", postPath(mine), "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Fail - Too many operations", func(t *testing.T) {
+		operations := make([]map[string]interface{}, 4)
+		for i := range operations {
+			operations[i] = map[string]interface{}{"op": "delete", "id": mine["id"], "version": 2}
+		}
+		code, response := bulk(t, map[string]interface{}{"operations": operations})
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{"operations"}, errorFields(response))
+	})
+}
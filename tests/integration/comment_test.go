@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/comment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComments(t *testing.T) {
+	setupTestDB(t)
+	testConfig.CommentMaxDepth = 2
+	setupTestRouter()
+
+	_, authorToken := registerUser(t, "Author User", "author@example.com", "user")
+	_, readerToken := registerUser(t, "Reader User", "reader@example.com", "user")
+
+	first := createPost(t, authorToken, map[string]interface{}{
+		"title":   "First Post",
+		"content": "Comments are threaded below.",
+	})
+	second := createPost(t, authorToken, map[string]interface{}{
+		"title":   "Second Post",
+		"content": "Another thread lives here.",
+	})
+	commentsPath := postPath(first) + "/comments"
+
+	addComment := func(t *testing.T, path string, parentID interface{}) map[string]interface{} {
+		payload := map[string]interface{}{"content": "A comment"}
+		if parentID != nil {
+			payload["parent_id"] = parentID
+		}
+		w, response := send("POST", path, readerToken, payload)
+		assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+		return response["data"].(map[string]interface{})
+	}
+
+	root := addComment(t, commentsPath, nil)
+	reply := addComment(t, commentsPath, root["id"])
+	nested := addComment(t, commentsPath, reply["id"])
+
+	t.Run("Success - Replies are nested up to the maximum depth", func(t *testing.T) {
+		assert.Equal(t, float64(0), root["depth"])
+		assert.Equal(t, float64(1), reply["depth"])
+		assert.Equal(t, float64(2), nested["depth"])
+
+		w, response := send("POST", commentsPath, readerToken, map[string]interface{}{
+			"content":   "Too deep",
+			"parent_id": nested["id"],
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"parent_id"}, errorFields(response))
+	})
+
+	t.Run("Fail - Parent comment of another post", func(t *testing.T) {
+		w, response := send("POST", postPath(second)+"/comments", readerToken, map[string]interface{}{
+			"content":   "Wrong thread",
+			"parent_id": root["id"],
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"parent_id"}, errorFields(response))
+	})
+
+	t.Run("Success - Only top level comments are listed with their reply count", func(t *testing.T) {
+		w, response := send("GET", commentsPath, "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		comments := response["data"].([]interface{})
+		assert.Len(t, comments, 1)
+		assert.Equal(t, float64(1), comments[0].(map[string]interface{})["reply_count"])
+
+		_, response = send("GET", fmt.Sprintf("/api/v1/comments/%v/replies", root["id"]), "", nil)
+		replies := response["data"].([]interface{})
+		assert.Len(t, replies, 1)
+		assert.Equal(t, reply["id"], replies[0].(map[string]interface{})["id"])
+
+		_, response = send("GET", postPath(first), "", nil)
+		assert.Equal(t, float64(3), response["data"].(map[string]interface{})["comment_count"])
+	})
+
+	t.Run("Success - Edit within the edit window", func(t *testing.T) {
+		w, response := send("PUT", fmt.Sprintf("/api/v1/comments/%v", reply["id"]), readerToken, map[string]interface{}{
+			"content": "An edited comment",
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "An edited comment", data["content"])
+		assert.NotNil(t, data["edited_at"])
+	})
+
+	t.Run("Fail - Edit by someone else", func(t *testing.T) {
+		w, _ := send("PUT", fmt.Sprintf("/api/v1/comments/%v", reply["id"]), authorToken, map[string]interface{}{
+			"content": "Not my comment",
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Fail - Edit after the edit window", func(t *testing.T) {
+		past := time.Now().Add(-time.Duration(testConfig.CommentEditWindowMinutes+1) * time.Minute)
+		testDB.Model(&comment.Comment{}).Where("id = ?", root["id"]).Update("created_at", past)
+
+		w, response := send("PUT", fmt.Sprintf("/api/v1/comments/%v", root["id"]), readerToken, map[string]interface{}{
+			"content": "Too late",
+		})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, []string{"comment"}, errorFields(response))
+	})
+
+	t.Run("Success - The post author removes a comment with its replies", func(t *testing.T) {
+		w, _ := send("DELETE", fmt.Sprintf("/api/v1/comments/%v", root["id"]), authorToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, response := send("GET", postPath(first), "", nil)
+		assert.Equal(t, float64(0), response["data"].(map[string]interface{})["comment_count"])
+	})
+}
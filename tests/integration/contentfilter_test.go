@@ -0,0 +1,55 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ardipermana59/go-template/internal/contentfilter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentFilterPipeline(t *testing.T) {
+	known := contentfilter.Hash("Buy cheap watches today")
+	pipeline := contentfilter.Pipeline{
+		contentfilter.WordList([]string{"scam"}, contentfilter.Reject),
+		contentfilter.WordList([]string{"casino"}, contentfilter.Flag),
+		contentfilter.LinkLimit(2, contentfilter.Flag),
+		contentfilter.Duplicate(func(ctx context.Context, hash string, excludePostID uint) (bool, error) {
+			return hash == known && excludePostID != 1, nil
+		}, contentfilter.Flag),
+	}
+
+	t.Run("Allow - Clean content", func(t *testing.T) {
+		result, err := pipeline.Check(contentfilter.Content{Title: "Hello", Body: "A post about scampi and https://example.com"})
+		assert.NoError(t, err)
+		assert.Equal(t, contentfilter.Allow, result.Verdict)
+		assert.Empty(t, result.Reasons)
+	})
+
+	t.Run("Flag - Words, links and duplicates", func(t *testing.T) {
+		result, err := pipeline.Check(contentfilter.Content{
+			Title: "Casino night",
+			Body:  "See https://a.example, https://b.example and www.c.example",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, contentfilter.Flag, result.Verdict)
+		assert.Len(t, result.Reasons, 2)
+		assert.Equal(t, "title", result.Reasons[0].Field)
+		assert.Equal(t, "content", result.Reasons[1].Field)
+
+		result, err = pipeline.Check(contentfilter.Content{Body: "  buy CHEAP watches\ntoday"})
+		assert.NoError(t, err)
+		assert.Equal(t, contentfilter.Flag, result.Verdict)
+
+		result, err = pipeline.Check(contentfilter.Content{PostID: 1, Body: "Buy cheap watches today"})
+		assert.NoError(t, err)
+		assert.Equal(t, contentfilter.Allow, result.Verdict)
+	})
+
+	t.Run("Reject - Highest verdict wins and all reasons are kept", func(t *testing.T) {
+		result, err := pipeline.Check(contentfilter.Content{Title: "Casino", Body: "This is a SCAM."})
+		assert.NoError(t, err)
+		assert.Equal(t, contentfilter.Reject, result.Verdict)
+		assert.Len(t, result.Reasons, 2)
+	})
+}
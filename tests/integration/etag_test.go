@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	setupTestDB(t)
+	testConfig.RequireIfMatch = true
+	setupTestRouter()
+
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+	_, otherToken := registerUser(t, "Other User", "other@example.com", "user")
+
+	p := createPost(t, token, map[string]interface{}{
+		"title":   "Versioned Post",
+		"content": "Every update bumps the version.",
+	})
+	path := postPath(p)
+
+	update := func(ifMatch, title string) *http.Request {
+		req := newRequest("PUT", path, token, map[string]interface{}{"title": title})
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+		return req
+	}
+
+	t.Run("Success - Reads return the version as ETag", func(t *testing.T) {
+		w, response := send("GET", path, "", nil)
+		assert.Equal(t, `"1"`, w.Header().Get("ETag"))
+		assert.Equal(t, float64(1), response["data"].(map[string]interface{})["version"])
+	})
+
+	t.Run("Fail - Updates without If-Match", func(t *testing.T) {
+		req := update("", "Unconditional Update")
+		w, response := serve(req)
+		assert.Equal(t, http.StatusPreconditionRequired, w.Code)
+		assert.Equal(t, []string{"if_match"}, errorFields(response))
+	})
+
+	t.Run("Success - Updates of the current version", func(t *testing.T) {
+		req := update(`"1"`, "First Update")
+		w, response := serve(req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+		assert.Equal(t, float64(2), response["data"].(map[string]interface{})["version"])
+	})
+
+	t.Run("Fail - Updates of a stale version", func(t *testing.T) {
+		req := update(`"1"`, "Lost Update")
+		w, response := serve(req)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+		assert.Equal(t, []string{"version"}, errorFields(response))
+
+		_, response = send("GET", path, "", nil)
+		assert.Equal(t, "First Update", response["data"].(map[string]interface{})["title"])
+	})
+
+	t.Run("Fail - Weak and malformed tags never match", func(t *testing.T) {
+		for _, tag := range []string{`W/"2"`, `2`, `"two"`, `"0"`} {
+			req := update(tag, "Malformed Update")
+			w, _ := serve(req)
+			assert.Equal(t, http.StatusPreconditionFailed, w.Code, tag)
+		}
+	})
+
+	t.Run("Success - A wildcard updates any version", func(t *testing.T) {
+		req := update("*", "Wildcard Update")
+		w, response := serve(req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, float64(3), response["data"].(map[string]interface{})["version"])
+	})
+
+	t.Run("Fail - A matching version does not bypass ownership", func(t *testing.T) {
+		req := newRequest("PUT", path, otherToken, map[string]interface{}{"title": "Not Mine"})
+		req.Header.Set("If-Match", `"3"`)
+		w, _ := serve(req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Success - Profiles are versioned too", func(t *testing.T) {
+		w, _ := send("GET", "/api/v1/profile", token, nil)
+		current := w.Header().Get("ETag")
+		assert.Equal(t, `"1"`, current)
+
+		req := newRequest("PUT", "/api/v1/profile", token, map[string]interface{}{"name": "Renamed User"})
+		req.Header.Set("If-Match", current)
+		w, _ = serve(req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+
+		req = newRequest("PUT", "/api/v1/profile", token, map[string]interface{}{"name": "Stale User"})
+		req.Header.Set("If-Match", current)
+		w, _ = serve(req)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	})
+
+	t.Run("Success - If-Match is optional when not required", func(t *testing.T) {
+		testConfig.RequireIfMatch = false
+		setupTestRouter()
+		defer func() {
+			testConfig.RequireIfMatch = true
+			setupTestRouter()
+		}()
+
+		req := update("", "Unconditional Update")
+		w, _ := serve(req)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/health"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var databaseErr error
+	service := health.NewService(100*time.Millisecond,
+		health.WithCheck("database", func(ctx context.Context) error {
+			return databaseErr
+		}, 0),
+		health.WithCheck("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}, time.Hour),
+		health.WithBuildInfo(health.BuildInfo{Version: "1.2.0", Commit: "abc123"}),
+	)
+	handler := health.NewHandler(service)
+
+	r := gin.New()
+	r.GET("/healthz", handler.Live)
+	r.GET("/readyz", handler.Ready)
+	r.GET("/version", handler.Version)
+
+	get := func(path string) (int, map[string]interface{}) {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		var body map[string]interface{}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body
+	}
+
+	t.Run("Success - Liveness does not check dependencies", func(t *testing.T) {
+		databaseErr = errors.New("connection refused")
+		defer func() { databaseErr = nil }()
+
+		code, _ := get("/healthz")
+		assert.Equal(t, http.StatusOK, code)
+	})
+
+	t.Run("Error - Readiness reports every component", func(t *testing.T) {
+		databaseErr = errors.New("connection refused")
+		defer func() { databaseErr = nil }()
+
+		code, body := get("/readyz")
+		assert.Equal(t, http.StatusServiceUnavailable, code)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, health.StatusNotReady, data["status"])
+		components := data["components"].(map[string]interface{})
+		database := components["database"].(map[string]interface{})
+		assert.Equal(t, health.StatusFailing, database["status"])
+		assert.NotContains(t, database, "error")
+		// The probe's own deadline cuts the hour long timeout short.
+		assert.Equal(t, health.StatusFailing, components["slow"].(map[string]interface{})["status"])
+	})
+
+	t.Run("Success - Checks time out on their own", func(t *testing.T) {
+		fast := health.NewService(50*time.Millisecond,
+			health.WithCheck("stuck", func(ctx context.Context) error {
+				time.Sleep(time.Second)
+				return nil
+			}, 0),
+			health.WithCheck("database", func(ctx context.Context) error { return nil }, 0),
+		)
+		start := time.Now()
+		report, ready := fast.Ready(context.Background())
+		assert.False(t, ready)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+		assert.Equal(t, "timed out after 50ms", report.Components["stuck"].Error)
+		assert.Equal(t, health.StatusOK, report.Components["database"].Status)
+	})
+
+	t.Run("Success - Ready when every check passes", func(t *testing.T) {
+		ready := health.NewService(time.Second,
+			health.WithCheck("database", func(ctx context.Context) error { return nil }, 0),
+		)
+		report, ok := ready.Ready(context.Background())
+		assert.True(t, ok)
+		assert.Equal(t, health.StatusReady, report.Status)
+
+		ready.ShutDown()
+		report, ok = ready.Ready(context.Background())
+		assert.False(t, ok)
+		assert.Equal(t, health.StatusShuttingDown, report.Status)
+	})
+
+	t.Run("Success - Version", func(t *testing.T) {
+		code, body := get("/version")
+		assert.Equal(t, http.StatusOK, code)
+		data := body["data"].(map[string]interface{})
+		assert.Equal(t, "1.2.0", data["version"])
+		assert.Equal(t, "abc123", data["commit"])
+		assert.NotEmpty(t, data["go_version"])
+	})
+}
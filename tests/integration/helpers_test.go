@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+// newRequest builds a request to the test router, encoding payload as JSON
+// when it is not nil and authenticating with token when it is not empty.
+func newRequest(method, path, token string, payload interface{}) *http.Request {
+	var body io.Reader
+	if payload != nil {
+		encoded, _ := json.Marshal(payload)
+		body = bytes.NewBuffer(encoded)
+	}
+
+	req, _ := http.NewRequest(method, path, body)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return req
+}
+
+// serve runs req through the test router and decodes the JSON response.
+func serve(req *http.Request) (*httptest.ResponseRecorder, map[string]interface{}) {
+	w := httptest.NewRecorder()
+	testRouter.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w, response
+}
+
+func send(method, path, token string, payload interface{}) (*httptest.ResponseRecorder, map[string]interface{}) {
+	return serve(newRequest(method, path, token, payload))
+}
+
+// registerUser registers a user with role and returns its ID and a token.
+func registerUser(t *testing.T, name, email, role string) (uint, string) {
+	w, _ := send("POST", "/api/v1/auth/register", "", map[string]string{
+		"name":             name,
+		"email":            email,
+		"password":         "password123",
+		"password_confirm": "password123",
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var registered user.User
+	assert.NoError(t, testDB.Where("email = ?", email).First(&registered).Error)
+	if role != registered.Role {
+		assert.NoError(t, testDB.Model(&registered).Update("role", role).Error)
+	}
+
+	token, err := testJWTService.GenerateToken(registered.ID, email, role)
+	assert.NoError(t, err)
+	return registered.ID, token
+}
+
+// createPost creates a post and returns the data of the response.
+func createPost(t *testing.T, token string, payload map[string]interface{}) map[string]interface{} {
+	w, response := send("POST", "/api/v1/posts", token, payload)
+	assert.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	return response["data"].(map[string]interface{})
+}
+
+// postPath returns the path of a post from the data of a response.
+func postPath(data map[string]interface{}) string {
+	return fmt.Sprintf("/api/v1/posts/%d", uint(data["id"].(float64)))
+}
+
+// errorFields returns the fields of the errors in a response.
+func errorFields(response map[string]interface{}) []string {
+	var fields []string
+	errors, _ := response["error"].([]interface{})
+	for _, e := range errors {
+		fields = append(fields, e.(map[string]interface{})["field"].(string))
+	}
+	return fields
+}
+
+// postTitles returns the titles of the posts listed in a response, in order.
+func postTitles(response map[string]interface{}) []string {
+	titles := []string{}
+	posts, _ := response["data"].([]interface{})
+	for _, p := range posts {
+		titles = append(titles, p.(map[string]interface{})["title"].(string))
+	}
+	return titles
+}
+
+// runJobs runs the due background jobs of the test router until none is left.
+func runJobs() {
+	for testJobQueue.RunNext(context.Background()) {
+	}
+}
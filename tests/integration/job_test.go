@@ -0,0 +1,351 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memoryJobRepository keeps jobs in memory so the queue can be tested
+// without a database.
+type memoryJobRepository struct {
+	job.Repository
+	mu        sync.Mutex
+	records   []job.Record
+	schedules map[string]*job.Schedule
+}
+
+func (r *memoryJobRepository) Create(record *job.Record) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record.UniqueKey != nil {
+		for _, existing := range r.records {
+			if existing.UniqueKey != nil && *existing.UniqueKey == *record.UniqueKey {
+				return false, nil
+			}
+		}
+	}
+	record.ID = uint(len(r.records) + 1)
+	record.CreatedAt = time.Now()
+	r.records = append(r.records, *record)
+	return true, nil
+}
+
+func (r *memoryJobRepository) Claim(types []string, now, lockedUntil time.Time) (*job.Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.records {
+		record := &r.records[i]
+		due := record.Status == job.StatusPending && !record.RunAt.After(now)
+		if !due || !containsString(types, record.Type) {
+			continue
+		}
+		record.Status = job.StatusRunning
+		record.Attempts++
+		record.LockedUntil = &lockedUntil
+		claimed := *record
+		return &claimed, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryJobRepository) SaveResult(record *job.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records[record.ID-1] = *record
+	return nil
+}
+
+func (r *memoryJobRepository) FindByID(id uint) (*job.Record, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id == 0 || int(id) > len(r.records) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	record := r.records[id-1]
+	return &record, nil
+}
+
+func (r *memoryJobRepository) FindSchedule(name, spec string, next time.Time) (*job.Schedule, error) {
+	if r.schedules == nil {
+		r.schedules = make(map[string]*job.Schedule)
+	}
+	if _, ok := r.schedules[name]; !ok {
+		r.schedules[name] = &job.Schedule{Name: name, Spec: spec, NextRunAt: next}
+	}
+	schedule := *r.schedules[name]
+	return &schedule, nil
+}
+
+func (r *memoryJobRepository) AdvanceSchedule(schedule *job.Schedule, spec string, next time.Time, ranAt *time.Time) (bool, error) {
+	stored := r.schedules[schedule.Name]
+	if !stored.NextRunAt.Equal(schedule.NextRunAt) {
+		return false, nil
+	}
+	stored.Spec = spec
+	stored.NextRunAt = next
+	if ranAt != nil {
+		stored.LastRunAt = ranAt
+	}
+	return true, nil
+}
+
+func (r *memoryJobRepository) WithTx(tx *gorm.DB) job.Repository {
+	return r
+}
+
+func (r *memoryJobRepository) Transaction(fn func(tx *gorm.DB) error) error {
+	return fn(nil)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type greetJob struct {
+	Name string `json:"name"`
+}
+
+func (greetJob) JobType() string { return "test.greet" }
+
+type slowJob struct{}
+
+func (slowJob) JobType() string { return "test.slow" }
+
+func TestJobParseSpec(t *testing.T) {
+	base := time.Date(2026, time.March, 14, 10, 17, 30, 0, time.Local)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, time.March, 14, 10, 30, 0, 0, time.Local)},
+		{"0 3 * * *", time.Date(2026, time.March, 15, 3, 0, 0, 0, time.Local)},
+		{"30 9 * * 1-5", time.Date(2026, time.March, 16, 9, 30, 0, 0, time.Local)},
+		{"0 0 1,15 * *", time.Date(2026, time.March, 15, 0, 0, 0, 0, time.Local)},
+		{"0 12 * * 7", time.Date(2026, time.March, 15, 12, 0, 0, 0, time.Local)},
+		{"@hourly", time.Date(2026, time.March, 14, 11, 0, 0, 0, time.Local)},
+		{"@monthly", time.Date(2026, time.April, 1, 0, 0, 0, 0, time.Local)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.Local)},
+		{"@every 90s", base.Add(90 * time.Second)},
+	}
+	for _, tt := range tests {
+		spec, err := job.ParseSpec(tt.spec)
+		if assert.NoError(t, err, tt.spec) {
+			assert.Equal(t, tt.want, spec.Next(base), tt.spec)
+		}
+	}
+
+	for _, invalid := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "@every 10ms", "@often"} {
+		_, err := job.ParseSpec(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestJobQueue(t *testing.T) {
+	settings := job.Settings{Workers: 2, PollInterval: time.Minute, MaxAttempts: 3, RetryBase: time.Minute}
+
+	t.Run("Success - Jobs run with their payload", func(t *testing.T) {
+		repo := &memoryJobRepository{}
+		queue := job.NewQueue(repo, settings)
+
+		var greeted []string
+		job.Handle(queue, func(ctx context.Context, j greetJob) error {
+			greeted = append(greeted, j.Name)
+			return nil
+		})
+
+		_, err := queue.Enqueue(greetJob{Name: "ada"})
+		assert.NoError(t, err)
+		_, err = queue.Enqueue(greetJob{Name: "grace"}, job.Delay(time.Hour))
+		assert.NoError(t, err)
+
+		assert.True(t, queue.RunNext(context.Background()))
+		assert.False(t, queue.RunNext(context.Background()))
+		assert.Equal(t, []string{"ada"}, greeted)
+		assert.Equal(t, job.StatusSucceeded, repo.records[0].Status)
+		assert.NotNil(t, repo.records[0].FinishedAt)
+		assert.Equal(t, job.StatusPending, repo.records[1].Status)
+	})
+
+	t.Run("Error - Failed jobs are retried with backoff and then dead", func(t *testing.T) {
+		repo := &memoryJobRepository{}
+		queue := job.NewQueue(repo, settings)
+
+		calls := 0
+		job.Handle(queue, func(ctx context.Context, j greetJob) error {
+			calls++
+			if calls == 2 {
+				panic("boom")
+			}
+			return errors.New("unavailable")
+		})
+
+		_, err := queue.Enqueue(greetJob{Name: "ada"})
+		assert.NoError(t, err)
+
+		start := time.Now()
+		assert.True(t, queue.RunNext(context.Background()))
+		assert.Equal(t, job.StatusPending, repo.records[0].Status)
+		assert.Equal(t, "unavailable", repo.records[0].LastError)
+		assert.WithinDuration(t, start.Add(time.Minute), repo.records[0].RunAt, 5*time.Second)
+		assert.False(t, queue.RunNext(context.Background()))
+
+		repo.records[0].RunAt = time.Now()
+		start = time.Now()
+		assert.True(t, queue.RunNext(context.Background()))
+		assert.Equal(t, "panic: boom", repo.records[0].LastError)
+		assert.WithinDuration(t, start.Add(2*time.Minute), repo.records[0].RunAt, 5*time.Second)
+
+		repo.records[0].RunAt = time.Now()
+		assert.True(t, queue.RunNext(context.Background()))
+		assert.Equal(t, job.StatusDead, repo.records[0].Status)
+		assert.Equal(t, 3, repo.records[0].Attempts)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("Error - Unique jobs are not queued twice", func(t *testing.T) {
+		repo := &memoryJobRepository{}
+		queue := job.NewQueue(repo, settings)
+		job.Handle(queue, func(ctx context.Context, j greetJob) error {
+			return nil
+		})
+
+		_, err := queue.Enqueue(greetJob{Name: "ada"}, job.Unique("greet:ada"))
+		assert.NoError(t, err)
+		_, err = queue.Enqueue(greetJob{Name: "ada"}, job.Unique("greet:ada"))
+		assert.Equal(t, job.ErrDuplicate, err)
+
+		// The key is released once the job finished.
+		assert.True(t, queue.RunNext(context.Background()))
+		_, err = queue.Enqueue(greetJob{Name: "ada"}, job.Unique("greet:ada"))
+		assert.NoError(t, err)
+	})
+
+	t.Run("Success - Concurrency limits jobs of a type", func(t *testing.T) {
+		repo := &memoryJobRepository{}
+		queue := job.NewQueue(repo, settings)
+
+		started := make(chan struct{}, 1)
+		release := make(chan struct{})
+		job.Handle(queue, func(ctx context.Context, j slowJob) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		}, job.Concurrency(1))
+		job.Handle(queue, func(ctx context.Context, j greetJob) error {
+			return nil
+		})
+
+		_, _ = queue.Enqueue(slowJob{})
+		_, _ = queue.Enqueue(slowJob{})
+		_, _ = queue.Enqueue(greetJob{Name: "ada"})
+
+		go queue.RunNext(context.Background())
+		<-started
+
+		// The second slow job waits while other types still run.
+		assert.True(t, queue.RunNext(context.Background()))
+		assert.Equal(t, job.StatusSucceeded, repo.records[2].Status)
+		assert.False(t, queue.RunNext(context.Background()))
+		assert.Equal(t, job.StatusPending, repo.records[1].Status)
+
+		close(release)
+		assert.Eventually(t, func() bool {
+			return queue.RunNext(context.Background())
+		}, time.Second, 10*time.Millisecond)
+		<-started
+	})
+
+	t.Run("Success - Stop cancels running jobs and queues them again", func(t *testing.T) {
+		repo := &memoryJobRepository{}
+		queue := job.NewQueue(repo, settings)
+
+		started := make(chan struct{})
+		job.Handle(queue, func(ctx context.Context, j slowJob) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		})
+		_, _ = queue.Enqueue(slowJob{})
+
+		queue.Start()
+		<-started
+
+		stopped := make(chan struct{})
+		go func() {
+			queue.Stop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-time.After(time.Second):
+			t.Fatal("Stop waited for a running job")
+		}
+
+		record, err := repo.FindByID(1)
+		assert.NoError(t, err)
+		assert.Equal(t, job.StatusPending, record.Status)
+		assert.Equal(t, 0, record.Attempts)
+		assert.Empty(t, record.LastError)
+	})
+
+	t.Run("Success - Recurring jobs are queued once per run", func(t *testing.T) {
+		repo := &memoryJobRepository{}
+		queue := job.NewQueue(repo, settings)
+		job.Handle(queue, func(ctx context.Context, j greetJob) error {
+			return nil
+		})
+		assert.NoError(t, queue.Every("greet", "@every 1h", greetJob{Name: "ada"}))
+		assert.Error(t, queue.Every("broken", "@sometimes", greetJob{}))
+
+		now := time.Now()
+		queue.Schedule(now)
+		assert.Len(t, repo.records, 0)
+
+		queue.Schedule(now.Add(time.Hour))
+		queue.Schedule(now.Add(time.Hour))
+		assert.Len(t, repo.records, 1)
+		assert.WithinDuration(t, now.Add(2*time.Hour), repo.schedules["greet"].NextRunAt, time.Second)
+
+		// The previous run is still queued.
+		queue.Schedule(now.Add(2 * time.Hour))
+		assert.Len(t, repo.records, 1)
+
+		assert.True(t, queue.RunNext(context.Background()))
+		queue.Schedule(now.Add(3 * time.Hour))
+		assert.Len(t, repo.records, 2)
+	})
+}
+
+func TestJobRetry(t *testing.T) {
+	repo := &memoryJobRepository{}
+	service := job.NewService(repo, &countingTrigger{})
+
+	dead := job.Record{Type: "test.greet", Payload: "{}", Status: job.StatusDead, Attempts: 3, MaxAttempts: 3}
+	succeeded := job.Record{Type: "test.greet", Payload: "{}", Status: job.StatusSucceeded, Attempts: 1, MaxAttempts: 3}
+	_, _ = repo.Create(&dead)
+	_, _ = repo.Create(&succeeded)
+
+	response, appErr := service.RetryJob(dead.ID)
+	assert.Nil(t, appErr)
+	assert.Equal(t, job.StatusPending, response.Status)
+	assert.Equal(t, 0, repo.records[0].Attempts)
+
+	_, appErr = service.RetryJob(succeeded.ID)
+	assert.True(t, appErr.Has("status"))
+
+	_, appErr = service.RetryJob(99)
+	assert.True(t, appErr.Has("job"))
+}
@@ -0,0 +1,256 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/job"
+	appmail "github.com/ardipermana59/go-template/internal/mail"
+	"github.com/ardipermana59/go-template/pkg/mailer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMailerTemplates(t *testing.T) {
+	files := fstest.MapFS{
+		"reset.en.txt":    {Data: []byte(`{{define "subject"}}Reset your password{{end}}Hi {{.Name}}, use {{.Link}}.`)},
+		"reset.en.html":   {Data: []byte(`<p>Hi {{.Name}}, use <a href="{{.Link}}">this link</a>.</p>`)},
+		"reset.pt.txt":    {Data: []byte(`{{define "subject"}}Redefina a senha{{end}}Oi {{.Name}}.`)},
+		"reset.pt-br.txt": {Data: []byte(`{{define "subject"}}Redefina sua senha{{end}}Olá {{.Name}}.`)},
+	}
+	templates, err := mailer.ParseTemplates(files, "en")
+	assert.NoError(t, err)
+
+	data := map[string]string{"Name": "<Ada>", "Link": "https://example.com/reset?a=1&b=2"}
+
+	t.Run("Success - Renders subject, text and escaped HTML", func(t *testing.T) {
+		msg, err := templates.Render("reset", "en", data)
+		assert.NoError(t, err)
+		assert.Equal(t, "Reset your password", msg.Subject)
+		assert.Equal(t, "Hi <Ada>, use https://example.com/reset?a=1&b=2.\n", msg.Text)
+		assert.Equal(t, `<p>Hi &lt;Ada&gt;, use <a href="https://example.com/reset?a=1&amp;b=2">this link</a>.</p>`, msg.HTML)
+	})
+
+	t.Run("Success - Falls back from region to language to default", func(t *testing.T) {
+		msg, _ := templates.Render("reset", "pt_BR", data)
+		assert.Equal(t, "Redefina sua senha", msg.Subject)
+		msg, _ = templates.Render("reset", "pt-PT", data)
+		assert.Equal(t, "Redefina a senha", msg.Subject)
+		assert.Empty(t, msg.HTML)
+		msg, _ = templates.Render("reset", "de", data)
+		assert.Equal(t, "Reset your password", msg.Subject)
+	})
+
+	t.Run("Error - Unknown templates and missing data", func(t *testing.T) {
+		_, err := templates.Render("invite", "en", data)
+		assert.Error(t, err)
+		_, err = templates.Render("reset", "en", map[string]string{"Name": "Ada"})
+		assert.Error(t, err)
+	})
+
+	t.Run("Error - Invalid template sets", func(t *testing.T) {
+		_, err := mailer.ParseTemplates(fstest.MapFS{"reset.en.txt": {Data: []byte("no subject")}}, "en")
+		assert.Error(t, err)
+		_, err = mailer.ParseTemplates(fstest.MapFS{"reset.en.html": {Data: []byte("<p>html only</p>")}}, "en")
+		assert.Error(t, err)
+		_, err = mailer.ParseTemplates(fstest.MapFS{"reset.txt": {Data: []byte(`{{define "subject"}}x{{end}}`)}}, "en")
+		assert.Error(t, err)
+	})
+
+	t.Run("Success - Shipped templates parse", func(t *testing.T) {
+		templates, err := appmail.Templates("en")
+		assert.NoError(t, err)
+		site := appmail.Site{Name: "Go Template", URL: "http://localhost:8080"}
+		msg, err := templates.Render("welcome", "id-ID", map[string]interface{}{"Name": "Ada", "Site": site})
+		assert.NoError(t, err)
+		assert.Equal(t, "Selamat datang di Go Template", msg.Subject)
+		assert.Contains(t, msg.HTML, `href="http://localhost:8080"`)
+	})
+}
+
+func TestMailerBuild(t *testing.T) {
+	msg := &mailer.Message{
+		From:    "Go Template <no-reply@example.com>",
+		To:      []string{"ada@example.com"},
+		Subject: "Héllo\r\nBcc: evil@example.com",
+		Text:    "Plain body",
+		HTML:    "<p>HTML body</p>",
+	}
+	data, err := mailer.Build(msg, time.Now())
+	assert.NoError(t, err)
+
+	parsed, err := mail.ReadMessage(strings.NewReader(string(data)))
+	assert.NoError(t, err)
+	assert.Empty(t, parsed.Header.Get("Bcc"))
+	subject, _ := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	assert.Equal(t, "Héllo\r\nBcc: evil@example.com", subject)
+	assert.Equal(t, "<no-reply@example.com>", strings.TrimPrefix(parsed.Header.Get("From"), `"Go Template" `))
+
+	mediaType, params, _ := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	assert.Equal(t, "multipart/alternative", mediaType)
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		body, _ := io.ReadAll(quotedprintable.NewReader(part))
+		bodies = append(bodies, string(body))
+	}
+	assert.Equal(t, []string{"Plain body", "<p>HTML body</p>"}, bodies)
+
+	_, err = mailer.Build(&mailer.Message{From: "no-reply@example.com", To: []string{"not an address"}, Text: "x"}, time.Now())
+	assert.Error(t, err)
+	_, err = mailer.Build(&mailer.Message{From: "no-reply@example.com", To: []string{"ada@example.com"}}, time.Now())
+	assert.Error(t, err)
+}
+
+func TestMailerFile(t *testing.T) {
+	dir := t.TempDir()
+	m, err := mailer.NewFileMailer(dir, "no-reply@example.com")
+	assert.NoError(t, err)
+
+	assert.NoError(t, m.Send(context.Background(), &mailer.Message{To: []string{"ada@example.com"}, Subject: "Hi", Text: "Body"}))
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if assert.Len(t, files, 1) {
+		data, _ := os.ReadFile(files[0])
+		assert.Contains(t, string(data), "From: <no-reply@example.com>")
+		assert.Contains(t, string(data), "Subject: Hi")
+	}
+}
+
+// fakeSMTPServer accepts one SMTP session and records the envelope and data.
+func fakeSMTPServer(t *testing.T) (addr string, received chan []string) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received = make(chan []string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+		var lines []string
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				reply("250 localhost")
+			case strings.HasPrefix(command, "MAIL FROM"), strings.HasPrefix(command, "RCPT TO"):
+				lines = append(lines, line)
+				reply("250 OK")
+			case command == "DATA":
+				reply("354 Go ahead")
+				for {
+					dataLine, err := r.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					lines = append(lines, strings.TrimRight(dataLine, "\r\n"))
+				}
+				reply("250 Queued")
+			case command == "QUIT":
+				reply("221 Bye")
+				received <- lines
+				return
+			default:
+				reply("502 Not implemented")
+			}
+		}
+	}()
+	return listener.Addr().String(), received
+}
+
+func TestMailerSMTP(t *testing.T) {
+	addr, received := fakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(addr)
+	portNumber, _ := net.LookupPort("tcp", port)
+
+	m, err := mailer.NewSMTPMailer(mailer.SMTPConfig{Host: host, Port: portNumber, TLS: mailer.TLSNone, From: "Go Template <no-reply@example.com>"})
+	assert.NoError(t, err)
+	err = m.Send(context.Background(), &mailer.Message{To: []string{"Ada <ada@example.com>"}, Subject: "Hi", Text: "Body"})
+	assert.NoError(t, err)
+
+	select {
+	case lines := <-received:
+		assert.Equal(t, "MAIL FROM:<no-reply@example.com>", lines[0])
+		assert.Equal(t, "RCPT TO:<ada@example.com>", lines[1])
+		assert.Contains(t, lines, "Subject: Hi")
+		assert.Contains(t, lines, "Body")
+	case <-time.After(5 * time.Second):
+		t.Fatal("the message was not delivered")
+	}
+
+	_, err = mailer.NewSMTPMailer(mailer.SMTPConfig{Host: host, TLS: "sometimes", From: "no-reply@example.com"})
+	assert.Error(t, err)
+
+	// STARTTLS is required but the server does not offer it.
+	addr, _ = fakeSMTPServer(t)
+	host, port, _ = net.SplitHostPort(addr)
+	portNumber, _ = net.LookupPort("tcp", port)
+	m, _ = mailer.NewSMTPMailer(mailer.SMTPConfig{Host: host, Port: portNumber, TLS: mailer.TLSStartTLS, From: "no-reply@example.com"})
+	err = m.Send(context.Background(), &mailer.Message{To: []string{"ada@example.com"}, Subject: "Hi", Text: "Body"})
+	assert.ErrorContains(t, err, "STARTTLS")
+}
+
+func TestMailService(t *testing.T) {
+	templates, err := mailer.ParseTemplates(fstest.MapFS{
+		"hello.en.txt": {Data: []byte(`{{define "subject"}}Hello {{.}}{{end}}Hello {{.}}.`)},
+	}, "en")
+	assert.NoError(t, err)
+
+	repo := &memoryJobRepository{}
+	queue := job.NewQueue(repo, job.Settings{Workers: 1, PollInterval: time.Minute, MaxAttempts: 3, RetryBase: time.Minute})
+	sent := mailer.NewMemoryMailer("no-reply@example.com")
+	appmail.RegisterJobs(queue, sent)
+	service := appmail.NewService(templates, queue, "Go Template <no-reply@example.com>")
+
+	assert.Error(t, service.Send([]string{"ada@example.com"}, "missing", "en", "Ada"))
+	assert.Error(t, service.Send([]string{"not an address"}, "hello", "en", "Ada"))
+	assert.Len(t, repo.records, 0)
+
+	assert.NoError(t, service.Send([]string{"ada@example.com"}, "hello", "en", "Ada"))
+	assert.Len(t, sent.Messages(), 0)
+
+	// Delivery failures are retried by the queue.
+	sent.Fail(errors.New("connection refused"))
+	assert.True(t, queue.RunNext(context.Background()))
+	assert.Equal(t, job.StatusPending, repo.records[0].Status)
+	assert.Equal(t, "connection refused", repo.records[0].LastError)
+
+	sent.Fail(nil)
+	repo.records[0].RunAt = time.Now()
+	assert.True(t, queue.RunNext(context.Background()))
+	messages := sent.Messages()
+	if assert.Len(t, messages, 1) {
+		assert.Equal(t, "Hello Ada", messages[0].Subject)
+		assert.Equal(t, "Go Template <no-reply@example.com>", messages[0].From)
+		assert.Equal(t, []string{"ada@example.com"}, messages[0].To)
+	}
+}
@@ -0,0 +1,42 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/ardipermana59/go-template/pkg/markdown"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+	t.Run("Success - Render markdown", func(t *testing.T) {
+		html, err := markdown.Render(markdown.FormatMarkdown, "# Title\n\nSome **bold** text and [a link](https://example.com).")
+		assert.NoError(t, err)
+
+		assert.Contains(t, html, "<h1>Title</h1>")
+		assert.Contains(t, html, "<strong>bold</strong>")
+		assert.Contains(t, html, `href="https://example.com"`)
+		assert.Contains(t, html, `rel="nofollow noopener"`)
+	})
+
+	t.Run("Success - Escape plain text", func(t *testing.T) {
+		html, err := markdown.Render(markdown.FormatPlain, "<b>not bold</b>\nsecond line\n\nnext paragraph")
+		assert.NoError(t, err)
+
+		assert.Equal(t, "<p>&lt;b&gt;not bold&lt;/b&gt;<br>second line</p>\n<p>next paragraph</p>\n", html)
+	})
+
+	t.Run("Sanitize - Strip scripts, event handlers and dangerous URLs", func(t *testing.T) {
+		source := "<script>alert(1)</script>\n\n" +
+			"<img src=\"x.png\" onerror=\"alert(1)\">\n\n" +
+			"[click](javascript:alert(1))\n\n" +
+			"<a href=\"data:text/html;base64,PHNjcmlwdD4=\">data</a>"
+
+		html, err := markdown.Render(markdown.FormatMarkdown, source)
+		assert.NoError(t, err)
+
+		assert.NotContains(t, html, "<script")
+		assert.NotContains(t, html, "onerror")
+		assert.NotContains(t, html, "javascript:")
+		assert.NotContains(t, html, "data:text/html")
+	})
+}
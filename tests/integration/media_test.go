@@ -0,0 +1,121 @@
+package integration
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"github.com/stretchr/testify/assert"
+)
+
+// pngImage encodes a width by height PNG.
+func pngImage(width, height int) []byte {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+// uploadFile sends content as the file field of a multipart form.
+func uploadFile(method, path, token, fileName string, content []byte) (int, map[string]interface{}) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", fileName)
+	part.Write(content)
+	form.Close()
+
+	req, _ := http.NewRequest(method, path, &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w, response := serve(req)
+	return w.Code, response
+}
+
+func TestImages(t *testing.T) {
+	setupTestDB(t)
+	testConfig.ImageVariantSizes = []int{32, 64}
+	testConfig.ImageVariantFormats = []string{"jpeg"}
+	setupTestRouter()
+
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+	_, otherToken := registerUser(t, "Other User", "other@example.com", "user")
+
+	t.Run("Success - Avatars get a variant per size", func(t *testing.T) {
+		code, response := uploadFile("PUT", "/api/v1/profile/avatar", token, "avatar.png", pngImage(100, 50))
+		assert.Equal(t, http.StatusAccepted, code)
+		avatar := response["data"].(map[string]interface{})["avatar"].(map[string]interface{})
+		assert.Equal(t, "pending", avatar["status"])
+
+		assert.Eventually(t, func() bool {
+			_, response := send("GET", "/api/v1/profile", token, nil)
+			avatar, _ := response["data"].(map[string]interface{})["avatar"].(map[string]interface{})
+			return avatar != nil && avatar["status"] == "ready"
+		}, 5*time.Second, 20*time.Millisecond)
+
+		_, response = send("GET", "/api/v1/profile", token, nil)
+		avatar = response["data"].(map[string]interface{})["avatar"].(map[string]interface{})
+		assert.Equal(t, float64(100), avatar["width"])
+		assert.Equal(t, float64(50), avatar["height"])
+
+		var sizes []string
+		for _, variant := range avatar["variants"].([]interface{}) {
+			variant := variant.(map[string]interface{})
+			sizes = append(sizes, fmt.Sprintf("%v:%vx%v", variant["format"], variant["width"], variant["height"]))
+			assert.NotEmpty(t, variant["url"])
+		}
+		assert.ElementsMatch(t, []string{"jpeg:32x16", "jpeg:64x32"}, sizes)
+	})
+
+	t.Run("Success - Deleting the avatar", func(t *testing.T) {
+		w, _ := send("DELETE", "/api/v1/profile/avatar", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, response := send("GET", "/api/v1/profile", token, nil)
+		assert.Nil(t, response["data"].(map[string]interface{})["avatar"])
+	})
+
+	t.Run("Fail - Files that are not images", func(t *testing.T) {
+		code, response := uploadFile("PUT", "/api/v1/profile/avatar", token, "avatar.png", []byte("not an image at all"))
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{"file"}, errorFields(response))
+	})
+
+	t.Run("Success - Post covers", func(t *testing.T) {
+		p := createPost(t, token, map[string]interface{}{
+			"title":   "Cover Post",
+			"content": "This post gets a cover image.",
+		})
+
+		code, response := uploadFile("PUT", postPath(p)+"/cover", otherToken, "cover.png", pngImage(40, 40))
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{"ownership"}, errorFields(response))
+
+		code, response = uploadFile("PUT", postPath(p)+"/cover", token, "cover.png", pngImage(40, 40))
+		assert.Equal(t, http.StatusAccepted, code)
+		assert.NotNil(t, response["data"].(map[string]interface{})["cover"])
+		assert.Equal(t, float64(2), response["data"].(map[string]interface{})["version"])
+
+		w, response := send("DELETE", postPath(p)+"/cover", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+		assert.Nil(t, response["data"].(map[string]interface{})["cover"])
+		_, response = send("GET", postPath(p), "", nil)
+		assert.Nil(t, response["data"].(map[string]interface{})["cover"])
+
+		var events int64
+		testDB.Model(&outbox.Message{}).Where("event_name = ? AND aggregate_id = ?", "post.updated", p["id"]).Count(&events)
+		assert.Equal(t, int64(2), events)
+	})
+}
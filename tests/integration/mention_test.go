@@ -0,0 +1,30 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/ardipermana59/go-template/internal/notification"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMentions(t *testing.T) {
+	t.Run("Success - Mentions in text", func(t *testing.T) {
+		mentions := notification.ParseMentions("@alice thanks, (@Bob) and @carol42! cc @ALICE")
+		assert.Equal(t, []string{"alice", "bob", "carol42"}, mentions)
+	})
+
+	t.Run("Success - Email addresses and invalid handles are ignored", func(t *testing.T) {
+		mentions := notification.ParseMentions("mail alice@example.com, @ab, @" + strings.Repeat("x", 31) + " or a_@dave")
+		assert.Empty(t, mentions)
+	})
+
+	t.Run("Success - Mentions are capped", func(t *testing.T) {
+		var text strings.Builder
+		for i := 0; i < notification.MaxMentions+5; i++ {
+			fmt.Fprintf(&text, "@user%d ", i)
+		}
+		assert.Len(t, notification.ParseMentions(text.String()), notification.MaxMentions)
+	})
+}
@@ -0,0 +1,177 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestModeration(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	_, authorToken := registerUser(t, "Author User", "author@example.com", "user")
+	_, readerToken := registerUser(t, "Reader User", "reader@example.com", "user")
+	_, moderatorToken := registerUser(t, "Moderator User", "moderator@example.com", "moderator")
+
+	p := createPost(t, authorToken, map[string]interface{}{
+		"title":   "Reported Post",
+		"content": "Some content that a reader finds abusive.",
+	})
+	path := postPath(p)
+	postID := p["id"].(float64)
+
+	w, _ := send("POST", path+"/comments", readerToken, map[string]string{"content": "A comment"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	w, _ = send("PUT", path+"/reactions/like", readerToken, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	act := func(t *testing.T, action string) {
+		w, response := send("POST", fmt.Sprintf("/api/v1/moderation/posts/%d/actions", uint(postID)), moderatorToken, map[string]string{
+			"action": action,
+			"note":   "Reviewed by a moderator",
+		})
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, action, response["data"].(map[string]interface{})["action"])
+	}
+	// visible asserts whether the post and what belongs to it can be seen
+	// with token.
+	visible := func(t *testing.T, token string, want bool) {
+		status := http.StatusNotFound
+		if want {
+			status = http.StatusOK
+		}
+
+		for _, suffix := range []string{"", "/comments", "/attachments"} {
+			w, _ := send("GET", path+suffix, token, nil)
+			assert.Equal(t, status, w.Code, path+suffix)
+		}
+		if token == "" {
+			return
+		}
+
+		w, _ := send("PUT", path+"/reactions/love", token, nil)
+		assert.Equal(t, status, w.Code)
+	}
+
+	t.Run("Success - Report a post", func(t *testing.T) {
+		w, response := send("POST", path+"/reports", readerToken, map[string]string{
+			"reason":  "harassment",
+			"details": "Insults another user",
+		})
+		assert.Equal(t, http.StatusCreated, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "harassment", data["reason"])
+		assert.Equal(t, "open", data["status"])
+	})
+
+	t.Run("Fail - Report a post twice", func(t *testing.T) {
+		w, _ := send("POST", path+"/reports", readerToken, map[string]string{"reason": "spam"})
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("Fail - Report your own post", func(t *testing.T) {
+		w, _ := send("POST", path+"/reports", authorToken, map[string]string{"reason": "spam"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail - Unknown reason", func(t *testing.T) {
+		w, response := send("POST", path+"/reports", moderatorToken, map[string]string{"reason": "boring"})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, errorFields(response), "reason")
+	})
+
+	t.Run("Success - Reported posts are queued", func(t *testing.T) {
+		w, response := send("GET", "/api/v1/moderation/queue", moderatorToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		items := response["data"].([]interface{})
+		assert.Len(t, items, 1)
+		item := items[0].(map[string]interface{})
+		assert.Equal(t, postID, item["post"].(map[string]interface{})["id"])
+		assert.Equal(t, float64(1), item["report_count"])
+	})
+
+	t.Run("Fail - Users cannot moderate", func(t *testing.T) {
+		w, _ := send("GET", "/api/v1/moderation/queue", readerToken, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		w, _ = send("POST", fmt.Sprintf("/api/v1/moderation/posts/%d/actions", uint(postID)), readerToken, map[string]string{"action": "hide"})
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Success - Hidden posts are only visible to their author", func(t *testing.T) {
+		act(t, "hide")
+
+		visible(t, readerToken, false)
+		visible(t, "", false)
+		visible(t, authorToken, true)
+
+		_, response := send("GET", path, authorToken, nil)
+		assert.Equal(t, true, response["data"].(map[string]interface{})["hidden"])
+
+		// Hidden posts are left out of the public list, even for their
+		// author, who still finds them among their own posts.
+		_, response = send("GET", "/api/v1/posts", authorToken, nil)
+		assert.NotContains(t, postTitles(response), "Reported Post")
+		_, response = send("GET", "/api/v1/posts/my", authorToken, nil)
+		assert.Contains(t, postTitles(response), "Reported Post")
+
+		_, response = send("GET", "/api/v1/moderation/queue", moderatorToken, nil)
+		assert.Empty(t, response["data"])
+	})
+
+	t.Run("Success - Restored posts are visible again", func(t *testing.T) {
+		act(t, "restore")
+
+		visible(t, readerToken, true)
+		visible(t, "", true)
+
+		_, response := send("GET", "/api/v1/posts", "", nil)
+		assert.Contains(t, postTitles(response), "Reported Post")
+	})
+
+	t.Run("Fail - Posts are not hidden when the action cannot be recorded", func(t *testing.T) {
+		testDB.Callback().Create().Before("gorm:create").Register("test:fail_actions", func(db *gorm.DB) {
+			if db.Statement.Table == "moderation_actions" {
+				db.AddError(errors.New("actions cannot be recorded"))
+			}
+		})
+		defer testDB.Callback().Create().Remove("test:fail_actions")
+
+		w, _ := send("POST", fmt.Sprintf("/api/v1/moderation/posts/%d/actions", uint(postID)), moderatorToken, map[string]string{"action": "hide"})
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		visible(t, readerToken, true)
+	})
+
+	t.Run("Success - Warn the author", func(t *testing.T) {
+		act(t, "warn")
+
+		w, response := send("GET", "/api/v1/me/warnings", authorToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		warnings := response["data"].([]interface{})
+		assert.Len(t, warnings, 1)
+		warning := warnings[0].(map[string]interface{})
+		assert.Equal(t, postID, warning["post_id"])
+		assert.Equal(t, "Reviewed by a moderator", warning["note"])
+
+		_, response = send("GET", "/api/v1/me/warnings", readerToken, nil)
+		assert.Empty(t, response["data"])
+	})
+
+	t.Run("Success - Removed posts are gone for everyone", func(t *testing.T) {
+		act(t, "remove")
+
+		w, _ := send("GET", path, authorToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Fail - Act on an unknown post", func(t *testing.T) {
+		w, _ := send("POST", "/api/v1/moderation/posts/99999/actions", moderatorToken, map[string]string{"action": "hide"})
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,200 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// memoryOutboxRepository keeps outbox messages in memory so dispatching can
+// be tested without a database.
+type memoryOutboxRepository struct {
+	messages  []outbox.Message
+	processed map[uint][]string
+}
+
+func (r *memoryOutboxRepository) Create(messages []outbox.Message) error {
+	for i := range messages {
+		messages[i].ID = uint(len(r.messages) + 1)
+		messages[i].CreatedAt = time.Now()
+		r.messages = append(r.messages, messages[i])
+	}
+	return nil
+}
+
+func (r *memoryOutboxRepository) FindPending(afterID uint, limit int) ([]outbox.Message, error) {
+	var pending []outbox.Message
+	for _, message := range r.messages {
+		if message.Status == outbox.StatusPending && message.ID > afterID && len(pending) < limit {
+			pending = append(pending, message)
+		}
+	}
+	return pending, nil
+}
+
+func (r *memoryOutboxRepository) Claim(id uint, now, until time.Time) (bool, error) {
+	message := &r.messages[id-1]
+	if message.LockedUntil != nil && message.LockedUntil.After(now) {
+		return false, nil
+	}
+	message.LockedUntil = &until
+	return true, nil
+}
+
+func (r *memoryOutboxRepository) ProcessedBy(messageID uint) ([]string, error) {
+	return r.processed[messageID], nil
+}
+
+func (r *memoryOutboxRepository) MarkProcessed(messageID uint, subscriber string, at time.Time) error {
+	if r.processed == nil {
+		r.processed = make(map[uint][]string)
+	}
+	r.processed[messageID] = append(r.processed[messageID], subscriber)
+	return nil
+}
+
+func (r *memoryOutboxRepository) SaveAttempt(message *outbox.Message) error {
+	message.LockedUntil = nil
+	r.messages[message.ID-1] = *message
+	return nil
+}
+
+func (r *memoryOutboxRepository) DeleteDispatchedBefore(before time.Time) (int64, error) {
+	return 0, nil
+}
+
+func (r *memoryOutboxRepository) WithTx(tx *gorm.DB) outbox.Repository {
+	return r
+}
+
+func TestOutboxDispatcher(t *testing.T) {
+	settings := outbox.Settings{Interval: time.Minute, MaxAttempts: 3, RetryBase: time.Minute}
+
+	t.Run("Success - Events reach their subscribers once", func(t *testing.T) {
+		repo := &memoryOutboxRepository{}
+		dispatcher := outbox.NewDispatcher(repo, settings)
+
+		var published []uint
+		var registered []uint
+		outbox.Subscribe(dispatcher, "test", func(ctx context.Context, meta outbox.Metadata, event post.PostPublished) error {
+			published = append(published, event.PostID)
+			return nil
+		})
+		outbox.Subscribe(dispatcher, "test", func(ctx context.Context, meta outbox.Metadata, event user.UserRegistered) error {
+			registered = append(registered, event.UserID)
+			return nil
+		})
+
+		assert.NoError(t, dispatcher.Write(nil, post.PostPublished{PostID: 1}, user.UserRegistered{UserID: 7}, post.PostDeleted{PostID: 1}))
+		handled, err := dispatcher.DispatchDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 3, handled)
+		assert.Equal(t, []uint{1}, published)
+		assert.Equal(t, []uint{7}, registered)
+
+		handled, _ = dispatcher.DispatchDue(context.Background())
+		assert.Equal(t, 0, handled)
+		for _, message := range repo.messages {
+			assert.Equal(t, outbox.StatusDispatched, message.Status)
+		}
+	})
+
+	t.Run("Error - Failures hold back the aggregate and are retried", func(t *testing.T) {
+		repo := &memoryOutboxRepository{}
+		dispatcher := outbox.NewDispatcher(repo, settings)
+
+		failing := true
+		var flaky, steady []string
+		outbox.Subscribe(dispatcher, "steady", func(ctx context.Context, meta outbox.Metadata, event post.PostPublished) error {
+			steady = append(steady, "published")
+			return nil
+		})
+		outbox.Subscribe(dispatcher, "flaky", func(ctx context.Context, meta outbox.Metadata, event post.PostPublished) error {
+			if failing && event.PostID == 1 {
+				return errors.New("unavailable")
+			}
+			flaky = append(flaky, "published")
+			return nil
+		})
+		outbox.Subscribe(dispatcher, "flaky", func(ctx context.Context, meta outbox.Metadata, event post.PostUpdated) error {
+			flaky = append(flaky, "updated")
+			return nil
+		})
+
+		assert.NoError(t, dispatcher.Write(nil,
+			post.PostPublished{PostID: 1},
+			post.PostUpdated{PostID: 1},
+			post.PostPublished{PostID: 2},
+		))
+
+		_, err := dispatcher.DispatchDue(context.Background())
+		assert.NoError(t, err)
+		// The update of post 1 waits for its first event; post 2 is not
+		// affected.
+		assert.Equal(t, []string{"published"}, flaky)
+		assert.Equal(t, 1, repo.messages[0].Attempts)
+		assert.Contains(t, repo.messages[0].LastError, "flaky: unavailable")
+		assert.Equal(t, outbox.StatusPending, repo.messages[1].Status)
+		assert.Equal(t, outbox.StatusDispatched, repo.messages[2].Status)
+
+		failing = false
+		repo.messages[0].NextAttemptAt = time.Now().Add(-time.Second)
+		_, err = dispatcher.DispatchDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"published", "published", "updated"}, flaky)
+		// The subscriber that succeeded the first time is not called again.
+		assert.Equal(t, []string{"published", "published"}, steady)
+		assert.Equal(t, outbox.StatusDispatched, repo.messages[0].Status)
+		assert.Equal(t, outbox.StatusDispatched, repo.messages[1].Status)
+	})
+
+	t.Run("Success - A held back aggregate does not stall the others", func(t *testing.T) {
+		repo := &memoryOutboxRepository{}
+		dispatcher := outbox.NewDispatcher(repo, settings)
+
+		var published []uint
+		outbox.Subscribe(dispatcher, "test", func(ctx context.Context, meta outbox.Metadata, event post.PostPublished) error {
+			if event.PostID == 1 {
+				return errors.New("unavailable")
+			}
+			published = append(published, event.PostID)
+			return nil
+		})
+
+		events := []outbox.Event{post.PostPublished{PostID: 1}}
+		for i := 0; i < 250; i++ {
+			events = append(events, post.PostPublished{PostID: 1})
+		}
+		events = append(events, post.PostPublished{PostID: 2})
+		assert.NoError(t, dispatcher.Write(nil, events...))
+
+		handled, err := dispatcher.DispatchDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, handled)
+		assert.Equal(t, []uint{2}, published)
+	})
+
+	t.Run("Error - Messages fail after the last attempt", func(t *testing.T) {
+		repo := &memoryOutboxRepository{}
+		dispatcher := outbox.NewDispatcher(repo, settings)
+		outbox.Subscribe(dispatcher, "broken", func(ctx context.Context, meta outbox.Metadata, event user.UserDeleted) error {
+			return errors.New("broken")
+		})
+
+		assert.NoError(t, dispatcher.Write(nil, user.UserDeleted{UserID: 1}))
+		for i := 0; i < settings.MaxAttempts; i++ {
+			repo.messages[0].NextAttemptAt = time.Now().Add(-time.Second)
+			_, err := dispatcher.DispatchDue(context.Background())
+			assert.NoError(t, err)
+		}
+		assert.Equal(t, outbox.StatusFailed, repo.messages[0].Status)
+		assert.Equal(t, settings.MaxAttempts, repo.messages[0].Attempts)
+	})
+}
@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/ardipermana59/go-template/internal/common/patch"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatch(t *testing.T) {
+	setupTestDB(t)
+	testConfig.RequireIfMatch = false
+	setupTestRouter()
+
+	_, adminToken := registerUser(t, "Admin User", "admin@example.com", "admin")
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+
+	w, response := send("POST", "/api/v1/admin/categories", adminToken, map[string]interface{}{"name": "News"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	category := response["data"].(map[string]interface{})
+
+	p := createPost(t, token, map[string]interface{}{
+		"title":       "Patched Post",
+		"content":     "Fields are changed one by one.",
+		"category_id": category["id"],
+		"tags":        []string{"go", "web"},
+	})
+	path := postPath(p)
+
+	patchRequest := func(path, contentType, body string) *http.Request {
+		req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+token)
+		return req
+	}
+
+	t.Run("Success - Merge patch clears fields set to null or empty", func(t *testing.T) {
+		w, response := serve(patchRequest(path, patch.MergePatch, `{"category_id": null, "tags": []}`))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "Patched Post", data["title"])
+		assert.Nil(t, data["category"])
+		assert.Empty(t, data["tags"])
+		assert.Equal(t, `"2"`, w.Header().Get("ETag"))
+	})
+
+	t.Run("Success - JSON Patch operations are applied in order", func(t *testing.T) {
+		w, response := serve(patchRequest(path, patch.JSONPatch, `[
+			{"op": "replace", "path": "/title", "value": "Renamed Post"},
+			{"op": "add", "path": "/tags/-", "value": "rust"},
+			{"op": "add", "path": "/tags/-", "value": "go"}
+		]`))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, "Renamed Post", data["title"])
+		assert.Len(t, data["tags"], 2)
+	})
+
+	t.Run("Fail - Failed JSON Patch tests", func(t *testing.T) {
+		w, response := serve(patchRequest(path, patch.JSONPatch, `[
+			{"op": "test", "path": "/title", "value": "Patched Post"},
+			{"op": "replace", "path": "/title", "value": "Never Applied"}
+		]`))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, []string{"patch"}, errorFields(response))
+	})
+
+	t.Run("Fail - Unknown fields", func(t *testing.T) {
+		w, _ := serve(patchRequest(path, patch.MergePatch, `{"user_id": 99}`))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	})
+
+	t.Run("Fail - Patched documents are validated", func(t *testing.T) {
+		w, _ := serve(patchRequest(path, patch.MergePatch, `{"title": "ab"}`))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("Fail - Plain JSON is not a patch", func(t *testing.T) {
+		w, _ := serve(patchRequest(path, "application/json", `{"title": "Plain Update"}`))
+		assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+	})
+
+	t.Run("Fail - Patches of a stale version", func(t *testing.T) {
+		req := patchRequest(path, patch.MergePatch, `{"title": "Stale Update"}`)
+		req.Header.Set("If-Match", `"1"`)
+		w, _ := serve(req)
+		assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+		_, response := send("GET", path, "", nil)
+		assert.Equal(t, "Renamed Post", response["data"].(map[string]interface{})["title"])
+	})
+
+	t.Run("Success - Merge patch clears the username", func(t *testing.T) {
+		w, response := serve(patchRequest("/api/v1/profile", patch.MergePatch, `{"username": "author"}`))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Equal(t, "author", response["data"].(map[string]interface{})["username"])
+
+		w, response = serve(patchRequest("/api/v1/profile", patch.MergePatch, `{"username": null}`))
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		data := response["data"].(map[string]interface{})
+		assert.Nil(t, data["username"])
+		assert.Equal(t, "Author User", data["name"])
+	})
+}
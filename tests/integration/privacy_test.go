@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/privacy"
+	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDataExport(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+	_, otherToken := registerUser(t, "Other User", "other@example.com", "user")
+	createPost(t, token, map[string]interface{}{
+		"title":   "Exported Post",
+		"content": "This post ends up in the archive.",
+	})
+
+	w, response := send("POST", "/api/v1/me/export", token, nil)
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	export := response["data"].(map[string]interface{})
+	exportPath := fmt.Sprintf("/api/v1/me/exports/%v", export["id"])
+
+	t.Run("Success - Pending exports are not requested twice", func(t *testing.T) {
+		assert.Equal(t, privacy.StatusPending, export["status"])
+
+		_, response := send("POST", "/api/v1/me/export", token, nil)
+		assert.Equal(t, export["id"], response["data"].(map[string]interface{})["id"])
+
+		w, _ := send("GET", exportPath+"/download", token, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("Success - The archive holds every section", func(t *testing.T) {
+		runJobs()
+
+		_, response := send("GET", exportPath, token, nil)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, privacy.StatusReady, data["status"])
+		assert.NotEmpty(t, data["download_url"])
+
+		w, _ := send("GET", exportPath+"/download", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+		archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+		assert.NoError(t, err)
+		files := make(map[string][]byte)
+		for _, file := range archive.File {
+			reader, _ := file.Open()
+			files[file.Name], _ = io.ReadAll(reader)
+			reader.Close()
+		}
+		for _, name := range []string{"profile", "posts", "comments", "reactions", "follows", "attachments", "moderation", "notifications", "manifest"} {
+			assert.Contains(t, files, name+".json")
+		}
+
+		var posts []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(files["posts.json"], &posts))
+		if assert.Len(t, posts, 1) {
+			assert.Equal(t, "Exported Post", posts[0]["title"])
+		}
+	})
+
+	t.Run("Fail - Exports of other users", func(t *testing.T) {
+		w, _ := send("GET", exportPath, otherToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w, _ = send("GET", exportPath+"/download", otherToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestAccountErasure(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	// sweep erases the accounts scheduled for deletion as if the grace period
+	// had passed.
+	sweep := func(userID uint) {
+		testDB.Model(&user.User{}).Where("id = ?", userID).Update("deletion_scheduled_at", time.Now().Add(-time.Minute))
+		_, err := testJobQueue.Enqueue(privacy.SweepJob{})
+		assert.NoError(t, err)
+		runJobs()
+	}
+	deleteAccount := func(token, password string) *http.Request {
+		return newRequest("DELETE", "/api/v1/me", token, map[string]string{"password": password})
+	}
+
+	userID, token := registerUser(t, "Leaving User", "leaving@example.com", "user")
+	p := createPost(t, token, map[string]interface{}{
+		"title":   "Remaining Post",
+		"content": "Kept when the account is anonymized.",
+	})
+
+	t.Run("Fail - Wrong password", func(t *testing.T) {
+		w, _ := serve(deleteAccount(token, "wrong-password"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("Success - Deleted accounts are disabled and can be restored", func(t *testing.T) {
+		w, response := serve(deleteAccount(token, "password123"))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, privacy.PolicyAnonymize, data["policy"])
+		assert.NotEmpty(t, data["delete_at"])
+
+		w, _ = send("GET", "/api/v1/profile", token, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		credentials := map[string]string{"email": "leaving@example.com", "password": "password123"}
+		w, _ = send("POST", "/api/v1/auth/login", "", credentials)
+		assert.NotEqual(t, http.StatusOK, w.Code)
+
+		w, _ = send("POST", "/api/v1/auth/restore", "", credentials)
+		assert.Equal(t, http.StatusOK, w.Code)
+		w, _ = send("GET", "/api/v1/profile", token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Success - Anonymized accounts keep their posts", func(t *testing.T) {
+		w, _ := serve(deleteAccount(token, "password123"))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		sweep(userID)
+
+		_, response := send("GET", postPath(p), "", nil)
+		author := response["data"].(map[string]interface{})["user"].(map[string]interface{})
+		assert.Equal(t, "Deleted user", author["name"])
+
+		w, _ = send("POST", "/api/v1/auth/login", "", map[string]string{"email": "leaving@example.com", "password": "password123"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("Success - The delete policy removes the account and its posts", func(t *testing.T) {
+		testConfig.ErasurePolicy = privacy.PolicyDelete
+		setupTestRouter()
+		defer func() {
+			testConfig.ErasurePolicy = privacy.PolicyAnonymize
+			setupTestRouter()
+		}()
+
+		userID, token := registerUser(t, "Deleted User", "deleted@example.com", "user")
+		p := createPost(t, token, map[string]interface{}{
+			"title":   "Removed Post",
+			"content": "Deleted together with the account.",
+		})
+
+		w, _ := serve(deleteAccount(token, "password123"))
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		sweep(userID)
+
+		w, _ = send("GET", postPath(p), "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		var count int64
+		testDB.Model(&user.User{}).Where("id = ?", userID).Count(&count)
+		assert.Equal(t, int64(0), count)
+		testDB.Model(&post.Post{}).Where("user_id = ?", userID).Count(&count)
+		assert.Equal(t, int64(0), count)
+	})
+}
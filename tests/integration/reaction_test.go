@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReactions(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	_, authorToken := registerUser(t, "Author User", "author@example.com", "user")
+	_, readerToken := registerUser(t, "Reader User", "reader@example.com", "user")
+
+	p := createPost(t, authorToken, map[string]interface{}{
+		"title":   "Reaction Post",
+		"content": "React to this post as you like.",
+	})
+	reactionsPath := postPath(p) + "/reactions/"
+
+	react := func(t *testing.T, method, reactionType, token string) map[string]interface{} {
+		w, response := send(method, reactionsPath+reactionType, token, nil)
+		assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		return response["data"].(map[string]interface{})
+	}
+
+	t.Run("Success - Reacting twice counts once", func(t *testing.T) {
+		react(t, "PUT", "like", readerToken)
+		summary := react(t, "PUT", "like", readerToken)
+		assert.Equal(t, map[string]interface{}{"like": float64(1)}, summary["reactions"])
+		assert.Equal(t, []interface{}{"like"}, summary["my_reactions"])
+	})
+
+	t.Run("Success - Reactions are counted per type and user", func(t *testing.T) {
+		react(t, "PUT", "love", readerToken)
+		summary := react(t, "PUT", "like", authorToken)
+		assert.Equal(t, map[string]interface{}{"like": float64(2), "love": float64(1)}, summary["reactions"])
+		assert.Equal(t, []interface{}{"like"}, summary["my_reactions"])
+
+		_, response := send("GET", postPath(p), readerToken, nil)
+		data := response["data"].(map[string]interface{})
+		assert.Equal(t, map[string]interface{}{"like": float64(2), "love": float64(1)}, data["reactions"])
+		assert.ElementsMatch(t, []interface{}{"like", "love"}, data["my_reactions"])
+	})
+
+	t.Run("Success - Removing a reaction twice is not an error", func(t *testing.T) {
+		react(t, "DELETE", "love", readerToken)
+		summary := react(t, "DELETE", "love", readerToken)
+		assert.Equal(t, map[string]interface{}{"like": float64(2)}, summary["reactions"])
+		assert.Equal(t, []interface{}{"like"}, summary["my_reactions"])
+	})
+
+	t.Run("Fail - Unknown reaction type", func(t *testing.T) {
+		w, response := send("PUT", reactionsPath+"meh", readerToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"type"}, errorFields(response))
+	})
+
+	t.Run("Fail - Unknown post", func(t *testing.T) {
+		w, response := send("PUT", "/api/v1/posts/999/reactions/like", readerToken, nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, []string{"post"}, errorFields(response))
+	})
+}
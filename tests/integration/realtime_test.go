@@ -0,0 +1,250 @@
+package integration
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/realtime"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRealtimeHub(t *testing.T) {
+	t.Run("Success - Events are delivered by topic", func(t *testing.T) {
+		hub := realtime.NewHub(10)
+		sub, missed := hub.Subscribe([]string{realtime.UserTopic(1)}, 0)
+		defer hub.Unsubscribe(sub)
+		assert.Empty(t, missed)
+
+		hub.Publish("post.created", []string{realtime.TopicPosts, realtime.UserTopic(2)}, nil)
+		hub.Publish("post.created", []string{realtime.TopicPosts, realtime.UserTopic(1)}, nil)
+
+		event := <-sub.Events()
+		assert.Equal(t, uint64(2), event.ID)
+		assert.Len(t, sub.Events(), 0)
+	})
+
+	t.Run("Success - Resume replays buffered events", func(t *testing.T) {
+		hub := realtime.NewHub(3)
+		for i := 0; i < 5; i++ {
+			hub.Publish("post.updated", []string{realtime.TopicPosts}, i)
+		}
+
+		sub, missed := hub.Subscribe([]string{realtime.TopicPosts}, 3)
+		hub.Unsubscribe(sub)
+		assert.Len(t, missed, 2)
+		assert.Equal(t, uint64(4), missed[0].ID)
+
+		sub, missed = hub.Subscribe([]string{realtime.TopicPosts}, 5)
+		hub.Unsubscribe(sub)
+		assert.Empty(t, missed)
+	})
+
+	t.Run("Success - Resume past the buffer resets", func(t *testing.T) {
+		hub := realtime.NewHub(3)
+		for i := 0; i < 5; i++ {
+			hub.Publish("post.updated", []string{realtime.TopicPosts}, i)
+		}
+
+		for _, lastEventID := range []uint64{1, 99} {
+			sub, missed := hub.Subscribe([]string{realtime.TopicPosts}, lastEventID)
+			hub.Unsubscribe(sub)
+			assert.Len(t, missed, 1)
+			assert.Equal(t, realtime.EventReset, missed[0].Type)
+			assert.Equal(t, uint64(5), missed[0].ID)
+		}
+	})
+
+	t.Run("Success - Slow subscribers are dropped", func(t *testing.T) {
+		hub := realtime.NewHub(0)
+		sub, _ := hub.Subscribe([]string{realtime.TopicPosts}, 0)
+		for i := 0; i < 100; i++ {
+			hub.Publish("post.created", []string{realtime.TopicPosts}, i)
+		}
+
+		received := 0
+		for range sub.Events() {
+			received++
+		}
+		assert.Less(t, received, 100)
+	})
+}
+
+func TestRealtimePostEvents(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+	_, otherToken := registerUser(t, "Other User", "other@example.com", "user")
+	_, moderatorToken := registerUser(t, "Moderator User", "moderator@example.com", "moderator")
+
+	sub, _ := testEventHub.Subscribe([]string{realtime.TopicPosts}, 0)
+	defer testEventHub.Unsubscribe(sub)
+
+	next := func(t *testing.T) realtime.Event {
+		select {
+		case event := <-sub.Events():
+			return event
+		case <-time.After(time.Second):
+			t.Fatal("no event was published")
+			return realtime.Event{}
+		}
+	}
+	moderate := func(t *testing.T, id interface{}, action string) {
+		w, _ := send("POST", fmt.Sprintf("/api/v1/moderation/posts/%v/actions", id), moderatorToken, map[string]string{"action": action})
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	p := createPost(t, token, map[string]interface{}{
+		"title":   "Streamed Post",
+		"content": "Subscribers see this post appear.",
+	})
+	event := next(t)
+	assert.Equal(t, "post.created", event.Type)
+
+	t.Run("Success - Hiding a post is sent as post.deleted", func(t *testing.T) {
+		moderate(t, p["id"], "hide")
+		event := next(t)
+		assert.Equal(t, "post.deleted", event.Type)
+		assert.Equal(t, realtime.DeletedPost{ID: uint(p["id"].(float64)), UserID: uint(p["user_id"].(float64))}, event.Data)
+	})
+
+	t.Run("Success - Restoring a post is sent as post.created", func(t *testing.T) {
+		moderate(t, p["id"], "restore")
+		event := next(t)
+		assert.Equal(t, "post.created", event.Type)
+		assert.Equal(t, "Streamed Post", event.Data.(*post.PostResponse).Title)
+	})
+
+	t.Run("Success - Edits hidden by the content filter are sent as post.deleted", func(t *testing.T) {
+		createPost(t, otherToken, map[string]interface{}{
+			"title":   "Original Post",
+			"content": "Words somebody else wrote first.",
+		})
+		assert.Equal(t, "post.created", next(t).Type)
+
+		w, _ := send("GET", postPath(p), token, nil)
+		req := newRequest("PUT", postPath(p), token, map[string]interface{}{"content": "Words somebody else wrote first."})
+		req.Header.Set("If-Match", w.Header().Get("ETag"))
+		w, _ = serve(req)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, "post.deleted", next(t).Type)
+
+		moderate(t, p["id"], "restore")
+		assert.Equal(t, "post.created", next(t).Type)
+	})
+
+	t.Run("Success - Hidden posts are not streamed", func(t *testing.T) {
+		moderate(t, p["id"], "hide")
+		assert.Equal(t, "post.deleted", next(t).Type)
+		moderate(t, p["id"], "hide")
+
+		w, _ := send("DELETE", postPath(p), token, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, sub.Events(), 0)
+	})
+}
+
+func TestRealtimeStream(t *testing.T) {
+	hub := realtime.NewHub(10)
+	hub.Publish("post.created", []string{realtime.TopicPosts}, map[string]int{"id": 1})
+	hub.Publish("post.created", []string{realtime.TopicPosts}, map[string]int{"id": 2})
+	hub.Publish("notification.created", []string{realtime.NotificationTopic(8)}, nil)
+	hub.Publish("notification.created", []string{realtime.NotificationTopic(7)}, map[string]int{"id": 3})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	}, realtime.NewHandler(hub, time.Minute).Stream)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	t.Run("Success - Replay after Last-Event-ID", func(t *testing.T) {
+		req, _ := http.NewRequest(http.MethodGet, server.URL+"/stream", nil)
+		req.Header.Set("Last-Event-ID", "1")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+		var ids []string
+		scanner := bufio.NewScanner(resp.Body)
+		for len(ids) < 2 && scanner.Scan() {
+			if id, ok := strings.CutPrefix(scanner.Text(), "id: "); ok {
+				ids = append(ids, id)
+			}
+		}
+		assert.Equal(t, []string{"2", "4"}, ids)
+	})
+
+	t.Run("Error - Unknown topic", func(t *testing.T) {
+		resp, err := http.Get(server.URL + "/stream?topics=notifications:8")
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
+
+func TestRealtimeStreamShutdown(t *testing.T) {
+	hub := realtime.NewHub(10)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", func(c *gin.Context) {
+		c.Set("user_id", uint(7))
+	}, realtime.NewHandler(hub, 50*time.Millisecond).Stream)
+	server := httptest.NewUnstartedServer(r)
+	server.Config.ReadTimeout = 200 * time.Millisecond
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Config.RegisterOnShutdown(hub.Close)
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/stream")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer resp.Body.Close()
+
+	// The stream outlives the server timeouts.
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+	deadline := time.After(600 * time.Millisecond)
+	heartbeats := 0
+wait:
+	for {
+		select {
+		case line, open := <-lines:
+			if !assert.True(t, open, "the stream ended early") {
+				return
+			}
+			if line == ": heartbeat" {
+				heartbeats++
+			}
+		case <-deadline:
+			break wait
+		}
+	}
+	assert.Greater(t, heartbeats, 5)
+
+	// Shutdown ends the stream instead of waiting for its deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	assert.NoError(t, server.Config.Shutdown(ctx))
+	for range lines {
+	}
+}
@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPostSlugs(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+
+	first := createPost(t, token, map[string]interface{}{
+		"title":   "Hello World!",
+		"content": "The first post with this title.",
+	})
+	second := createPost(t, token, map[string]interface{}{
+		"title":   "Hello, world",
+		"content": "The second post with this title.",
+	})
+
+	// rename updates the title of the first post at its current version.
+	rename := func(title string) (*httptest.ResponseRecorder, map[string]interface{}) {
+		w, _ := send("GET", postPath(first), token, nil)
+		req := newRequest("PUT", postPath(first), token, map[string]interface{}{"title": title})
+		req.Header.Set("If-Match", w.Header().Get("ETag"))
+		return serve(req)
+	}
+
+	t.Run("Success - Slugs are readable and unique", func(t *testing.T) {
+		assert.Equal(t, "hello-world", first["slug"])
+		assert.Equal(t, "hello-world-2", second["slug"])
+
+		accented := createPost(t, token, map[string]interface{}{
+			"title":   "Über Café Привет",
+			"content": "Accents and Cyrillic are transliterated.",
+		})
+		assert.Equal(t, "uber-cafe-privet", accented["slug"])
+	})
+
+	t.Run("Success - Old slugs redirect to the current one", func(t *testing.T) {
+		w, response := rename("Goodbye World")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "goodbye-world", response["data"].(map[string]interface{})["slug"])
+
+		w, _ = send("GET", "/api/v1/posts/by-slug/hello-world?ref=home", "", nil)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/api/v1/posts/by-slug/goodbye-world?ref=home", w.Header().Get("Location"))
+
+		w, response = send("GET", "/api/v1/posts/by-slug/goodbye-world", "", nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, first["id"], response["data"].(map[string]interface{})["id"])
+	})
+
+	t.Run("Success - Old slugs are not given to other posts", func(t *testing.T) {
+		third := createPost(t, token, map[string]interface{}{
+			"title":   "Hello World",
+			"content": "The third post with this title.",
+		})
+		assert.Equal(t, "hello-world-3", third["slug"])
+	})
+
+	t.Run("Success - A post gets its own old slug back", func(t *testing.T) {
+		w, response := rename("Hello World")
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "hello-world", response["data"].(map[string]interface{})["slug"])
+
+		w, _ = send("GET", "/api/v1/posts/by-slug/goodbye-world", "", nil)
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "/api/v1/posts/by-slug/hello-world", w.Header().Get("Location"))
+	})
+
+	t.Run("Fail - Unknown slug", func(t *testing.T) {
+		w, _ := send("GET", "/api/v1/posts/by-slug/missing", "", nil)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,85 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ardipermana59/go-template/pkg/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeS3 is a minimal path-style S3 stand-in that keeps objects in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+	types   map[string]string
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=key/") {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestS3Storage(t *testing.T) {
+	fake := &fakeS3{objects: map[string][]byte{}, types: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	store, err := storage.NewS3Storage(storage.S3Config{
+		Endpoint:  server.URL,
+		Bucket:    "uploads",
+		AccessKey: "key",
+		SecretKey: "secret",
+		PathStyle: true,
+	}, server.Client())
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	content := "hello attachment"
+	err = store.Put(ctx, "posts/1/a.txt", strings.NewReader(content), int64(len(content)), "text/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain", fake.types["/uploads/posts/1/a.txt"])
+	assert.Equal(t, server.URL+"/uploads/posts/1/a.txt", store.URL("posts/1/a.txt"))
+
+	reader, err := store.Open(ctx, "posts/1/a.txt")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(reader)
+	reader.Close()
+	assert.Equal(t, content, string(body))
+
+	assert.NoError(t, store.Delete(ctx, "posts/1/a.txt"))
+	assert.NoError(t, store.Delete(ctx, "posts/1/a.txt"))
+
+	_, err = store.Open(ctx, "posts/1/a.txt")
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+
+	_, err = store.Open(ctx, "../secret")
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/syndication"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type stubFeedService struct {
+	feed *syndication.Feed
+}
+
+func (s *stubFeedService) PostsFeed(selfURL string) (*syndication.Feed, apperror.AppErrors) {
+	feed := *s.feed
+	feed.SelfURL = selfURL
+	return &feed, nil
+}
+
+func (s *stubFeedService) UserFeed(userID uint, selfURL string) (*syndication.Feed, apperror.AppErrors) {
+	return s.PostsFeed(selfURL)
+}
+
+func TestPostsAtomFeed(t *testing.T) {
+	updated := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	service := &stubFeedService{feed: &syndication.Feed{
+		ID:      "http://example.com/api/v1/posts",
+		Title:   "Example",
+		Link:    "http://example.com/api/v1/posts",
+		Author:  "Example",
+		Updated: updated,
+		Entries: []syndication.Entry{{
+			ID:          "http://example.com/api/v1/posts/1",
+			Title:       "Fish & Chips",
+			Link:        "http://example.com/api/v1/posts/by-slug/fish-chips",
+			Author:      "Jane",
+			ContentHTML: "<p>Hello</p>",
+			Published:   updated,
+			Updated:     updated,
+		}},
+	}}
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/feeds/posts.atom", syndication.NewHandler(service, "http://example.com").PostsAtom)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/feeds/posts.atom", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, syndication.AtomContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "Wed, 01 May 2024 10:00:00 GMT", w.Header().Get("Last-Modified"))
+
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Links   []struct {
+			Rel  string `xml:"rel,attr"`
+			Href string `xml:"href,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			Title   string `xml:"title"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	assert.NoError(t, xml.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "2024-05-01T10:00:00Z", doc.Updated)
+	assert.Equal(t, "http://example.com/feeds/posts.atom", doc.Links[0].Href)
+	assert.Equal(t, "Fish & Chips", doc.Entries[0].Title)
+	assert.Equal(t, "<p>Hello</p>", doc.Entries[0].Content)
+
+	etag := w.Header().Get("ETag")
+	req := httptest.NewRequest(http.MethodGet, "/feeds/posts.atom", nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/feeds/posts.atom", nil)
+	req.Header.Set("If-Modified-Since", "Wed, 01 May 2024 10:00:00 GMT")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/feeds/posts.atom", nil)
+	req.Header.Set("If-Modified-Since", "Tue, 30 Apr 2024 10:00:00 GMT")
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
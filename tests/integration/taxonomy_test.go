@@ -0,0 +1,201 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTaxonomy(t *testing.T) {
+	setupTestDB(t)
+	testConfig.ContentFilterBlockedWords = []string{"casino"}
+	setupTestRouter()
+
+	_, adminToken := registerUser(t, "Admin User", "admin@example.com", "admin")
+	_, token := registerUser(t, "Author User", "author@example.com", "user")
+
+	w, response := send("POST", "/api/v1/admin/categories", adminToken, map[string]interface{}{"name": "Programming"})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	programming := response["data"].(map[string]interface{})
+	assert.Equal(t, "programming", programming["slug"])
+
+	w, response = send("POST", "/api/v1/admin/categories", adminToken, map[string]interface{}{
+		"name":      "Go Lang",
+		"parent_id": programming["id"],
+	})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	golang := response["data"].(map[string]interface{})
+
+	goPost := createPost(t, token, map[string]interface{}{
+		"title":       "Go Post",
+		"content":     "Goroutines and channels explained.",
+		"category_id": golang["id"],
+		"tags":        []string{" Go ", "web"},
+	})
+	createPost(t, token, map[string]interface{}{
+		"title":       "Rust Post",
+		"content":     "Ownership and borrowing explained.",
+		"category_id": programming["id"],
+		"tags":        []string{"rust"},
+	})
+	createPost(t, token, map[string]interface{}{
+		"title":   "Plain Post",
+		"content": "A post without any tag or category.",
+	})
+
+	t.Run("Success - Tags are normalised", func(t *testing.T) {
+		var names []string
+		for _, tag := range goPost["tags"].([]interface{}) {
+			names = append(names, tag.(map[string]interface{})["name"].(string))
+		}
+		assert.ElementsMatch(t, []string{"go", "web"}, names)
+	})
+
+	t.Run("Success - Filter posts by tag", func(t *testing.T) {
+		_, response := send("GET", "/api/v1/posts?tag=GO", "", nil)
+		assert.Equal(t, []string{"Go Post"}, postTitles(response))
+	})
+
+	t.Run("Success - Filter posts by category includes subcategories", func(t *testing.T) {
+		_, response := send("GET", "/api/v1/posts?category=programming", "", nil)
+		assert.ElementsMatch(t, []string{"Go Post", "Rust Post"}, postTitles(response))
+
+		_, response = send("GET", "/api/v1/posts?category=go-lang", "", nil)
+		assert.Equal(t, []string{"Go Post"}, postTitles(response))
+
+		_, response = send("GET", "/api/v1/posts?category=missing", "", nil)
+		assert.Empty(t, postTitles(response))
+	})
+
+	t.Run("Fail - Unknown category", func(t *testing.T) {
+		w, response := send("POST", "/api/v1/posts", token, map[string]interface{}{
+			"title":       "Lost Post",
+			"content":     "This category does not exist.",
+			"category_id": 999,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"category"}, errorFields(response))
+	})
+
+	t.Run("Fail - Category under its own descendant", func(t *testing.T) {
+		w, response := send("PUT", fmt.Sprintf("/api/v1/admin/categories/%v", programming["id"]), adminToken, map[string]interface{}{
+			"parent_id": golang["id"],
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"parent_id"}, errorFields(response))
+	})
+
+	t.Run("Success - Move a category back to the top", func(t *testing.T) {
+		path := fmt.Sprintf("/api/v1/admin/categories/%v", golang["id"])
+
+		w, response := send("PUT", path, adminToken, map[string]interface{}{"name": "Golang"})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, programming["id"], response["data"].(map[string]interface{})["parent_id"])
+
+		w, response = send("PUT", path, adminToken, map[string]interface{}{"root": true, "parent_id": programming["id"]})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"root"}, errorFields(response))
+
+		w, response = send("PUT", path, adminToken, map[string]interface{}{"root": true})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, response["data"].(map[string]interface{})["parent_id"])
+
+		_, response = send("GET", "/api/v1/posts?category=programming", "", nil)
+		assert.Equal(t, []string{"Rust Post"}, postTitles(response))
+
+		w, _ = send("PUT", path, adminToken, map[string]interface{}{"name": "Go Lang", "parent_id": programming["id"]})
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("Success - Merge tags", func(t *testing.T) {
+		golangPost := createPost(t, token, map[string]interface{}{
+			"title":   "Golang Post",
+			"content": "Interfaces are satisfied implicitly.",
+			"tags":    []string{"golang", "go"},
+		})
+		tagIDs := make(map[string]interface{})
+		for _, tag := range golangPost["tags"].([]interface{}) {
+			tag := tag.(map[string]interface{})
+			tagIDs[tag["name"].(string)] = tag["id"]
+		}
+
+		w, response := send("POST", "/api/v1/admin/tags/merge", adminToken, map[string]interface{}{
+			"source_ids": []interface{}{tagIDs["golang"]},
+			"target_id":  tagIDs["go"],
+		})
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "go", response["data"].(map[string]interface{})["slug"])
+
+		_, response = send("GET", "/api/v1/posts?tag=go", "", nil)
+		assert.ElementsMatch(t, []string{"Go Post", "Golang Post"}, postTitles(response))
+
+		_, response = send("GET", "/api/v1/posts?tag=golang", "", nil)
+		assert.Empty(t, postTitles(response))
+
+		_, response = send("GET", "/api/v1/tags", "", nil)
+		counts := make(map[string]float64)
+		for _, tag := range response["data"].([]interface{}) {
+			tag := tag.(map[string]interface{})
+			counts[tag["slug"].(string)] = tag["post_count"].(float64)
+		}
+		assert.NotContains(t, counts, "golang")
+		assert.Equal(t, float64(2), counts["go"])
+	})
+
+	t.Run("Success - Tag counts leave out hidden posts", func(t *testing.T) {
+		hidden := createPost(t, token, map[string]interface{}{
+			"title":   "Hidden Post",
+			"content": "A moderator hides this post.",
+			"tags":    []string{"rust", "secret"},
+		})
+		testDB.Table("posts").Where("id = ?", hidden["id"]).Update("hidden_at", time.Now())
+
+		_, response := send("GET", "/api/v1/posts?tag=rust", "", nil)
+		assert.Equal(t, []string{"Rust Post"}, postTitles(response))
+
+		_, response = send("GET", "/api/v1/tags", "", nil)
+		counts := make(map[string]float64)
+		for _, tag := range response["data"].([]interface{}) {
+			tag := tag.(map[string]interface{})
+			counts[tag["slug"].(string)] = tag["post_count"].(float64)
+		}
+		assert.Equal(t, float64(1), counts["rust"])
+		assert.NotContains(t, counts, "secret")
+	})
+
+	t.Run("Fail - Rejected posts leave no tags behind", func(t *testing.T) {
+		w, response := send("POST", "/api/v1/posts", token, map[string]interface{}{
+			"title":   "Lucky Post",
+			"content": "Visit the best casino in town.",
+			"tags":    []string{"gambling"},
+		})
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+		assert.Equal(t, []string{"content"}, errorFields(response))
+
+		req := newRequest("GET", postPath(goPost), token, nil)
+		w, _ = serve(req)
+		req = newRequest("PUT", postPath(goPost), token, map[string]interface{}{
+			"content": "Goroutines, channels and a casino.",
+			"tags":    []string{"betting"},
+		})
+		req.Header.Set("If-Match", w.Header().Get("ETag"))
+		w, _ = serve(req)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, w.Body.String())
+
+		var count int64
+		testDB.Table("tags").Where("slug IN ?", []string{"gambling", "betting"}).Count(&count)
+		assert.Zero(t, count)
+	})
+
+	t.Run("Fail - Merge into an unknown tag", func(t *testing.T) {
+		w, response := send("POST", "/api/v1/admin/tags/merge", adminToken, map[string]interface{}{
+			"source_ids": []uint{1},
+			"target_id":  999,
+		})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"tag"}, errorFields(response))
+	})
+}
@@ -0,0 +1,98 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	readerID, readerToken := registerUser(t, "Reader User", "reader@example.com", "user")
+	aliceID, aliceToken := registerUser(t, "Alice User", "alice@example.com", "user")
+	bobID, bobToken := registerUser(t, "Bob User", "bob@example.com", "user")
+	_, carolToken := registerUser(t, "Carol User", "carol@example.com", "user")
+
+	// Posts are written in this order; A2 and B2 share their creation time,
+	// so the later ID comes first.
+	base := time.Now().Add(-time.Hour).Truncate(time.Second)
+	posts := []struct {
+		title   string
+		token   string
+		created time.Time
+	}{
+		{"A1", aliceToken, base},
+		{"C1", carolToken, base.Add(time.Minute)},
+		{"B1", bobToken, base.Add(2 * time.Minute)},
+		{"A2", aliceToken, base.Add(3 * time.Minute)},
+		{"B2", bobToken, base.Add(3 * time.Minute)},
+		{"A3", aliceToken, base.Add(4 * time.Minute)},
+		{"B3", bobToken, base.Add(5 * time.Minute)},
+	}
+	ids := make(map[string]interface{})
+	for _, p := range posts {
+		data := createPost(t, p.token, map[string]interface{}{
+			"title":   "Post " + p.title,
+			"content": "The content of post " + p.title,
+		})
+		ids[p.title] = data["id"]
+		testDB.Model(&post.Post{}).Where("id = ?", data["id"]).Update("created_at", p.created)
+	}
+	testDB.Model(&post.Post{}).Where("id = ?", ids["A3"]).Update("hidden_at", time.Now())
+
+	for _, followeeID := range []uint{aliceID, bobID} {
+		w, _ := send("POST", fmt.Sprintf("/api/v1/users/%d/follow", followeeID), readerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+
+	t.Run("Success - Pages follow the cursor without gaps", func(t *testing.T) {
+		var titles []string
+		var pages []int
+		cursor := ""
+		for i := 0; i < 5; i++ {
+			w, response := send("GET", "/api/v1/feed?limit=2&cursor="+url.QueryEscape(cursor), readerToken, nil)
+			assert.Equal(t, http.StatusOK, w.Code)
+			page := postTitles(response)
+			titles = append(titles, page...)
+			pages = append(pages, len(page))
+
+			meta := response["meta"].(map[string]interface{})
+			if meta["has_more"] != true {
+				assert.Nil(t, meta["next_cursor"])
+				break
+			}
+			cursor = meta["next_cursor"].(string)
+		}
+
+		// Hidden posts and posts of users who are not followed are left out
+		// without cutting pages short.
+		assert.Equal(t, []string{"Post B3", "Post B2", "Post A2", "Post B1", "Post A1"}, titles)
+		assert.Equal(t, []int{2, 2, 1}, pages)
+	})
+
+	t.Run("Success - Unfollowed users leave the feed", func(t *testing.T) {
+		w, _ := send("DELETE", fmt.Sprintf("/api/v1/users/%d/follow", bobID), readerToken, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		_, response := send("GET", "/api/v1/feed", readerToken, nil)
+		assert.Equal(t, []string{"Post A2", "Post A1"}, postTitles(response))
+	})
+
+	t.Run("Fail - Invalid cursor", func(t *testing.T) {
+		w, response := send("GET", "/api/v1/feed?cursor=not-a-cursor", readerToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"cursor"}, errorFields(response))
+	})
+
+	t.Run("Fail - Following yourself", func(t *testing.T) {
+		w, _ := send("POST", fmt.Sprintf("/api/v1/users/%d/follow", readerID), readerToken, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,141 @@
+package integration
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/transfer"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransferRoundTrip(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	records := []*transfer.Record{
+		{
+			ExternalID:    "post-1",
+			Title:         "Fish, \"Chips\"",
+			Content:       "Line one\nline two",
+			ContentFormat: "markdown",
+			Category:      "food",
+			Tags:          []string{"uk", "fried food"},
+			Author:        "jane@example.com",
+			CreatedAt:     &createdAt,
+		},
+		{ExternalID: "post-2", Title: "Second", Content: "Without tags", Tags: []string{}},
+	}
+
+	for _, format := range transfer.Formats {
+		t.Run(format, func(t *testing.T) {
+			var buf bytes.Buffer
+			writer, err := transfer.NewWriter(format, &buf)
+			assert.NoError(t, err)
+			for _, record := range records {
+				assert.NoError(t, writer.Write(record))
+			}
+			assert.NoError(t, writer.Flush())
+
+			reader, err := transfer.NewReader(format, &buf)
+			assert.NoError(t, err)
+
+			first, _, err := reader.Read()
+			assert.NoError(t, err)
+			assert.Equal(t, records[0], first)
+
+			second, _, err := reader.Read()
+			assert.NoError(t, err)
+			assert.Equal(t, "post-2", second.ExternalID)
+			assert.Empty(t, second.Tags)
+
+			_, _, err = reader.Read()
+			assert.Equal(t, io.EOF, err)
+		})
+	}
+}
+
+func TestTransferReaderReportsBadLines(t *testing.T) {
+	input := "{\"external_id\":\"a\",\"title\":\"One\"}\n\nnot json\n{\"external_id\":\"b\",\"title\":\"Two\"}"
+	reader, err := transfer.NewReader(transfer.FormatNDJSON, strings.NewReader(input))
+	assert.NoError(t, err)
+
+	record, line, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "a", record.ExternalID)
+	assert.Equal(t, 1, line)
+
+	_, line, err = reader.Read()
+	assert.IsType(t, &transfer.SyntaxError{}, err)
+	assert.Equal(t, 3, line)
+
+	record, line, err = reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "b", record.ExternalID)
+	assert.Equal(t, 4, line)
+
+	_, err = transfer.NewReader(transfer.FormatCSV, strings.NewReader("title,content\n"))
+	assert.Error(t, err)
+}
+
+// slowTransferService writes exports and reads imports a little at a time.
+type slowTransferService struct {
+	transfer.Service
+}
+
+func (slowTransferService) Export(w io.Writer, format string, userID uint) error {
+	for i := 0; i < 5; i++ {
+		time.Sleep(100 * time.Millisecond)
+		if _, err := io.WriteString(w, "{}\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (slowTransferService) Import(r io.Reader, format string, userID uint) (*transfer.ImportResult, apperror.AppErrors) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, apperror.InvalidImportFile(err)
+	}
+	return &transfer.ImportResult{Created: strings.Count(string(data), "\n")}, nil
+}
+
+func TestTransferOutlivesServerTimeouts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := transfer.NewHandler(slowTransferService{})
+	r := gin.New()
+	r.GET("/export", handler.ExportAllPosts)
+	r.POST("/import", handler.ImportPosts)
+	server := httptest.NewUnstartedServer(r)
+	server.Config.ReadTimeout = 200 * time.Millisecond
+	server.Config.WriteTimeout = 200 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/export")
+	if assert.NoError(t, err) {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, strings.Repeat("{}\n", 5), string(body))
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(100 * time.Millisecond)
+			_, _ = io.WriteString(writer, "{}\n")
+		}
+		writer.Close()
+	}()
+	resp, err = http.Post(server.URL+"/import", "application/x-ndjson", reader)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+}
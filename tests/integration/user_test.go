@@ -2,17 +2,43 @@ package integration
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
+	"time"
 
 	"github.com/ardipermana59/go-template/config"
+	"github.com/ardipermana59/go-template/internal/analytics"
+	"github.com/ardipermana59/go-template/internal/attachment"
 	"github.com/ardipermana59/go-template/internal/auth"
+	"github.com/ardipermana59/go-template/internal/comment"
+	"github.com/ardipermana59/go-template/internal/common/etag"
+	"github.com/ardipermana59/go-template/internal/contentfilter"
+	"github.com/ardipermana59/go-template/internal/follow"
+	"github.com/ardipermana59/go-template/internal/health"
+	"github.com/ardipermana59/go-template/internal/job"
+	"github.com/ardipermana59/go-template/internal/mail"
+	"github.com/ardipermana59/go-template/internal/media"
 	"github.com/ardipermana59/go-template/internal/middleware"
+	"github.com/ardipermana59/go-template/internal/moderation"
+	"github.com/ardipermana59/go-template/internal/notification"
+	"github.com/ardipermana59/go-template/internal/outbox"
 	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/privacy"
+	"github.com/ardipermana59/go-template/internal/reaction"
+	"github.com/ardipermana59/go-template/internal/realtime"
+	"github.com/ardipermana59/go-template/internal/syndication"
+	"github.com/ardipermana59/go-template/internal/taxonomy"
+	"github.com/ardipermana59/go-template/internal/timeline"
+	"github.com/ardipermana59/go-template/internal/transfer"
 	"github.com/ardipermana59/go-template/internal/user"
+	"github.com/ardipermana59/go-template/internal/webhook"
 	"github.com/ardipermana59/go-template/pkg/database"
+	"github.com/ardipermana59/go-template/pkg/mailer"
+	"github.com/ardipermana59/go-template/pkg/storage"
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -22,6 +48,9 @@ var (
 	testDB         *gorm.DB
 	testRouter     *gin.Engine
 	testJWTService auth.JWTService
+	testConfig     *config.Config
+	testJobQueue   *job.Queue
+	testEventHub   *realtime.Hub
 )
 
 func setupTestDB(t *testing.T) {
@@ -33,27 +62,191 @@ func setupTestDB(t *testing.T) {
 	db, err := database.NewDatabase(cfg.GetDSN())
 	assert.NoError(t, err)
 
+	db.Exec("DROP TABLE IF EXISTS job_schedules")
+	db.Exec("DROP TABLE IF EXISTS jobs")
+	db.Exec("DROP TABLE IF EXISTS outbox_processed")
+	db.Exec("DROP TABLE IF EXISTS outbox_messages")
+	db.Exec("DROP TABLE IF EXISTS webhook_deliveries")
+	db.Exec("DROP TABLE IF EXISTS webhook_subscriptions")
+	db.Exec("DROP TABLE IF EXISTS notifications")
+	db.Exec("DROP TABLE IF EXISTS data_exports")
+	db.Exec("DROP TABLE IF EXISTS moderation_actions")
+	db.Exec("DROP TABLE IF EXISTS reports")
+	db.Exec("DROP TABLE IF EXISTS post_view_days")
+	db.Exec("DROP TABLE IF EXISTS attachments")
+	db.Exec("DROP TABLE IF EXISTS follows")
+	db.Exec("DROP TABLE IF EXISTS reactions")
+	db.Exec("DROP TABLE IF EXISTS comments")
+	db.Exec("DROP TABLE IF EXISTS post_slugs")
+	db.Exec("DROP TABLE IF EXISTS post_tags")
 	db.Exec("DROP TABLE IF EXISTS posts")
+	db.Exec("DROP TABLE IF EXISTS tags")
+	db.Exec("DROP TABLE IF EXISTS categories")
 	db.Exec("DROP TABLE IF EXISTS users")
+	db.Exec("DROP TABLE IF EXISTS image_variants")
+	db.Exec("DROP TABLE IF EXISTS images")
 
-	err = db.AutoMigrate(&user.User{}, &post.Post{})
+	err = db.AutoMigrate(&media.Image{}, &media.ImageVariant{}, &user.User{}, &taxonomy.Tag{}, &taxonomy.Category{}, &post.Post{}, &post.PostSlug{}, &comment.Comment{}, &reaction.Reaction{}, &follow.Follow{}, &attachment.Attachment{}, &privacy.DataExport{}, &analytics.PostViewDay{}, &moderation.Report{}, &moderation.Action{}, &notification.Notification{}, &webhook.Subscription{}, &webhook.Delivery{}, &outbox.Message{}, &outbox.Processed{}, &job.Record{}, &job.Schedule{})
 	assert.NoError(t, err)
 
 	testDB = db
+	testConfig = cfg
 	testJWTService = auth.NewJWTService(cfg.JWTSecret, cfg.JWTExpireHours)
 }
 
 func setupTestRouter() {
+	uploadDir, _ := os.MkdirTemp("", "uploads-")
+	fileStorage, _ := storage.NewLocalStorage(uploadDir, "/media")
+
+	imageWorker := media.NewWorker(1, 100)
+	mediaService := media.NewService(media.NewRepository(testDB), fileStorage, imageWorker,
+		testConfig.UploadMaxSizeMB, testConfig.ImageVariantSizes, testConfig.ImageVariantFormats)
+	imageWorker.Start(mediaService)
+
+	outboxDispatcher := outbox.NewDispatcher(outbox.NewRepository(testDB), outbox.Settings{
+		Interval:    time.Second,
+		MaxAttempts: 10,
+		RetryBase:   5 * time.Second,
+	})
+
+	jobRepo := job.NewRepository(testDB)
+	jobQueue := job.NewQueue(jobRepo, job.Settings{
+		Workers:      1,
+		PollInterval: time.Second,
+		MaxAttempts:  5,
+		RetryBase:    10 * time.Second,
+	})
+
 	userRepo := user.NewRepository(testDB)
-	userService := user.NewService(userRepo, testJWTService)
+	userService := user.NewService(userRepo, testJWTService, mediaService,
+		user.WithOutbox(outboxDispatcher),
+	)
 	userHandler := user.NewHandler(userService)
 
+	taxonomyRepo := taxonomy.NewRepository(testDB)
+	taxonomyService := taxonomy.NewService(taxonomyRepo)
+	taxonomyHandler := taxonomy.NewHandler(taxonomyService)
+
+	eventHub := realtime.NewHub(testConfig.RealtimeReplaySize)
+	realtimeHandler := realtime.NewHandler(eventHub, time.Duration(testConfig.RealtimeHeartbeatSeconds)*time.Second)
+
+	notificationRepo := notification.NewRepository(testDB)
+	notificationService := notification.NewService(notificationRepo, userRepo,
+		notification.WithPublisher(eventHub),
+	)
+	notificationHandler := notification.NewHandler(notificationService)
+
 	postRepo := post.NewRepository(testDB)
-	postService := post.NewService(postRepo)
+	moderationRepo := moderation.NewRepository(testDB)
+
+	commentRepo := comment.NewRepository(testDB)
+	commentService := comment.NewService(commentRepo, postRepo,
+		testConfig.CommentMaxDepth, time.Duration(testConfig.CommentEditWindowMinutes)*time.Minute)
+	commentHandler := comment.NewHandler(commentService)
+
+	reactionRepo := reaction.NewRepository(testDB)
+	reactionService := reaction.NewService(reactionRepo, postRepo, notificationService, testConfig.ReactionTypes)
+	reactionHandler := reaction.NewHandler(reactionService)
+
+	attachmentRepo := attachment.NewRepository(testDB)
+	attachmentService := attachment.NewService(attachmentRepo, postRepo, fileStorage,
+		testConfig.UploadMaxSizeMB, testConfig.UploadUserQuotaMB, testConfig.UploadAllowedTypes)
+	attachmentHandler := attachment.NewHandler(attachmentService)
+
+	analyticsService := analytics.NewService(analytics.NewRepository(testDB), postRepo,
+		time.Duration(testConfig.ViewDedupeWindowMinutes)*time.Minute)
+	analyticsHandler := analytics.NewHandler(analyticsService)
+
+	postService := post.NewService(postRepo, taxonomyService, mediaService,
+		post.WithEnricher(commentService),
+		post.WithEnricher(reactionService),
+		post.WithEnricher(analyticsService),
+		post.WithDeleteHook(commentService.DeletePostComments),
+		post.WithDeleteHook(reactionService.DeletePostReactions),
+		post.WithDeleteHook(attachmentService.DeletePostAttachments),
+		post.WithDeleteHook(analyticsService.DeletePostViews),
+		post.WithViewRecorder(analyticsService),
+		post.WithContentFilter(contentfilter.Pipeline{
+			contentfilter.WordList(testConfig.ContentFilterBlockedWords, contentfilter.Reject),
+			contentfilter.WordList(testConfig.ContentFilterFlaggedWords, contentfilter.Flag),
+			contentfilter.LinkLimit(testConfig.ContentFilterMaxLinks, contentfilter.Flag),
+			contentfilter.Duplicate(post.HashLookup(postRepo), contentfilter.Flag),
+		}),
+		post.WithReviewHook(moderation.ReviewHook(moderationRepo)),
+		post.WithSaveHook(notificationService.NotifyMentions),
+		post.WithEventPublisher(eventHub),
+		post.WithOutbox(outboxDispatcher),
+		post.WithBulkLimit(testConfig.BulkMaxOperations),
+		post.WithRequireVersion(testConfig.RequireIfMatch),
+	)
 	postHandler := post.NewHandler(postService)
 
+	webhookService := webhook.NewService(webhook.NewRepository(testDB), webhook.NewDispatcher(time.Minute), webhook.Settings{
+		MaxAttempts:  8,
+		RetryBase:    30 * time.Second,
+		DisableAfter: 20,
+		Timeout:      10 * time.Second,
+	})
+	webhookHandler := webhook.NewHandler(webhookService)
+	webhook.Subscribe(outboxDispatcher, webhookService, postService, userService)
+
+	mailTemplates, _ := mail.Templates("en")
+	mail.RegisterJobs(jobQueue, mailer.NewMemoryMailer("Go Template <no-reply@localhost>"))
+	mailService := mail.NewService(mailTemplates, jobQueue, "Go Template <no-reply@localhost>")
+	mail.Subscribe(outboxDispatcher, mailService, userService, mail.Site{Name: testConfig.AppName, URL: testConfig.AppURL}, "en")
+
+	moderationService := moderation.NewService(moderationRepo, postRepo, postService)
+	moderationHandler := moderation.NewHandler(moderationService)
+
+	followRepo := follow.NewRepository(testDB)
+	followService := follow.NewService(followRepo, userRepo, notificationService)
+	followHandler := follow.NewHandler(followService)
+
+	timelineService := timeline.NewService(timeline.NewFanOutOnReadStore(testDB), postService)
+	timelineHandler := timeline.NewHandler(timelineService)
+
+	syndicationService := syndication.NewService(postService, userService, testConfig.AppName, testConfig.AppURL, testConfig.FeedLimit)
+	syndicationHandler := syndication.NewHandler(syndicationService, testConfig.AppURL)
+
+	transferHandler := transfer.NewHandler(transfer.NewService(postService, taxonomyService))
+
+	privacyService := privacy.NewService(privacy.NewRepository(testDB), fileStorage, jobQueue, userService, postService,
+		testConfig.ErasurePolicy, time.Duration(testConfig.AccountDeletionGraceDays)*24*time.Hour, time.Duration(testConfig.DataExportExpireHours)*time.Hour,
+		privacy.WithSection("profile", userService),
+		privacy.WithSection("posts", postService),
+		privacy.WithSection("comments", commentService),
+		privacy.WithSection("reactions", reactionService),
+		privacy.WithSection("follows", followService),
+		privacy.WithSection("attachments", attachmentService),
+		privacy.WithSection("moderation", moderationService),
+		privacy.WithSection("notifications", notificationService),
+	)
+	privacy.RegisterJobs(jobQueue, privacyService)
+	privacyHandler := privacy.NewHandler(privacyService, testConfig.AppURL)
+
+	jobHandler := job.NewHandler(job.NewService(jobRepo, jobQueue))
+	testJobQueue = jobQueue
+	testEventHub = eventHub
+
 	gin.SetMode(gin.TestMode)
+	healthHandler := health.NewHandler(health.NewService(2*time.Second,
+		health.WithCheck("database", func(ctx context.Context) error {
+			return database.Ping(ctx, testDB)
+		}, 0),
+	))
+
 	r := gin.Default()
+	_ = r.SetTrustedProxies(testConfig.TrustedProxies)
+
+	r.GET("/healthz", healthHandler.Live)
+	r.GET("/readyz", healthHandler.Ready)
+	r.GET("/version", healthHandler.Version)
+
+	r.GET("/feeds/posts.rss", syndicationHandler.PostsRSS)
+	r.GET("/feeds/posts.atom", syndicationHandler.PostsAtom)
+	r.GET("/users/:user_id/feed.atom", syndicationHandler.UserAtom)
+
+	ifMatch := etag.IfMatch(testConfig.RequireIfMatch)
 
 	api := r.Group("/api/v1")
 	{
@@ -61,36 +254,120 @@ func setupTestRouter() {
 		{
 			authGroup.POST("/register", userHandler.Register)
 			authGroup.POST("/login", userHandler.Login)
+			authGroup.POST("/restore", userHandler.RestoreAccount)
 		}
 
 		protectedGroup := api.Group("")
 		protectedGroup.Use(middleware.AuthMiddleware(testJWTService))
+		protectedGroup.Use(middleware.ActiveAccountMiddleware(userService))
 		{
 			protectedGroup.GET("/profile", userHandler.GetProfile)
-			protectedGroup.PUT("/profile", userHandler.UpdateProfile)
+			protectedGroup.PUT("/profile", ifMatch, userHandler.UpdateProfile)
+			protectedGroup.PATCH("/profile", ifMatch, userHandler.PatchProfile)
 			protectedGroup.PUT("/change-password", userHandler.ChangePassword)
+			protectedGroup.PUT("/profile/avatar", userHandler.UpdateAvatar)
+			protectedGroup.DELETE("/profile/avatar", userHandler.DeleteAvatar)
+			protectedGroup.DELETE("/me", privacyHandler.DeleteAccount)
+			protectedGroup.POST("/me/export", privacyHandler.RequestExport)
+			protectedGroup.GET("/me/exports/:id", privacyHandler.GetExport)
+			protectedGroup.GET("/me/exports/:id/download", privacyHandler.DownloadExport)
+			protectedGroup.GET("/me/warnings", moderationHandler.GetMyWarnings)
+
+			protectedGroup.GET("/notifications", notificationHandler.GetNotifications)
+			protectedGroup.PUT("/notifications/read", notificationHandler.MarkAllRead)
+			protectedGroup.PUT("/notifications/:id/read", notificationHandler.MarkRead)
 
 			protectedGroup.GET("/posts/my", postHandler.GetMyPosts)
 			protectedGroup.POST("/posts", postHandler.CreatePost)
-			protectedGroup.PUT("/posts/:id", postHandler.UpdatePost)
+			protectedGroup.PUT("/posts/:id", ifMatch, postHandler.UpdatePost)
+			protectedGroup.PATCH("/posts/:id", ifMatch, postHandler.PatchPost)
+			protectedGroup.POST("/posts/bulk", postHandler.Bulk)
+			protectedGroup.GET("/posts/export", transferHandler.ExportMyPosts)
+			protectedGroup.POST("/posts/import", transferHandler.ImportPosts)
 			protectedGroup.DELETE("/posts/:id", postHandler.DeletePost)
+			protectedGroup.PUT("/posts/:id/cover", postHandler.UpdateCover)
+			protectedGroup.DELETE("/posts/:id/cover", postHandler.DeleteCover)
+			protectedGroup.GET("/posts/:id/stats", analyticsHandler.GetPostStats)
+
+			protectedGroup.POST("/posts/:id/comments", commentHandler.CreateComment)
+			protectedGroup.POST("/posts/:id/reports", moderationHandler.ReportPost)
+			protectedGroup.PUT("/comments/:id", commentHandler.UpdateComment)
+			protectedGroup.DELETE("/comments/:id", commentHandler.DeleteComment)
+
+			protectedGroup.POST("/users/:user_id/follow", followHandler.Follow)
+			protectedGroup.DELETE("/users/:user_id/follow", followHandler.Unfollow)
+			protectedGroup.GET("/feed", timelineHandler.GetFeed)
+
+			protectedGroup.PUT("/posts/:id/reactions/:type", reactionHandler.React)
+			protectedGroup.DELETE("/posts/:id/reactions/:type", reactionHandler.Unreact)
+
+			protectedGroup.POST("/posts/:id/attachments", attachmentHandler.Upload)
+			protectedGroup.DELETE("/attachments/:id", attachmentHandler.DeleteAttachment)
 		}
 
 		publicGroup := api.Group("")
+		publicGroup.Use(middleware.OptionalAuthMiddleware(testJWTService))
 		{
 			publicGroup.GET("/posts", postHandler.GetAllPosts)
 			publicGroup.GET("/posts/:id", postHandler.GetPostByID)
+			publicGroup.GET("/posts/by-slug/:slug", postHandler.GetPostBySlug)
 			publicGroup.GET("/users/:user_id/posts", postHandler.GetPostsByUserID)
+			publicGroup.GET("/users/:user_id/followers", followHandler.GetFollowers)
+			publicGroup.GET("/users/:user_id/following", followHandler.GetFollowing)
+			publicGroup.GET("/posts/:id/comments", commentHandler.GetPostComments)
+			publicGroup.GET("/comments/:id/replies", commentHandler.GetReplies)
+			publicGroup.GET("/tags", taxonomyHandler.GetTags)
+			publicGroup.GET("/categories", taxonomyHandler.GetCategories)
+			publicGroup.GET("/posts/:id/attachments", attachmentHandler.GetPostAttachments)
+		}
+
+		streamGroup := api.Group("/stream")
+		streamGroup.Use(middleware.QueryTokenMiddleware())
+		streamGroup.Use(middleware.AuthMiddleware(testJWTService))
+		streamGroup.Use(middleware.ActiveAccountMiddleware(userService))
+		{
+			streamGroup.GET("", realtimeHandler.Stream)
+			streamGroup.GET("/ws", realtimeHandler.WebSocket)
+		}
+
+		moderationGroup := api.Group("/moderation")
+		moderationGroup.Use(middleware.AuthMiddleware(testJWTService))
+		moderationGroup.Use(middleware.ActiveAccountMiddleware(userService))
+		moderationGroup.Use(middleware.RoleMiddleware("admin", "moderator"))
+		{
+			moderationGroup.GET("/queue", moderationHandler.GetQueue)
+			moderationGroup.POST("/posts/:id/actions", moderationHandler.TakeAction)
 		}
 
 		adminGroup := api.Group("/admin")
 		adminGroup.Use(middleware.AuthMiddleware(testJWTService))
+		adminGroup.Use(middleware.ActiveAccountMiddleware(userService))
 		adminGroup.Use(middleware.RoleMiddleware("admin"))
 		{
 			adminGroup.GET("/users", userHandler.GetAllUsers)
 			adminGroup.GET("/users/:id", userHandler.GetUserByID)
-			adminGroup.PUT("/users/:id", userHandler.UpdateUser)
+			adminGroup.PUT("/users/:id", ifMatch, userHandler.UpdateUser)
+			adminGroup.PATCH("/users/:id", ifMatch, userHandler.PatchUser)
 			adminGroup.DELETE("/users/:id", userHandler.DeleteUser)
+			adminGroup.GET("/posts/export", transferHandler.ExportAllPosts)
+
+			adminGroup.PUT("/tags/:id", taxonomyHandler.RenameTag)
+			adminGroup.POST("/tags/merge", taxonomyHandler.MergeTags)
+			adminGroup.POST("/categories", taxonomyHandler.CreateCategory)
+			adminGroup.PUT("/categories/:id", taxonomyHandler.UpdateCategory)
+			adminGroup.DELETE("/categories/:id", taxonomyHandler.DeleteCategory)
+
+			adminGroup.POST("/webhooks", webhookHandler.CreateSubscription)
+			adminGroup.GET("/webhooks", webhookHandler.GetSubscriptions)
+			adminGroup.GET("/webhooks/:id", webhookHandler.GetSubscription)
+			adminGroup.PUT("/webhooks/:id", webhookHandler.UpdateSubscription)
+			adminGroup.DELETE("/webhooks/:id", webhookHandler.DeleteSubscription)
+			adminGroup.GET("/webhooks/:id/deliveries", webhookHandler.GetDeliveries)
+			adminGroup.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", webhookHandler.Redeliver)
+
+			adminGroup.GET("/jobs", jobHandler.GetJobs)
+			adminGroup.GET("/jobs/:id", jobHandler.GetJob)
+			adminGroup.POST("/jobs/:id/retry", jobHandler.RetryJob)
 		}
 	}
 
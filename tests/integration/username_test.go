@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsernames(t *testing.T) {
+	setupTestDB(t)
+	setupTestRouter()
+
+	register := func(email, username string) (int, map[string]interface{}) {
+		w, response := send("POST", "/api/v1/auth/register", "", map[string]string{
+			"name":             "Some User",
+			"email":            email,
+			"username":         username,
+			"password":         "password123",
+			"password_confirm": "password123",
+		})
+		return w.Code, response
+	}
+
+	t.Run("Success - Register with a username", func(t *testing.T) {
+		code, response := register("alice@example.com", "alice")
+		assert.Equal(t, http.StatusCreated, code)
+		assert.Equal(t, "alice", response["data"].(map[string]interface{})["username"])
+	})
+
+	t.Run("Fail - Username already taken", func(t *testing.T) {
+		code, response := register("other@example.com", "alice")
+		assert.Equal(t, http.StatusBadRequest, code)
+		assert.Equal(t, []string{"username"}, errorFields(response))
+	})
+
+	t.Run("Fail - Concurrent registrations with the same username", func(t *testing.T) {
+		const attempts = 5
+		codes := make([]int, attempts)
+		fields := make([][]string, attempts)
+
+		var wg sync.WaitGroup
+		for i := 0; i < attempts; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				code, response := register(fmt.Sprintf("racer%d@example.com", i), "racer")
+				codes[i], fields[i] = code, errorFields(response)
+			}(i)
+		}
+		wg.Wait()
+
+		// Requests that lose the race to the unique index get the same
+		// error as those rejected by the check before the insert.
+		for i, code := range codes {
+			if code != http.StatusCreated {
+				assert.Equal(t, http.StatusBadRequest, code)
+				assert.Equal(t, []string{"username"}, fields[i])
+			}
+		}
+		assert.Contains(t, codes, http.StatusCreated)
+
+		var count int64
+		testDB.Table("users").Where("username = ?", "racer").Count(&count)
+		assert.Equal(t, int64(1), count)
+	})
+
+	t.Run("Fail - Update to a taken username", func(t *testing.T) {
+		_, token := registerUser(t, "Bob User", "bob@example.com", "user")
+		_, response := send("GET", "/api/v1/profile", token, nil)
+		version := response["data"].(map[string]interface{})["version"]
+
+		req := newRequest("PUT", "/api/v1/profile", token, map[string]string{"username": "alice"})
+		req.Header.Set("If-Match", fmt.Sprintf(`"%v"`, version))
+		w, response := serve(req)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, []string{"username"}, errorFields(response))
+	})
+}
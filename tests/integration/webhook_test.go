@@ -0,0 +1,269 @@
+package integration
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ardipermana59/go-template/internal/common/apperror"
+	"github.com/ardipermana59/go-template/internal/outbox"
+	"github.com/ardipermana59/go-template/internal/post"
+	"github.com/ardipermana59/go-template/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+// memoryWebhookRepository keeps webhooks in memory so deliveries can be
+// tested against httptest receivers without a database.
+type memoryWebhookRepository struct {
+	webhook.Repository
+	subscriptions []webhook.Subscription
+	deliveries    []webhook.Delivery
+}
+
+func (r *memoryWebhookRepository) FindActiveSubscriptions() ([]webhook.Subscription, error) {
+	var active []webhook.Subscription
+	for _, subscription := range r.subscriptions {
+		if subscription.Active {
+			active = append(active, subscription)
+		}
+	}
+	return active, nil
+}
+
+func (r *memoryWebhookRepository) CreateDeliveries(deliveries []webhook.Delivery) error {
+	for i := range deliveries {
+		deliveries[i].ID = uint(len(r.deliveries) + 1)
+		r.deliveries = append(r.deliveries, deliveries[i])
+	}
+	return nil
+}
+
+func (r *memoryWebhookRepository) FindDue(now time.Time, limit int) ([]webhook.Delivery, error) {
+	var due []webhook.Delivery
+	for _, delivery := range r.deliveries {
+		subscription := r.subscriptions[delivery.SubscriptionID-1]
+		if delivery.Status == webhook.DeliveryPending && subscription.Active && !delivery.NextAttemptAt.After(now) {
+			delivery.Subscription = subscription
+			due = append(due, delivery)
+		}
+	}
+	return due, nil
+}
+
+func (r *memoryWebhookRepository) Claim(delivery *webhook.Delivery, until time.Time) (bool, error) {
+	r.deliveries[delivery.ID-1].NextAttemptAt = &until
+	return true, nil
+}
+
+func (r *memoryWebhookRepository) SaveAttempt(delivery *webhook.Delivery) error {
+	r.deliveries[delivery.ID-1] = *delivery
+	return nil
+}
+
+func (r *memoryWebhookRepository) RecordSuccess(subscriptionID uint) error {
+	r.subscriptions[subscriptionID-1].ConsecutiveFailures = 0
+	return nil
+}
+
+func (r *memoryWebhookRepository) RecordFailure(subscriptionID uint, maxFailures int, at time.Time) (bool, error) {
+	subscription := &r.subscriptions[subscriptionID-1]
+	subscription.ConsecutiveFailures++
+	if subscription.Active && subscription.ConsecutiveFailures >= maxFailures {
+		subscription.Active = false
+		subscription.DisabledAt = &at
+		return true, nil
+	}
+	return false, nil
+}
+
+type countingTrigger struct {
+	count int
+}
+
+func (t *countingTrigger) Trigger() {
+	t.count++
+}
+
+// publicPosts serves every post the outbox subscriber asks for.
+type publicPosts struct{}
+
+func (publicPosts) GetPostByID(id, viewerID uint) (*post.PostResponse, apperror.AppErrors) {
+	return &post.PostResponse{ID: id}, nil
+}
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"evt_1"}`)
+	now := time.Unix(1700000000, 0)
+	signature := webhook.Sign("secret", now.Unix(), body)
+
+	assert.True(t, webhook.Verify("secret", signature, now.Unix(), body, 5*time.Minute, now))
+	assert.False(t, webhook.Verify("other", signature, now.Unix(), body, 5*time.Minute, now))
+	assert.False(t, webhook.Verify("secret", signature, now.Unix(), []byte(`{"id":"evt_2"}`), 5*time.Minute, now))
+	assert.False(t, webhook.Verify("secret", signature, now.Unix(), body, 5*time.Minute, now.Add(10*time.Minute)))
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhook.Backoff(30*time.Second, 1))
+	assert.Equal(t, 2*time.Minute, webhook.Backoff(30*time.Second, 3))
+	assert.Equal(t, 12*time.Hour, webhook.Backoff(30*time.Second, 40))
+}
+
+func TestWebhookSender(t *testing.T) {
+	t.Run("Success - Request is signed", func(t *testing.T) {
+		var verified bool
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			timestamp, _ := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+			verified = webhook.Verify("secret", r.Header.Get(webhook.HeaderSignature), timestamp, body, time.Minute, time.Now())
+			assert.Equal(t, "evt_1", r.Header.Get(webhook.HeaderEventID))
+			assert.Equal(t, "post.created", r.Header.Get(webhook.HeaderEvent))
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		status, err := webhook.NewSender(time.Second).Send(context.Background(), receiver.URL, "secret", "evt_1", "post.created", []byte(`{}`))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, status)
+		assert.True(t, verified)
+	})
+
+	t.Run("Error - Redirects are not followed", func(t *testing.T) {
+		receiver := httptest.NewServer(http.RedirectHandler("http://example.com", http.StatusFound))
+		defer receiver.Close()
+
+		status, err := webhook.NewSender(time.Second).Send(context.Background(), receiver.URL, "secret", "evt_1", "post.created", []byte(`{}`))
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusFound, status)
+	})
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	settings := webhook.Settings{MaxAttempts: 3, RetryBase: time.Minute, DisableAfter: 2, Timeout: time.Second}
+
+	t.Run("Success - Events reach subscribers of their type", func(t *testing.T) {
+		var received int32
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&received, 1)
+		}))
+		defer receiver.Close()
+
+		repo := &memoryWebhookRepository{subscriptions: []webhook.Subscription{
+			{ID: 1, URL: receiver.URL, Secret: "secret", Events: "post.created,post.deleted", Active: true},
+			{ID: 2, URL: receiver.URL, Secret: "secret", Events: "user.created", Active: true},
+		}}
+		trigger := &countingTrigger{}
+		service := webhook.NewService(repo, trigger, settings)
+
+		assert.NoError(t, service.Enqueue("evt_1", webhook.EventPostCreated, &post.PostResponse{ID: 1}))
+		assert.Len(t, repo.deliveries, 1)
+		assert.Equal(t, 1, trigger.count)
+
+		attempted, err := service.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 1, attempted)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&received))
+		assert.Equal(t, webhook.DeliverySucceeded, repo.deliveries[0].Status)
+		assert.NotNil(t, repo.deliveries[0].DeliveredAt)
+	})
+
+	t.Run("Error - Failures are retried and disable the subscription", func(t *testing.T) {
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		repo := &memoryWebhookRepository{subscriptions: []webhook.Subscription{
+			{ID: 1, URL: receiver.URL, Secret: "secret", Events: "post.updated", Active: true},
+		}}
+		service := webhook.NewService(repo, &countingTrigger{}, settings)
+		assert.NoError(t, service.Enqueue("evt_2", webhook.EventPostUpdated, map[string]int{"id": 1}))
+
+		_, err := service.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		delivery := repo.deliveries[0]
+		assert.Equal(t, webhook.DeliveryPending, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusInternalServerError, delivery.LastStatusCode)
+		assert.WithinDuration(t, time.Now().Add(time.Minute), *delivery.NextAttemptAt, 5*time.Second)
+
+		// The retry is not due yet.
+		attempted, _ := service.DeliverDue(context.Background())
+		assert.Equal(t, 0, attempted)
+
+		past := time.Now().Add(-time.Second)
+		repo.deliveries[0].NextAttemptAt = &past
+		_, err = service.DeliverDue(context.Background())
+		assert.NoError(t, err)
+		assert.Equal(t, 2, repo.deliveries[0].Attempts)
+		assert.False(t, repo.subscriptions[0].Active)
+		assert.NotNil(t, repo.subscriptions[0].DisabledAt)
+
+		// Nothing is sent to a disabled subscription.
+		repo.deliveries[0].NextAttemptAt = &past
+		attempted, _ = service.DeliverDue(context.Background())
+		assert.Equal(t, 0, attempted)
+	})
+
+	t.Run("Success - Sends cut short by shutdown are not failures", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.Copy(io.Discard, r.Body)
+			cancel()
+			<-r.Context().Done()
+		}))
+		defer receiver.Close()
+
+		repo := &memoryWebhookRepository{subscriptions: []webhook.Subscription{
+			{ID: 1, URL: receiver.URL, Secret: "secret", Events: "post.updated", Active: true},
+		}}
+		service := webhook.NewService(repo, &countingTrigger{}, settings)
+		assert.NoError(t, service.Enqueue("evt_3", webhook.EventPostUpdated, map[string]int{"id": 1}))
+		due := *repo.deliveries[0].NextAttemptAt
+
+		_, err := service.DeliverDue(ctx)
+		assert.NoError(t, err)
+		delivery := repo.deliveries[0]
+		assert.Equal(t, webhook.DeliveryPending, delivery.Status)
+		assert.Equal(t, 0, delivery.Attempts)
+		assert.Equal(t, due, *delivery.NextAttemptAt)
+		assert.Equal(t, 0, repo.subscriptions[0].ConsecutiveFailures)
+	})
+}
+
+func TestWebhookPostEvents(t *testing.T) {
+	repo := &memoryWebhookRepository{subscriptions: []webhook.Subscription{
+		{ID: 1, URL: "http://127.0.0.1", Secret: "secret", Events: "post.created,post.updated,post.deleted", Active: true},
+	}}
+	service := webhook.NewService(repo, &countingTrigger{}, webhook.Settings{MaxAttempts: 3, RetryBase: time.Minute, Timeout: time.Second})
+	dispatcher := outbox.NewDispatcher(&memoryOutboxRepository{}, outbox.Settings{Interval: time.Minute, MaxAttempts: 3, RetryBase: time.Minute})
+	webhook.Subscribe(dispatcher, service, publicPosts{}, nil)
+
+	// A post is edited, hidden by a moderator, edited while hidden, restored
+	// and finally deleted while hidden.
+	assert.NoError(t, dispatcher.Write(nil,
+		post.PostUpdated{PostID: 1, UserID: 2},
+		post.PostUpdated{PostID: 1, UserID: 2, Hidden: true},
+		post.PostUpdated{PostID: 1, UserID: 2, Hidden: true, WasHidden: true},
+		post.PostUpdated{PostID: 1, UserID: 2, WasHidden: true},
+		post.PostUpdated{PostID: 1, UserID: 2, Hidden: true},
+		post.PostDeleted{PostID: 1, UserID: 2, Hidden: true},
+	))
+	_, err := dispatcher.DispatchDue(context.Background())
+	assert.NoError(t, err)
+
+	var types []string
+	for _, delivery := range repo.deliveries {
+		types = append(types, delivery.EventType)
+	}
+	assert.Equal(t, []string{
+		webhook.EventPostUpdated,
+		webhook.EventPostDeleted,
+		webhook.EventPostCreated,
+		webhook.EventPostDeleted,
+	}, types)
+}